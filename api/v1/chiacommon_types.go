@@ -4,7 +4,11 @@ Copyright 2023 Chia Network Inc.
 
 package v1
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
 
 // CommonSpec represents the common configuration options for controller APIs at the top-spec level
 type CommonSpec struct {
@@ -18,15 +22,19 @@ type CommonSpec struct {
 	// +optional
 	Sidecars Sidecars `json:"sidecars,omitempty"`
 
-	//StorageConfig defines the Chia container's CHIA_ROOT storage config
-	// +optional
-	Storage *StorageConfig `json:"storage,omitempty"`
-
 	// ServiceType is the type of the service that governs this ChiaNode StatefulSet.
 	// +optional
 	// +kubebuilder:default="ClusterIP"
 	ServiceType string `json:"serviceType,omitempty"`
 
+	// Service configures the component's main Service. Its type overrides ServiceType.
+	// +optional
+	Service ServiceConfig `json:"service,omitempty"`
+
+	// NetworkPolicy configures a NetworkPolicy that restricts which clients can connect to the component's pods
+	// +optional
+	NetworkPolicy NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+
 	// ImagePullPolicy is the pull policy for containers in the pod
 	// +optional
 	// +kubebuilder:default="Always"
@@ -39,6 +47,11 @@ type CommonSpec struct {
 	// PodSecurityContext defines the security context for the pod
 	// +optional
 	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// TerminationGracePeriodSeconds is the amount of time chia services are given to stop cleanly before they are killed.
+	// Defaults depend on the component, ChiaNodes default to 5 minutes to protect the blockchain database.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
 }
 
 // Sidecars allows defining a list of containers that will share the kubernetes Pod alongside Chia containers
@@ -125,11 +138,230 @@ type SpecChiaExporter struct {
 	// +optional
 	Image string `json:"image,omitempty"`
 
+	// ImagePullPolicy is the pull policy for the chia-exporter image. defaults to the CR's imagePullPolicy.
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// LogLevel is chia-exporter's log level. defaults to chia-exporter's own default.
+	// +kubebuilder:validation:Enum=debug;info;warn;error
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// MetricsPort is the port chia-exporter listens on in the pod. The chia-exporter Service still serves metrics on 9914. defaults to 9914.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	MetricsPort *int32 `json:"metricsPort,omitempty"`
+
+	// Args replaces the chia-exporter image's default arguments
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Env are additional environment variables for the chia-exporter container
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Resources are the chia-exporter container's resource requests and limits. chia-exporter doesn't share the chia container's resources,
+	// so it has no requests or limits unless they're set here.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// SecurityContext is the chia-exporter container's security context. defaults to the chia container's security context.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// LivenessProbe sets a liveness probe on the chia-exporter container. The container has no liveness probe by default
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// ReadinessProbe sets a readiness probe on the chia-exporter container. The container has no readiness probe by default
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// StartupProbe sets a startup probe on the chia-exporter container. The container has no startup probe by default
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
 	// Labels is a map of string keys and values to attach to the chia exporter k8s Service
 	// +optional
 	ServiceLabels map[string]string `json:"serviceLabels,omitempty"`
+
+	// Service configures the chia-exporter Service
+	// +optional
+	Service ServiceConfig `json:"service,omitempty"`
+
+	// Monitor creates a Prometheus Operator ServiceMonitor or PodMonitor that scrapes chia-exporter.
+	// Ignored if the monitor kind's CRD wasn't installed when the operator started.
+	// +optional
+	Monitor *ChiaExporterMonitor `json:"monitor,omitempty"`
+}
+
+// ChiaExporterMonitor configures the Prometheus Operator monitor that scrapes a component's chia-exporter
+type ChiaExporterMonitor struct {
+	// Kind is the kind of monitor to create. ServiceMonitors scrape the chia-exporter Service, PodMonitors scrape the pods directly.
+	// defaults to ServiceMonitor.
+	// +kubebuilder:validation:Enum=ServiceMonitor;PodMonitor
+	// +kubebuilder:default=ServiceMonitor
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Namespace is the namespace to create the monitor in, like the namespace Prometheus selects monitors from. defaults to the CR's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Labels are added to the monitor, like the labels a Prometheus selects monitors by
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Interval is how often chia-exporter is scraped, like 30s. defaults to Prometheus' global scrape interval.
+	// +kubebuilder:validation:Pattern="^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// ScrapeTimeout is how long a scrape may take before it fails. defaults to Prometheus' global scrape timeout.
+	// +kubebuilder:validation:Pattern="^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$"
+	// +optional
+	ScrapeTimeout string `json:"scrapeTimeout,omitempty"`
+
+	// Relabelings are applied to the scrape target's labels before scraping
+	// +optional
+	Relabelings []RelabelConfig `json:"relabelings,omitempty"`
+
+	// MetricRelabelings are applied to scraped samples before they're ingested
+	// +optional
+	MetricRelabelings []RelabelConfig `json:"metricRelabelings,omitempty"`
+}
+
+// AlertingConfig configures the Prometheus Operator PrometheusRule with a component's alerts. The alerts select the component's metrics by the
+// k8s_chia_net_provenance target label, which the operator's chia-exporter monitors add.
+type AlertingConfig struct {
+	// Namespace is the namespace to create the PrometheusRule in, like the namespace Prometheus selects rules from. defaults to the CR's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Labels are added to the PrometheusRule, like the labels a Prometheus selects rules by
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// For is how long an alert's condition must hold before it fires. defaults to 15m.
+	// +kubebuilder:validation:Pattern="^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$"
+	// +optional
+	For string `json:"for,omitempty"`
+
+	// Severity is the severity label of the alerts. defaults to warning.
+	// +optional
+	Severity string `json:"severity,omitempty"`
+
+	// DisabledAlerts lists the names of alerts to leave out of the PrometheusRule
+	// +optional
+	DisabledAlerts []string `json:"disabledAlerts,omitempty"`
+}
+
+// RelabelConfig is a Prometheus relabeling rule
+type RelabelConfig struct {
+	// SourceLabels are the labels whose values are concatenated and matched against Regex
+	// +optional
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+
+	// Separator is placed between concatenated source label values. defaults to ;.
+	// +optional
+	Separator *string `json:"separator,omitempty"`
+
+	// TargetLabel is the label the result is written to by replace actions
+	// +optional
+	TargetLabel string `json:"targetLabel,omitempty"`
+
+	// Regex is matched against the concatenated source label values. defaults to (.*).
+	// +optional
+	Regex string `json:"regex,omitempty"`
+
+	// Modulus is the modulus taken of the hash of the source label values for hashmod actions
+	// +optional
+	Modulus uint64 `json:"modulus,omitempty"`
+
+	// Replacement is written to TargetLabel by replace actions, and may reference Regex capture groups. defaults to $1.
+	// +optional
+	Replacement *string `json:"replacement,omitempty"`
+
+	// Action is the relabeling action to perform. defaults to replace.
+	// +kubebuilder:validation:Enum=replace;Replace;keep;Keep;drop;Drop;hashmod;HashMod;labelmap;LabelMap;labeldrop;LabelDrop;labelkeep;LabelKeep;lowercase;Lowercase;uppercase;Uppercase;keepequal;KeepEqual;dropequal;DropEqual
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// NetworkPolicyConfig configures the NetworkPolicy for a component's pods. The peer port is open to all clients, the daemon and RPC ports
+// are limited to RPCFrom, and the chia-exporter port is limited to MetricsFrom. Other ports are closed unless allowed by AdditionalIngress.
+type NetworkPolicyConfig struct {
+	// Enabled says whether the operator creates a NetworkPolicy for the component's pods. Disabling it deletes the NetworkPolicy.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// RPCFrom lists the clients allowed to connect to the daemon and RPC ports. defaults to pods in the component's namespace.
+	// The operator is always allowed so it can query the component's RPC server.
+	// +optional
+	RPCFrom []networkingv1.NetworkPolicyPeer `json:"rpcFrom,omitempty"`
+
+	// MetricsFrom lists the clients allowed to connect to the chia-exporter port. defaults to pods in the MonitoringNamespace.
+	// +optional
+	MetricsFrom []networkingv1.NetworkPolicyPeer `json:"metricsFrom,omitempty"`
+
+	// MonitoringNamespace is the namespace allowed to connect to the chia-exporter port when MetricsFrom isn't set. defaults to monitoring.
+	// +optional
+	MonitoringNamespace string `json:"monitoringNamespace,omitempty"`
+
+	// AdditionalIngress lists more ingress rules for the component's pods, like rules for sidecar container ports
+	// +optional
+	AdditionalIngress []networkingv1.NetworkPolicyIngressRule `json:"additionalIngress,omitempty"`
+}
+
+// ServiceConfig configures one of the Services the operator creates for a component.
+// Labels and annotations are added to the component's AdditionalMetadata for this Service.
+type ServiceConfig struct {
+	AdditionalMetadata `json:",inline"`
+
+	// Enabled says whether the operator creates this Service. Disabling it deletes the Service. defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Type is the type of this Service. Ignored for headless Services.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// LoadBalancerIP requests a specific IP for LoadBalancer Services, on cloud providers that support it
+	// +optional
+	LoadBalancerIP string `json:"loadBalancerIP,omitempty"`
+
+	// LoadBalancerClass selects the load balancer implementation for LoadBalancer Services
+	// +optional
+	LoadBalancerClass *string `json:"loadBalancerClass,omitempty"`
+
+	// ExternalTrafficPolicy sets how NodePort and LoadBalancer Services route external traffic. Local preserves client source IPs.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
+	// SessionAffinity keeps a client's connections on the same pod when set to ClientIP
+	// +kubebuilder:validation:Enum=None;ClientIP
+	// +optional
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+
+	// IPFamilyPolicy sets whether this Service is single-stack or dual-stack
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+
+	// Ports lists the names of the ports this Service exposes, like daemon, peers, rpc, or metrics. defaults to all of the Service's ports.
+	// At least one of the listed ports must be one of the Service's ports.
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	Ports []ServicePortName `json:"ports,omitempty"`
 }
 
+// ServicePortName is the name of one of the ports of a Service the operator creates
+// +kubebuilder:validation:Enum=daemon;peers;rpc;metrics;dns;dns-tcp
+type ServicePortName string
+
 // ChiaSecretKey defines the name of a kubernetes secret and key in that namespace that contains the Chia mnemonic
 type ChiaSecretKey struct {
 	// SecretName is the name of the kubernetes secret containing a mnemonic key
@@ -161,7 +393,7 @@ storage:
 	hostPathVolume:
       path: "/home/user/storage/chiaroot"
 
-  plots:
+  plots: # ChiaHarvesters only
     persistentVolumeClaim:
 	  - claimName: "plot1"
 	  - claimName: "plot2"
@@ -175,10 +407,15 @@ type StorageConfig struct {
 	// Storage configuration for CHIA_ROOT
 	// +optional
 	ChiaRoot *ChiaRootConfig `json:"chiaRoot,omitempty"`
+}
+
+// DatabaseStorageConfig contains the storage configuration settings of components that keep a blockchain database, ChiaNodes and ChiaSeeders
+type DatabaseStorageConfig struct {
+	StorageConfig `json:",inline"`
 
-	// Storage configuration for harvester plots
+	// Storage configuration for a dedicated database volume, mounted over the db directory in CHIA_ROOT
 	// +optional
-	Plots *PlotsConfig `json:"plots,omitempty"`
+	Database *DatabaseConfig `json:"database,omitempty"`
 }
 
 // ChiaRootConfig optional config for CHIA_ROOT persistent storage, likely only needed for Chia full_nodes, but may help in startup time for other components.
@@ -193,16 +430,17 @@ type ChiaRootConfig struct {
 	HostPathVolume *HostPathVolumeConfig `json:"hostPathVolume,omitempty"`
 }
 
-// PlotsConfig optional config for harvester plots persistent storage, only needed for Chia harvesters.
-// Supports adding both PVCs and hostPath volumes.
-type PlotsConfig struct {
-	// PersistentVolumeClaim use an existing persistent volume claim to mount plot directories
+// DatabaseConfig optional storage configuration for the database directory, separate from the rest of CHIA_ROOT.
+// Both options may be specified but only one can be used, therefore PersistentVolumeClaims will be respected over HostPath volumes if both are specified.
+type DatabaseConfig struct {
+	// PersistentVolumeClaim use a persistent volume claim to store the database directory.
+	// ChiaNodes create a claim for each replica from the storageClass and resourceRequest, ChiaSeeders use the existing claimName.
 	// +optional
-	PersistentVolumeClaim []*PersistentVolumeClaimConfig `json:"persistentVolumeClaim,omitempty"`
+	PersistentVolumeClaim *PersistentVolumeClaimConfig `json:"persistentVolumeClaim,omitempty"`
 
-	// HostPathVolume use an existing directory on the host to mount plot directories
+	// HostPathVolume use an existing directory on the host to store the database directory
 	// +optional
-	HostPathVolume []*HostPathVolumeConfig `json:"hostPathVolume,omitempty"`
+	HostPathVolume *HostPathVolumeConfig `json:"hostPathVolume,omitempty"`
 }
 
 // PersistentVolumeClaimConfig config for PVC volumes in kubernetes
@@ -211,14 +449,25 @@ type PersistentVolumeClaimConfig struct {
 	// +optional
 	ClaimName string `json:"claimName,omitempty"`
 
-	// StorageClass is the name of a storage class for the PVC -- this is only relevant for ChiaNode objects and is ignored for others
+	// StorageClass is the name of a storage class for the PVC. For ChiaFarmers, ChiaWallets, ChiaTimelords, and ChiaSeeders, a CHIA_ROOT claim
+	// is created when a resourceRequest is given without a claimName, and uses the cluster's default storage class if this is empty.
+	// It is ignored for ChiaHarvesters.
 	// +kubebuilder:default=""
 	// +optional
 	StorageClass string `json:"storageClass,omitempty"`
 
-	// StorageClass is the amount of storage requested -- this is only relevant for ChiaNode objects and is ignored for others
+	// ResourceRequest is the amount of storage requested, as a kubernetes quantity like 100Gi. For ChiaFarmers, ChiaWallets, ChiaTimelords,
+	// and ChiaSeeders, a CHIA_ROOT claim is created when this is given without a claimName. It is ignored for ChiaHarvesters.
+	// +kubebuilder:validation:Pattern=`^(\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))))?$`
 	// +optional
 	ResourceRequest string `json:"resourceRequest,omitempty"`
+
+	// RetentionPolicy is whether a claim the operator created is kept or deleted when its CR is deleted. defaults to Retain.
+	// It is ignored for ChiaNodes and existing claims.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Retain
+	// +optional
+	RetentionPolicy appsv1.PersistentVolumeClaimRetentionPolicyType `json:"retentionPolicy,omitempty"`
 }
 
 // HostPathVolumeConfig config for hostPath volumes in kubernetes
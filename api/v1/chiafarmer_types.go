@@ -5,6 +5,7 @@ Copyright 2023 Chia Network Inc.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -14,6 +15,53 @@ type ChiaFarmerSpec struct {
 
 	// ChiaConfig defines the configuration options available to Chia component containers
 	ChiaConfig ChiaFarmerSpecChia `json:"chia"`
+
+	// Storage defines the Chia container's CHIA_ROOT storage config
+	// +optional
+	Storage *StorageConfig `json:"storage,omitempty"`
+
+	// RemoteHarvesters exposes the farmer's peer port outside of the cluster, and generates a bundle Secret with the certificate authority
+	// and farmer address that harvesters running outside of kubernetes need to connect to this farmer
+	// +optional
+	RemoteHarvesters *ChiaFarmerRemoteHarvesters `json:"remoteHarvesters,omitempty"`
+
+	// Alerting creates a Prometheus Operator PrometheusRule with the farmer's alerts.
+	// Ignored if the PrometheusRule CRD wasn't installed when the operator started.
+	// +optional
+	Alerting *ChiaFarmerAlerting `json:"alerting,omitempty"`
+}
+
+// ChiaFarmerAlerting configures a ChiaFarmer's alerts
+type ChiaFarmerAlerting struct {
+	AlertingConfig `json:",inline"`
+
+	// MinHarvesters is the number of connected harvesters below which the farmer alerts. defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinHarvesters *int32 `json:"minHarvesters,omitempty"`
+}
+
+// ChiaFarmerRemoteHarvesters defines how the farmer is exposed to harvesters outside of the cluster
+type ChiaFarmerRemoteHarvesters struct {
+	// ServiceType is the type of the Service that exposes the farmer's peer port. defaults to LoadBalancer.
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	// +kubebuilder:default=LoadBalancer
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// NodePort is the port the farmer is exposed on for NodePort Services. kubernetes assigns one if unset.
+	// +optional
+	NodePort *int32 `json:"nodePort,omitempty"`
+
+	// ExternalAddress is the hostname or IP address remote harvesters use to reach the farmer. Defaults to the LoadBalancer's ingress address,
+	// and is required for NodePort Services.
+	// +optional
+	ExternalAddress string `json:"externalAddress,omitempty"`
+
+	// SecretName is the name of the generated bundle Secret. defaults to <farmer name>-farmer-remote-harvester.
+	// Must not be the same as the farmer's caSecretName.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
 }
 
 // ChiaFarmerSpecChia defines the desired state of Chia component configuration
@@ -26,6 +74,48 @@ type ChiaFarmerSpecChia struct {
 	// FullNodePeer defines the farmer's full_node peer in host:port format.
 	// In Kubernetes this is likely to be <node service name>.<namespace>.svc.cluster.local:8555
 	FullNodePeer string `json:"fullNodePeer"`
+
+	// XCHTargetAddress is the address farming rewards are paid to, rendered into the farmer's xch_target_address in the chia config on every start
+	// +kubebuilder:validation:Pattern=`^t?xch1[02-9ac-hj-np-z]{58}$`
+	// +optional
+	XCHTargetAddress string `json:"xchTargetAddress,omitempty"`
+
+	// PoolXCHTargetAddress is the address pool rewards for solo plots are paid to, rendered into the pool's xch_target_address in the chia config.
+	// Defaults to XCHTargetAddress.
+	// +kubebuilder:validation:Pattern=`^t?xch1[02-9ac-hj-np-z]{58}$`
+	// +optional
+	PoolXCHTargetAddress string `json:"poolXCHTargetAddress,omitempty"`
+
+	// PoolList defines the plot NFTs this farmer farms with a pool, rendered into the pool_list in the chia config on every start
+	// +optional
+	PoolList []ChiaFarmerPoolConfig `json:"poolList,omitempty"`
+}
+
+// ChiaFarmerPoolConfig defines a plot NFT's pool configuration, an entry in the pool_list of the chia config
+type ChiaFarmerPoolConfig struct {
+	// LauncherID is the plot NFT's launcher ID
+	// +kubebuilder:validation:Pattern=`^(0x)?[0-9a-fA-F]{64}$`
+	LauncherID string `json:"launcherID"`
+
+	// PoolContractAddress is the plot NFT's pool contract address, which plots for this pool were created with
+	// +kubebuilder:validation:Pattern=`^t?xch1[02-9ac-hj-np-z]{58}$`
+	PoolContractAddress string `json:"poolContractAddress"`
+
+	// OwnerPublicKey is the plot NFT owner's public key
+	// +kubebuilder:validation:Pattern=`^(0x)?[0-9a-fA-F]{96}$`
+	OwnerPublicKey string `json:"ownerPublicKey"`
+
+	// PoolURL is the URL of the pool the plot NFT has joined
+	PoolURL string `json:"poolURL"`
+
+	// TargetPuzzleHash is the pool's target puzzle hash, pool rewards are paid to it
+	// +kubebuilder:validation:Pattern=`^(0x)?[0-9a-fA-F]{64}$`
+	TargetPuzzleHash string `json:"targetPuzzleHash"`
+
+	// PayoutAddress is the address the pool pays this farmer's share to. Defaults to XCHTargetAddress.
+	// +kubebuilder:validation:Pattern=`^t?xch1[02-9ac-hj-np-z]{58}$`
+	// +optional
+	PayoutAddress string `json:"payoutAddress,omitempty"`
 }
 
 // ChiaFarmerStatus defines the observed state of ChiaFarmer
@@ -33,6 +123,25 @@ type ChiaFarmerStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// RemoteHarvesters reports the farmer's external address and bundle Secret for harvesters outside of the cluster
+	// +optional
+	RemoteHarvesters *ChiaFarmerRemoteHarvestersStatus `json:"remoteHarvesters,omitempty"`
+
+	// Conditions report problems with the farmer's configuration or cluster that the operator works around. Each is removed once resolved.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ChiaFarmerRemoteHarvestersStatus reports how harvesters outside of the cluster connect to the farmer
+type ChiaFarmerRemoteHarvestersStatus struct {
+	// FarmerAddress is the host:port remote harvesters connect to, empty until the external address is known
+	// +optional
+	FarmerAddress string `json:"farmerAddress,omitempty"`
+
+	// SecretName is the name of the bundle Secret, which is created once the external address is known
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -34,6 +35,13 @@ spec:
     timezone: "UTC"
     logLevel: "INFO"
     fullNodePeer: "node.default.svc.cluster.local:58444"
+    xchTargetAddress: "txch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqm6ksh7qddh"
+    poolList:
+      - launcherID: "0x0000000000000000000000000000000000000000000000000000000000000001"
+        poolContractAddress: "txch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqm6ksh7qddh"
+        ownerPublicKey: "0x000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002"
+        poolURL: "https://pool.example.com"
+        targetPuzzleHash: "0x0000000000000000000000000000000000000000000000000000000000000003"
     secretKey:
       name: "chiakey-secret"
       key: "key.txt"
@@ -41,6 +49,20 @@ spec:
     enabled: true
     serviceLabels:
       network: testnet
+    monitor:
+      kind: PodMonitor
+      namespace: monitoring
+      interval: 30s
+      labels:
+        release: prometheus
+      metricRelabelings:
+        - sourceLabels: [__name__]
+          regex: go_.*
+          action: drop
+  remoteHarvesters:
+    serviceType: NodePort
+    nodePort: 30447
+    externalAddress: farmer.example.com
 `)
 
 	var (
@@ -51,6 +73,7 @@ spec:
 		networkPort          uint16 = 8080
 		introducerAddress           = "introducer.svc.cluster.local"
 		dnsIntroducerAddress        = "dns-introducer.svc.cluster.local"
+		nodePort             int32  = 30447
 	)
 	expect := ChiaFarmer{
 		TypeMeta: metav1.TypeMeta{
@@ -78,7 +101,17 @@ spec:
 					IntroducerAddress:    &introducerAddress,
 					DNSIntroducerAddress: &dnsIntroducerAddress,
 				},
-				FullNodePeer: "node.default.svc.cluster.local:58444",
+				FullNodePeer:     "node.default.svc.cluster.local:58444",
+				XCHTargetAddress: "txch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqm6ksh7qddh",
+				PoolList: []ChiaFarmerPoolConfig{
+					{
+						LauncherID:          "0x0000000000000000000000000000000000000000000000000000000000000001",
+						PoolContractAddress: "txch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqm6ksh7qddh",
+						OwnerPublicKey:      "0x000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002",
+						PoolURL:             "https://pool.example.com",
+						TargetPuzzleHash:    "0x0000000000000000000000000000000000000000000000000000000000000003",
+					},
+				},
 				SecretKey: ChiaSecretKey{
 					Name: "chiakey-secret",
 					Key:  "key.txt",
@@ -90,8 +123,28 @@ spec:
 					ServiceLabels: map[string]string{
 						"network": "testnet",
 					},
+					Monitor: &ChiaExporterMonitor{
+						Kind:      "PodMonitor",
+						Namespace: "monitoring",
+						Interval:  "30s",
+						Labels: map[string]string{
+							"release": "prometheus",
+						},
+						MetricRelabelings: []RelabelConfig{
+							{
+								SourceLabels: []string{"__name__"},
+								Regex:        "go_.*",
+								Action:       "drop",
+							},
+						},
+					},
 				},
 			},
+			RemoteHarvesters: &ChiaFarmerRemoteHarvesters{
+				ServiceType:     corev1.ServiceTypeNodePort,
+				NodePort:        &nodePort,
+				ExternalAddress: "farmer.example.com",
+			},
 		},
 	}
 
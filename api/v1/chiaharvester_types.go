@@ -5,6 +5,7 @@ Copyright 2023 Chia Network Inc.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -14,6 +15,46 @@ type ChiaHarvesterSpec struct {
 
 	// ChiaConfig defines the configuration options available to Chia component containers
 	ChiaConfig ChiaHarvesterSpecChia `json:"chia"`
+
+	// Storage defines the Chia container's CHIA_ROOT and plot storage config
+	// +optional
+	Storage *ChiaHarvesterStorageConfig `json:"storage,omitempty"`
+
+	// Replicas is the number of harvester shards. Each shard runs in its own Deployment with a disjoint subset of the plot volumes,
+	// and reports to the same farmer. Ignored if the harvester runs as a DaemonSet. defaults to 1.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Sharding configures how plot volumes are assigned to harvester shards when replicas is more than 1
+	// +optional
+	Sharding *ChiaHarvesterSharding `json:"sharding,omitempty"`
+
+	// PlotCheck schedules a CronJob that runs `chia plots check` against this harvester's plots
+	// +optional
+	PlotCheck *ChiaHarvesterPlotCheck `json:"plotCheck,omitempty"`
+
+	// DaemonSet runs the harvester as a DaemonSet, with a harvester pod on each kubernetes node matching the nodeSelector, instead of a Deployment.
+	// Each harvester pod discovers the plot directories on its own node.
+	// +optional
+	DaemonSet *ChiaHarvesterDaemonSet `json:"daemonSet,omitempty"`
+
+	// Alerting creates a Prometheus Operator PrometheusRule with the harvester's alerts.
+	// Ignored if the PrometheusRule CRD wasn't installed when the operator started.
+	// +optional
+	Alerting *ChiaHarvesterAlerting `json:"alerting,omitempty"`
+}
+
+// ChiaHarvesterAlerting configures a ChiaHarvester's alerts
+type ChiaHarvesterAlerting struct {
+	AlertingConfig `json:",inline"`
+
+	// PlotCountDropPercent is how much the harvester's plot count may drop within an hour before it alerts, in percent. defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	PlotCountDropPercent *int32 `json:"plotCountDropPercent,omitempty"`
 }
 
 // ChiaHarvesterSpecChia defines the desired state of Chia component configuration
@@ -25,11 +66,210 @@ type ChiaHarvesterSpecChia struct {
 	FarmerAddress string `json:"farmerAddress"`
 }
 
+// ChiaHarvesterStorageConfig contains the storage configuration settings of a ChiaHarvester
+type ChiaHarvesterStorageConfig struct {
+	StorageConfig `json:",inline"`
+
+	// Storage configuration for harvester plots
+	// +optional
+	Plots *PlotsConfig `json:"plots,omitempty"`
+}
+
+// PlotsConfig optional config for harvester plots persistent storage, only needed for Chia harvesters.
+// Supports adding both PVCs and hostPath volumes.
+type PlotsConfig struct {
+	// PersistentVolumeClaim use an existing persistent volume claim to mount plot directories
+	// +optional
+	PersistentVolumeClaim []*PersistentVolumeClaimConfig `json:"persistentVolumeClaim,omitempty"`
+
+	// HostPathVolume use an existing directory on the host to mount plot directories
+	// +optional
+	HostPathVolume []*HostPathVolumeConfig `json:"hostPathVolume,omitempty"`
+
+	// Volumes mount plot directories from any kind of kubernetes volume source, such as nfs, csi, iscsi or ephemeral volumes
+	// +optional
+	Volumes []PlotVolumeConfig `json:"volumes,omitempty"`
+}
+
+// PlotVolumeConfig mounts a plot directory from a kubernetes volume source
+type PlotVolumeConfig struct {
+	// Name identifies the plot volume, and must be unique among a harvester's plot volumes
+	Name string `json:"name"`
+
+	// VolumeSource is the kubernetes volume source that holds the plots
+	corev1.VolumeSource `json:",inline"`
+
+	// ReadOnly mounts the volume read-only. Harvesters only need to read plots, so this defaults to true.
+	// +kubebuilder:default=true
+	// +optional
+	ReadOnly *bool `json:"readOnly,omitempty"`
+
+	// SubPath mounts a sub-directory of the volume instead of its root
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+
+	// MountPath is the path the volume is mounted at in the harvester container, which is added to the harvester's plot directories.
+	// Defaults to /plots/<name>.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// ShardingStrategy is how plot volumes are assigned to harvester shards
+// +kubebuilder:validation:Enum=RoundRobin;Label
+type ShardingStrategy string
+
+const (
+	// ShardingStrategyRoundRobin assigns plot volumes to shards in turn, in the order they're listed in the storage config
+	ShardingStrategyRoundRobin ShardingStrategy = "RoundRobin"
+
+	// ShardingStrategyLabel assigns plot PersistentVolumeClaims to the shard given by a label on the claim
+	ShardingStrategyLabel ShardingStrategy = "Label"
+)
+
+// ChiaHarvesterSharding defines how plot volumes are assigned to harvester shards
+type ChiaHarvesterSharding struct {
+	// Strategy is how plot volumes are assigned to shards. defaults to RoundRobin.
+	// +kubebuilder:default=RoundRobin
+	// +optional
+	Strategy ShardingStrategy `json:"strategy,omitempty"`
+
+	// LabelKey is the PersistentVolumeClaim label that gives the index of the shard a plot claim is mounted in, with the Label strategy.
+	// Plot volumes without a valid shard index are assigned round-robin.
+	// +kubebuilder:default="k8s.chia.net/harvester-shard"
+	// +optional
+	LabelKey string `json:"labelKey,omitempty"`
+}
+
+// ChiaHarvesterPlotCheck defines the schedule and options for scheduled plot integrity checks
+type ChiaHarvesterPlotCheck struct {
+	// Schedule is the cron schedule the plot check runs on
+	Schedule string `json:"schedule"`
+
+	// Challenges is the number of challenges each plot is checked with, passed to `chia plots check -n`
+	// +kubebuilder:default=30
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Challenges *int32 `json:"challenges,omitempty"`
+
+	// LowQualityThreshold is the percentage of challenges a plot needs to find proofs for to not be reported as low quality.
+	// Plots that find no proofs at all are always reported as bad.
+	// +kubebuilder:default=50
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	LowQualityThreshold *int32 `json:"lowQualityThreshold,omitempty"`
+
+	// Resources defines the compute resources for the plot check container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ChiaHarvesterDaemonSet defines how harvesters running as a DaemonSet discover the plot directories on their kubernetes node.
+// Node labels and annotations are checked first, and nodes without any fall back to the plotDirectoryGlob, or the whole hostPath.
+type ChiaHarvesterDaemonSet struct {
+	// HostPath is the directory on each kubernetes node that plot directories are discovered under.
+	// It is mounted read-only, and disks mounted under it after the harvester starts are propagated to the harvester container.
+	HostPath string `json:"hostPath"`
+
+	// PlotDirectoryGlob is a glob relative to hostPath that matches the plot directories on each node, for example "disk*/plots".
+	// The glob is expanded when the harvester pod starts.
+	// +optional
+	PlotDirectoryGlob string `json:"plotDirectoryGlob,omitempty"`
+
+	// PlotDirectoryAnnotation is the key of a kubernetes Node annotation that lists the node's plot directories, as comma-separated paths under hostPath
+	// +optional
+	PlotDirectoryAnnotation string `json:"plotDirectoryAnnotation,omitempty"`
+
+	// PlotDirectoryLabelPrefix is a kubernetes Node label key prefix. Each of a node's labels with the prefix adds the directory under hostPath
+	// named by the rest of the label key, for example the label "plots.k8s.chia.net/disk1" adds "<hostPath>/disk1".
+	// +optional
+	PlotDirectoryLabelPrefix string `json:"plotDirectoryLabelPrefix,omitempty"`
+}
+
 // ChiaHarvesterStatus defines the observed state of ChiaHarvester
 type ChiaHarvesterStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// PlotCheck reports the results of the most recent scheduled plot check
+	// +optional
+	PlotCheck *ChiaHarvesterPlotCheckStatus `json:"plotCheck,omitempty"`
+
+	// Nodes reports the harvester pod on each kubernetes node, when the harvester runs as a DaemonSet
+	// +optional
+	Nodes []ChiaHarvesterNodeStatus `json:"nodes,omitempty"`
+
+	// Shards reports the plot volumes assigned to each harvester shard, when replicas is more than 1
+	// +optional
+	Shards []ChiaHarvesterShardStatus `json:"shards,omitempty"`
+
+	// Conditions report problems with the harvester's configuration or cluster that the operator works around. Each is removed once resolved.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ChiaHarvesterShardStatus reports the plot volumes assigned to a harvester shard
+type ChiaHarvesterShardStatus struct {
+	// Index is the shard's index
+	Index int32 `json:"index"`
+
+	// DeploymentName is the name of the shard's Deployment
+	DeploymentName string `json:"deploymentName"`
+
+	// PlotVolumes are the plot volumes mounted in the shard, by claim name, host path, or volume name
+	// +optional
+	PlotVolumes []string `json:"plotVolumes,omitempty"`
+}
+
+// ChiaHarvesterNodeStatus reports the harvester pod running on a kubernetes node
+type ChiaHarvesterNodeStatus struct {
+	// NodeName is the name of the kubernetes node
+	NodeName string `json:"nodeName"`
+
+	// PodName is the name of the harvester pod on the node
+	PodName string `json:"podName"`
+
+	// Ready says whether the harvester pod on the node is ready
+	Ready bool `json:"ready"`
+
+	// PlotDirectories are the plot directories discovered from the node's labels and annotations, as paths in the harvester container.
+	// Directories matched by a plotDirectoryGlob are only known to the harvester pod, and aren't listed.
+	// +optional
+	PlotDirectories []string `json:"plotDirectories,omitempty"`
+}
+
+// ChiaHarvesterPlotCheckStatus reports the results of a plot check
+type ChiaHarvesterPlotCheckStatus struct {
+	// JobName is the name of the Job the results were read from
+	JobName string `json:"jobName"`
+
+	// CompletionTime is when the plot check finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// TotalPlots is the number of plots that were checked
+	TotalPlots int32 `json:"totalPlots"`
+
+	// BadPlots are plots that found no proofs for any challenge, or that chia failed to open
+	// +optional
+	BadPlots []PlotCheckResult `json:"badPlots,omitempty"`
+
+	// LowQualityPlots are plots that found proofs for less than the low quality threshold of challenges
+	// +optional
+	LowQualityPlots []PlotCheckResult `json:"lowQualityPlots,omitempty"`
+}
+
+// PlotCheckResult is the result of checking a single plot
+type PlotCheckResult struct {
+	// Path is the plot file's path in the harvester container
+	Path string `json:"path"`
+
+	// Proofs is the number of proofs the plot found
+	Proofs int32 `json:"proofs"`
+
+	// Challenges is the number of challenges the plot was checked with
+	Challenges int32 `json:"challenges"`
 }
 
 //+kubebuilder:object:root=true
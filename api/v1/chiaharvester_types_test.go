@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -34,10 +35,38 @@ spec:
     timezone: "UTC"
     logLevel: "INFO"
     farmerAddress: "farmer.default.svc.cluster.local:58444"
+  replicas: 3
+  sharding:
+    strategy: Label
+    labelKey: example.com/shard
+  plotCheck:
+    schedule: "0 4 * * 0"
+    challenges: 50
+  daemonSet:
+    hostPath: /mnt
+    plotDirectoryGlob: "disk*/plots"
+    plotDirectoryAnnotation: k8s.chia.net/plot-directories
+  alerting:
+    namespace: monitoring
+    labels:
+      release: prometheus
+    for: 30m
+    disabledAlerts:
+    - ChiaExporterDown
+    plotCountDropPercent: 10
   chiaExporter:
     enabled: true
     serviceLabels:
       network: testnet
+  storage:
+    plots:
+      volumes:
+      - name: nas
+        nfs:
+          server: nas.example.com
+          path: /exports/plots
+        subPath: k32
+        mountPath: /mnt/nas-plots
 `)
 
 	var (
@@ -48,6 +77,8 @@ spec:
 		networkPort          uint16 = 8080
 		introducerAddress           = "introducer.svc.cluster.local"
 		dnsIntroducerAddress        = "dns-introducer.svc.cluster.local"
+		challenges           int32  = 50
+		plotCountDropPercent int32  = 10
 	)
 	expect := ChiaHarvester{
 		TypeMeta: metav1.TypeMeta{
@@ -77,6 +108,31 @@ spec:
 				},
 				FarmerAddress: "farmer.default.svc.cluster.local:58444",
 			},
+			Replicas: 3,
+			Sharding: &ChiaHarvesterSharding{
+				Strategy: ShardingStrategyLabel,
+				LabelKey: "example.com/shard",
+			},
+			PlotCheck: &ChiaHarvesterPlotCheck{
+				Schedule:   "0 4 * * 0",
+				Challenges: &challenges,
+			},
+			DaemonSet: &ChiaHarvesterDaemonSet{
+				HostPath:                "/mnt",
+				PlotDirectoryGlob:       "disk*/plots",
+				PlotDirectoryAnnotation: "k8s.chia.net/plot-directories",
+			},
+			Alerting: &ChiaHarvesterAlerting{
+				AlertingConfig: AlertingConfig{
+					Namespace: "monitoring",
+					Labels: map[string]string{
+						"release": "prometheus",
+					},
+					For:            "30m",
+					DisabledAlerts: []string{"ChiaExporterDown"},
+				},
+				PlotCountDropPercent: &plotCountDropPercent,
+			},
 			CommonSpec: CommonSpec{
 				ChiaExporterConfig: SpecChiaExporter{
 					Enabled: true,
@@ -85,6 +141,23 @@ spec:
 					},
 				},
 			},
+			Storage: &ChiaHarvesterStorageConfig{
+				Plots: &PlotsConfig{
+					Volumes: []PlotVolumeConfig{
+						{
+							Name: "nas",
+							VolumeSource: corev1.VolumeSource{
+								NFS: &corev1.NFSVolumeSource{
+									Server: "nas.example.com",
+									Path:   "/exports/plots",
+								},
+							},
+							SubPath:   "k32",
+							MountPath: "/mnt/nas-plots",
+						},
+					},
+				},
+			},
 		},
 	}
 
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChiaMaintenanceTask is the name of an operational task a ChiaMaintenance can run
+// +kubebuilder:validation:Enum=DBUpgrade;DBValidate;PlotsCheck;PrunePeers;Custom
+type ChiaMaintenanceTask string
+
+const (
+	// MaintenanceTaskDBUpgrade runs `chia db upgrade` against a ChiaNode's blockchain database
+	MaintenanceTaskDBUpgrade ChiaMaintenanceTask = "DBUpgrade"
+
+	// MaintenanceTaskDBValidate runs `chia db validate` against a ChiaNode's blockchain database
+	MaintenanceTaskDBValidate ChiaMaintenanceTask = "DBValidate"
+
+	// MaintenanceTaskPlotsCheck runs `chia plots check` against a ChiaHarvester's plots
+	MaintenanceTaskPlotsCheck ChiaMaintenanceTask = "PlotsCheck"
+
+	// MaintenanceTaskPrunePeers removes the peer tables from a ChiaNode's or ChiaWallet's CHIA_ROOT
+	MaintenanceTaskPrunePeers ChiaMaintenanceTask = "PrunePeers"
+
+	// MaintenanceTaskCustom runs the command given in the ChiaMaintenance spec
+	MaintenanceTaskCustom ChiaMaintenanceTask = "Custom"
+)
+
+// ChiaMaintenancePhase is the lifecycle phase of a ChiaMaintenance
+type ChiaMaintenancePhase string
+
+const (
+	// MaintenancePhasePending means the ChiaMaintenance has not started yet
+	MaintenancePhasePending ChiaMaintenancePhase = "Pending"
+
+	// MaintenancePhaseScalingDown means the target's workload is being scaled down for exclusive access
+	MaintenancePhaseScalingDown ChiaMaintenancePhase = "ScalingDown"
+
+	// MaintenancePhaseRunning means the maintenance Job is running
+	MaintenancePhaseRunning ChiaMaintenancePhase = "Running"
+
+	// MaintenancePhaseSucceeded means the maintenance Job completed successfully and the target was restored
+	MaintenancePhaseSucceeded ChiaMaintenancePhase = "Succeeded"
+
+	// MaintenancePhaseFailed means the maintenance Job failed, or could not be run, and the target was restored
+	MaintenancePhaseFailed ChiaMaintenancePhase = "Failed"
+)
+
+// ChiaMaintenanceSpec defines the desired state of ChiaMaintenance
+type ChiaMaintenanceSpec struct {
+	// Target is the chia component whose CHIA_ROOT and volumes the task runs against
+	Target ChiaMaintenanceTarget `json:"target"`
+
+	// Task is the operational task to run
+	Task ChiaMaintenanceTask `json:"task"`
+
+	// Command is the shell command to run for Custom tasks. It is ignored for other tasks.
+	// +optional
+	Command string `json:"command,omitempty"`
+
+	// Args are additional arguments appended to the task's chia command
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// ExclusiveAccess scales the target's workload to zero while the task runs, and restores it afterwards.
+	// Defaults to true for DBUpgrade, DBValidate, and PrunePeers tasks, and false for others.
+	// For ChiaNode targets this stops all of the ChiaNode's replicas, whichever replica the task runs against.
+	// +optional
+	ExclusiveAccess *bool `json:"exclusiveAccess,omitempty"`
+
+	// Image overrides the image used for the task. Defaults to the target's chia image.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// ActiveDeadlineSeconds is the maximum amount of time the task's Job may run for
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Resources defines the compute resources for the task's container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ChiaMaintenanceTarget references the chia component a ChiaMaintenance runs against
+type ChiaMaintenanceTarget struct {
+	// Kind is the kind of the target custom resource
+	// +kubebuilder:validation:Enum=ChiaNode;ChiaHarvester;ChiaWallet
+	Kind string `json:"kind"`
+
+	// Name is the name of the target custom resource in the ChiaMaintenance's namespace
+	Name string `json:"name"`
+
+	// Replica is the StatefulSet ordinal whose CHIA_ROOT volume claim is used for ChiaNode targets. Must be less than the ChiaNode's replicas. Defaults to 0.
+	// Exclusive access still scales down every replica of the ChiaNode's StatefulSet, not just this one.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replica *int32 `json:"replica,omitempty"`
+}
+
+// ChiaMaintenanceStatus defines the observed state of ChiaMaintenance
+type ChiaMaintenanceStatus struct {
+	// Phase is the lifecycle phase of this ChiaMaintenance
+	// +optional
+	Phase ChiaMaintenancePhase `json:"phase,omitempty"`
+
+	// JobName is the name of the Job running the task
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// StartTime is when the task's Job was created
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the task finished and the target was restored
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Result is the tail of the task's output
+	// +optional
+	Result string `json:"result,omitempty"`
+
+	// Message is a human readable description of the current phase
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Task",type=string,JSONPath=`.spec.task`
+//+kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target.name`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+
+// ChiaMaintenance is the Schema for the chiamaintenances API
+type ChiaMaintenance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChiaMaintenanceSpec   `json:"spec,omitempty"`
+	Status ChiaMaintenanceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ChiaMaintenanceList contains a list of ChiaMaintenance
+type ChiaMaintenanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ChiaMaintenance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ChiaMaintenance{}, &ChiaMaintenanceList{})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestUnmarshalChiaMaintenance(t *testing.T) {
+	yamlData := []byte(`
+apiVersion: k8s.chia.net/v1
+kind: ChiaMaintenance
+metadata:
+  labels:
+    app.kubernetes.io/name: chiamaintenance
+    app.kubernetes.io/instance: chiamaintenance-sample
+    app.kubernetes.io/part-of: chia-operator
+    app.kubernetes.io/created-by: chia-operator
+  name: chiamaintenance-sample
+spec:
+  target:
+    kind: ChiaNode
+    name: chianode-sample
+    replica: 1
+  task: DBValidate
+  args:
+    - "--validate-blocks"
+  exclusiveAccess: true
+  activeDeadlineSeconds: 3600
+`)
+
+	var (
+		replica               int32 = 1
+		exclusiveAccess             = true
+		activeDeadlineSeconds int64 = 3600
+	)
+	expect := ChiaMaintenance{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "k8s.chia.net/v1",
+			Kind:       "ChiaMaintenance",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "chiamaintenance-sample",
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "chiamaintenance",
+				"app.kubernetes.io/instance":   "chiamaintenance-sample",
+				"app.kubernetes.io/part-of":    "chia-operator",
+				"app.kubernetes.io/created-by": "chia-operator",
+			},
+		},
+		Spec: ChiaMaintenanceSpec{
+			Target: ChiaMaintenanceTarget{
+				Kind:    "ChiaNode",
+				Name:    "chianode-sample",
+				Replica: &replica,
+			},
+			Task:                  MaintenanceTaskDBValidate,
+			Args:                  []string{"--validate-blocks"},
+			ExclusiveAccess:       &exclusiveAccess,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+		},
+	}
+
+	var actual ChiaMaintenance
+	err := yaml.Unmarshal(yamlData, &actual)
+	if err != nil {
+		t.Errorf("Error unmarshaling yaml: %v", err)
+		return
+	}
+
+	diff := cmp.Diff(actual, expect)
+	if diff != "" {
+		t.Errorf("Unmarshaled struct does not match the expected struct. Actual: %+v\nExpected: %+v\nDiff: %s", actual, expect, diff)
+		return
+	}
+}
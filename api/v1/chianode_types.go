@@ -15,10 +15,148 @@ type ChiaNodeSpec struct {
 	// ChiaConfig defines the configuration options available to Chia component containers
 	ChiaConfig ChiaNodeSpecChia `json:"chia"`
 
+	// Storage defines the Chia container's CHIA_ROOT and blockchain database storage config
+	// +optional
+	Storage *DatabaseStorageConfig `json:"storage,omitempty"`
+
 	// Replicas is the desired number of replicas of the given Statefulset. defaults to 1.
 	// +optional
 	// +kubebuilder:default=1
 	Replicas int32 `json:"replicas,omitempty"`
+
+	// Backup schedules VolumeSnapshots of each replica's CHIA_ROOT volume claim. Requires the snapshot.storage.k8s.io CRDs in the cluster.
+	// +optional
+	Backup *ChiaNodeBackup `json:"backup,omitempty"`
+
+	// DataSource pre-populates the CHIA_ROOT of new replicas so they don't sync the blockchain from genesis
+	// +optional
+	DataSource *ChiaNodeDataSource `json:"dataSource,omitempty"`
+
+	// PeerServices creates a Service for each replica's peer port, selected by the replica's pod name, so inbound peers reach
+	// a specific replica and each replica can be reached at its own external address
+	// +optional
+	PeerServices *ChiaNodePeerServices `json:"peerServices,omitempty"`
+
+	// IntraClusterPeering adds the node's other replicas, and optionally other ChiaNodes, to each replica's full_node_peers
+	// so in-cluster full_nodes connect to each other directly instead of only finding each other through introducers
+	// +optional
+	IntraClusterPeering *ChiaNodeIntraClusterPeering `json:"intraClusterPeering,omitempty"`
+
+	// InternalService configures the node's internal Service, which routes to replicas on the client's own kubernetes node
+	// +optional
+	InternalService ServiceConfig `json:"internalService,omitempty"`
+
+	// HeadlessService configures the node's headless Service, which gives each replica a DNS name
+	// +optional
+	HeadlessService ServiceConfig `json:"headlessService,omitempty"`
+
+	// SyncedService configures the node's synced Service, which only routes to synced replicas
+	// +optional
+	SyncedService ServiceConfig `json:"syncedService,omitempty"`
+
+	// Alerting creates a Prometheus Operator PrometheusRule with the node's alerts.
+	// Ignored if the PrometheusRule CRD wasn't installed when the operator started.
+	// +optional
+	Alerting *AlertingConfig `json:"alerting,omitempty"`
+}
+
+// ChiaNodePeerServices defines the per-replica peer Services of a ChiaNode. Each replica's peer Service is a LoadBalancer that exposes
+// the same port the replica listens on, since chia advertises its own peer port and learns its address from its peers' connections.
+type ChiaNodePeerServices struct {
+	// Replicas configures each replica's peer Service, indexed by StatefulSet ordinal.
+	// Replicas without an entry get a peer Service with addresses assigned by kubernetes.
+	// +optional
+	Replicas []ChiaNodeReplicaPeer `json:"replicas,omitempty"`
+}
+
+// ChiaNodeReplicaPeer defines the external address of one ChiaNode replica
+type ChiaNodeReplicaPeer struct {
+	// ExternalHostname is the hostname the replica is reached at. It's set as the external-dns hostname annotation on the replica's
+	// peer Service, and reported as the replica's external address.
+	// +optional
+	ExternalHostname string `json:"externalHostname,omitempty"`
+
+	// Annotations are added to the replica's peer Service, like a cloud provider's annotation for a static load balancer IP
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ChiaNodeIntraClusterPeering defines the in-cluster full_nodes a ChiaNode's replicas peer with
+type ChiaNodeIntraClusterPeering struct {
+	// Replicas adds each of the node's other replicas as a peer, by its DNS name in the node's headless Service. defaults to true.
+	// +kubebuilder:default=true
+	// +optional
+	Replicas *bool `json:"replicas,omitempty"`
+
+	// ChiaNodes lists other ChiaNodes to peer with. Each one is added as a peer by its synced Service, which routes to one of its synced replicas.
+	// +optional
+	ChiaNodes []ChiaNodePeerReference `json:"chiaNodes,omitempty"`
+
+	// TrustedCIDRs is a list of CIDRs, like the cluster's pod CIDR, whose peers the full_node treats as trusted
+	// +optional
+	TrustedCIDRs []string `json:"trustedCIDRs,omitempty"`
+}
+
+// ChiaNodePeerReference refers to a ChiaNode to peer with
+type ChiaNodePeerReference struct {
+	// Name is the name of the ChiaNode
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the ChiaNode. defaults to the peering ChiaNode's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ChiaNodeDataSource defines where new ChiaNode replicas get their initial blockchain database from.
+// VolumeSnapshotName and PersistentVolumeClaimName require a persistentVolumeClaim CHIA_ROOT, and are only used when a replica's volume claim is first created.
+type ChiaNodeDataSource struct {
+	// VolumeSnapshotName is the name of a VolumeSnapshot of a CHIA_ROOT volume to restore new replicas' volume claims from
+	// +optional
+	VolumeSnapshotName string `json:"volumeSnapshotName,omitempty"`
+
+	// PersistentVolumeClaimName is the name of an existing CHIA_ROOT volume claim to clone new replicas' volume claims from.
+	// Ignored if VolumeSnapshotName is set.
+	// +optional
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+
+	// DatabaseURL is the URL of a blockchain database file that an init container downloads when a replica has no database yet.
+	// Files ending in .gz are decompressed.
+	// +optional
+	DatabaseURL string `json:"databaseURL,omitempty"`
+
+	// DatabaseSHA256 is the expected SHA256 checksum of the file downloaded from DatabaseURL
+	// +optional
+	DatabaseSHA256 string `json:"databaseSHA256,omitempty"`
+
+	// Verify runs `chia db validate` in an init container against a downloaded or restored database before the chia container starts.
+	// A database is only verified once, and databases a replica synced itself are never verified. Defaults to true.
+	// +optional
+	Verify *bool `json:"verify,omitempty"`
+}
+
+// ChiaNodeBackup defines the schedule and retention of VolumeSnapshot backups for a ChiaNode
+type ChiaNodeBackup struct {
+	// Schedule is the cron schedule snapshots are taken on
+	Schedule string `json:"schedule"`
+
+	// VolumeSnapshotClassName is the VolumeSnapshotClass used for snapshots. Defaults to the cluster's default class.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Quiesce stops each replica while its snapshot is taken, so the blockchain database is not written to mid-snapshot.
+	// Replicas are snapshotted one at a time from the highest ordinal down.
+	// +optional
+	Quiesce bool `json:"quiesce,omitempty"`
+
+	// MaxCount is the number of snapshots that are ready to use kept for each replica
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxCount *int32 `json:"maxCount,omitempty"`
+
+	// MaxAge is the age after which snapshots are deleted. The most recent successful snapshot of each replica is always kept.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
 }
 
 // ChiaNodeSpecChia defines the desired state of Chia component configuration
@@ -31,6 +169,103 @@ type ChiaNodeStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// Backup reports the state of scheduled VolumeSnapshot backups
+	// +optional
+	Backup *ChiaNodeBackupStatus `json:"backup,omitempty"`
+
+	// VolumeResize reports the progress of expanding the replicas' chiaroot and chiadb volume claims after a larger storage request
+	// +optional
+	VolumeResize *ChiaNodeVolumeResizeStatus `json:"volumeResize,omitempty"`
+
+	// PeerServices reports each replica's peer Service and external address
+	// +optional
+	PeerServices []ChiaNodePeerServiceStatus `json:"peerServices,omitempty"`
+
+	// SyncedReplicas is the number of replicas that reported a synced blockchain the last time their RPC was queried.
+	// These replicas are selected by the ChiaNode's synced Service.
+	// +optional
+	SyncedReplicas int32 `json:"syncedReplicas,omitempty"`
+
+	// Conditions report problems with the node's configuration or cluster that the operator works around. Each is removed once resolved.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ChiaNodePeerServiceStatus reports the peer Service of one ChiaNode replica
+type ChiaNodePeerServiceStatus struct {
+	// Ordinal is the replica's StatefulSet ordinal
+	Ordinal int32 `json:"ordinal"`
+
+	// ServiceName is the name of the replica's peer Service
+	ServiceName string `json:"serviceName"`
+
+	// ExternalAddress is the host:port the replica is reached at, empty until it's known
+	// +optional
+	ExternalAddress string `json:"externalAddress,omitempty"`
+}
+
+// VolumeResizeState is the state of a volume claim's expansion
+type VolumeResizeState string
+
+const (
+	// VolumeResizeStateResizing means the claim's volume is being expanded by its storage provider
+	VolumeResizeStateResizing VolumeResizeState = "Resizing"
+
+	// VolumeResizeStateFileSystemResizePending means the volume was expanded and its filesystem will be expanded when it is next mounted
+	VolumeResizeStateFileSystemResizePending VolumeResizeState = "FileSystemResizePending"
+
+	// VolumeResizeStateComplete means the claim's capacity matches the requested size
+	VolumeResizeStateComplete VolumeResizeState = "Complete"
+
+	// VolumeResizeStateUnsupported means the claim's StorageClass does not allow volume expansion
+	VolumeResizeStateUnsupported VolumeResizeState = "Unsupported"
+)
+
+// ChiaNodeVolumeResizeStatus defines the observed progress of a volume claim expansion
+type ChiaNodeVolumeResizeStatus struct {
+	// Claims is the expansion progress of each replica's claims
+	// +optional
+	Claims []ChiaNodeVolumeClaimResizeStatus `json:"claims,omitempty"`
+}
+
+// ChiaNodeVolumeClaimResizeStatus defines the observed progress of a single volume claim's expansion
+type ChiaNodeVolumeClaimResizeStatus struct {
+	// Name is the name of the PersistentVolumeClaim
+	Name string `json:"name"`
+
+	// RequestedSize is the storage request the claim is being expanded to
+	RequestedSize string `json:"requestedSize"`
+
+	// Capacity is the claim's current capacity
+	Capacity string `json:"capacity"`
+
+	// State is the state of the claim's expansion
+	State VolumeResizeState `json:"state"`
+}
+
+// ChiaNodeBackupStatus defines the observed state of a ChiaNode's VolumeSnapshot backups
+type ChiaNodeBackupStatus struct {
+	// Active is true while the snapshots for a scheduled backup are being taken
+	// +optional
+	Active bool `json:"active,omitempty"`
+
+	// QuiescedReplica is the ordinal of the replica stopped for its snapshot during a quiesced backup.
+	// The StatefulSet is scaled down to this many replicas while it is set.
+	// +optional
+	QuiescedReplica *int32 `json:"quiescedReplica,omitempty"`
+
+	// LastScheduleTime is the scheduled time of the most recent backup
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// LastSuccessfulTime is when the most recent snapshot that is ready to use was taken
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// LastSuccessfulSnapshots are the names of the most recent snapshots that are ready to use, one per replica
+	// +optional
+	LastSuccessfulSnapshots []string `json:"lastSuccessfulSnapshots,omitempty"`
 }
 
 //+kubebuilder:object:root=true
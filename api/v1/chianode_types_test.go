@@ -6,8 +6,10 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -35,8 +37,53 @@ spec:
     logLevel: "INFO"
   chiaExporter:
     enabled: true
+    imagePullPolicy: IfNotPresent
+    logLevel: debug
+    metricsPort: 9915
+    env:
+      - name: GOMEMLIMIT
+        value: 200MiB
     serviceLabels:
       network: testnet
+  terminationGracePeriodSeconds: 600
+  storage:
+    chiaRoot:
+      persistentVolumeClaim:
+        storageClass: standard
+        resourceRequest: 10Gi
+    database:
+      persistentVolumeClaim:
+        storageClass: local-nvme
+        resourceRequest: 300Gi
+  backup:
+    schedule: "0 3 * * *"
+    quiesce: true
+    maxCount: 7
+    maxAge: 168h
+  dataSource:
+    volumeSnapshotName: chianode-sample-node-0-20240301-030000
+    databaseURL: https://example.com/blockchain_v2_mainnet.sqlite.gz
+  peerServices:
+    replicas:
+      - externalHostname: node-0.example.com
+  intraClusterPeering:
+    chiaNodes:
+      - name: other-node
+        namespace: other-namespace
+    trustedCIDRs:
+      - 10.0.0.0/8
+  service:
+    type: LoadBalancer
+    externalTrafficPolicy: Local
+    ports:
+      - peers
+  networkPolicy:
+    enabled: true
+    monitoringNamespace: prometheus
+  internalService:
+    ports:
+      - daemon
+      - rpc
 `)
 
 	var (
@@ -47,6 +94,9 @@ spec:
 		networkPort          uint16 = 8080
 		introducerAddress           = "introducer.svc.cluster.local"
 		dnsIntroducerAddress        = "dns-introducer.svc.cluster.local"
+		gracePeriod          int64  = 600
+		backupMaxCount       int32  = 7
+		metricsPort          int32  = 9915
 	)
 	expect := ChiaNode{
 		TypeMeta: metav1.TypeMeta{
@@ -77,11 +127,75 @@ spec:
 			},
 			CommonSpec: CommonSpec{
 				ChiaExporterConfig: SpecChiaExporter{
-					Enabled: true,
+					Enabled:         true,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					LogLevel:        "debug",
+					MetricsPort:     &metricsPort,
+					Env: []corev1.EnvVar{
+						{
+							Name:  "GOMEMLIMIT",
+							Value: "200MiB",
+						},
+					},
 					ServiceLabels: map[string]string{
 						"network": "testnet",
 					},
 				},
+				TerminationGracePeriodSeconds: &gracePeriod,
+				Service: ServiceConfig{
+					Type:                  corev1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+					Ports:                 []ServicePortName{"peers"},
+				},
+				NetworkPolicy: NetworkPolicyConfig{
+					Enabled:             true,
+					MonitoringNamespace: "prometheus",
+				},
+			},
+			Storage: &DatabaseStorageConfig{
+				StorageConfig: StorageConfig{
+					ChiaRoot: &ChiaRootConfig{
+						PersistentVolumeClaim: &PersistentVolumeClaimConfig{
+							StorageClass:    "standard",
+							ResourceRequest: "10Gi",
+						},
+					},
+				},
+				Database: &DatabaseConfig{
+					PersistentVolumeClaim: &PersistentVolumeClaimConfig{
+						StorageClass:    "local-nvme",
+						ResourceRequest: "300Gi",
+					},
+				},
+			},
+			Backup: &ChiaNodeBackup{
+				Schedule: "0 3 * * *",
+				Quiesce:  true,
+				MaxCount: &backupMaxCount,
+				MaxAge:   &metav1.Duration{Duration: 168 * time.Hour},
+			},
+			DataSource: &ChiaNodeDataSource{
+				VolumeSnapshotName: "chianode-sample-node-0-20240301-030000",
+				DatabaseURL:        "https://example.com/blockchain_v2_mainnet.sqlite.gz",
+			},
+			PeerServices: &ChiaNodePeerServices{
+				Replicas: []ChiaNodeReplicaPeer{
+					{
+						ExternalHostname: "node-0.example.com",
+					},
+				},
+			},
+			IntraClusterPeering: &ChiaNodeIntraClusterPeering{
+				ChiaNodes: []ChiaNodePeerReference{
+					{
+						Name:      "other-node",
+						Namespace: "other-namespace",
+					},
+				},
+				TrustedCIDRs: []string{"10.0.0.0/8"},
+			},
+			InternalService: ServiceConfig{
+				Ports: []ServicePortName{"daemon", "rpc"},
 			},
 		},
 	}
@@ -14,6 +14,15 @@ type ChiaSeederSpec struct {
 
 	// ChiaConfig defines the configuration options available to Chia component containers
 	ChiaConfig ChiaSeederSpecChia `json:"chia"`
+
+	// Storage defines the Chia container's CHIA_ROOT and blockchain database storage config
+	// +optional
+	Storage *DatabaseStorageConfig `json:"storage,omitempty"`
+
+	// Alerting creates a Prometheus Operator PrometheusRule with the seeder's alerts.
+	// Ignored if the PrometheusRule CRD wasn't installed when the operator started.
+	// +optional
+	Alerting *AlertingConfig `json:"alerting,omitempty"`
 }
 
 // ChiaSeederSpecChia defines the desired state of Chia component configuration
@@ -47,6 +56,10 @@ type ChiaSeederStatus struct {
 	// Ready says whether the chia component is ready deployed
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// Conditions report problems with the seeder's configuration or cluster that the operator works around. Each is removed once resolved.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
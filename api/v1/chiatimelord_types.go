@@ -14,6 +14,19 @@ type ChiaTimelordSpec struct {
 
 	// ChiaConfig defines the configuration options available to Chia component containers
 	ChiaConfig ChiaTimelordSpecChia `json:"chia"`
+
+	// Storage defines the Chia container's CHIA_ROOT storage config
+	// +optional
+	Storage *StorageConfig `json:"storage,omitempty"`
+
+	// IdleThreshold is the amount of time the timelord may go without receiving a new peak before the Idle condition is raised. Defaults to 10m.
+	// +optional
+	IdleThreshold *metav1.Duration `json:"idleThreshold,omitempty"`
+
+	// Alerting creates a Prometheus Operator PrometheusRule with the timelord's alerts.
+	// Ignored if the PrometheusRule CRD wasn't installed when the operator started.
+	// +optional
+	Alerting *AlertingConfig `json:"alerting,omitempty"`
 }
 
 // ChiaTimelordSpecChia defines the desired state of Chia component configuration
@@ -25,11 +38,29 @@ type ChiaTimelordSpecChia struct {
 	FullNodePeer string `json:"fullNodePeer"`
 }
 
-// ChiaTimelordStatus defines the observed state of ChiaTimelord
+// ChiaTimelordStatus defines the observed state of ChiaTimelord.
+// The timelord RPC server only serves the endpoints common to every chia service, and VDF clients connect to the timelord outside of
+// the chia protocol, so the number of active VDF clients and the height of the last infused proof can't be queried and aren't reported.
 type ChiaTimelordStatus struct {
 	// Ready says whether the CA is ready, this should be true when the SSL secret is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// FullNodeConnected says whether the timelord had an open connection to a full_node peer the last time its RPC was queried
+	// +optional
+	FullNodeConnected bool `json:"fullNodeConnected,omitempty"`
+
+	// PeakHeight is the highest peak height reported by the timelord's full_node connections the last time its RPC was queried
+	// +optional
+	PeakHeight *uint32 `json:"peakHeight,omitempty"`
+
+	// LastPeakTime is the time the operator first observed the current PeakHeight
+	// +optional
+	LastPeakTime *metav1.Time `json:"lastPeakTime,omitempty"`
+
+	// Conditions represent the latest available observations of the timelord's health
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
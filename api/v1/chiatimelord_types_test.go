@@ -6,8 +6,10 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -38,6 +40,13 @@ spec:
     enabled: true
     serviceLabels:
       network: testnet
+  storage:
+    chiaRoot:
+      persistentVolumeClaim:
+        storageClass: standard
+        resourceRequest: 10Gi
+        retentionPolicy: Delete
+  idleThreshold: 15m
 `)
 
 	var (
@@ -85,6 +94,16 @@ spec:
 					},
 				},
 			},
+			Storage: &StorageConfig{
+				ChiaRoot: &ChiaRootConfig{
+					PersistentVolumeClaim: &PersistentVolumeClaimConfig{
+						StorageClass:    "standard",
+						ResourceRequest: "10Gi",
+						RetentionPolicy: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+					},
+				},
+			},
+			IdleThreshold: &metav1.Duration{Duration: 15 * time.Minute},
 		},
 	}
 
@@ -14,8 +14,36 @@ type ChiaWalletSpec struct {
 
 	// ChiaConfig defines the configuration options available to Chia component containers
 	ChiaConfig ChiaWalletSpecChia `json:"chia"`
+
+	// Storage defines the Chia container's CHIA_ROOT storage config
+	// +optional
+	Storage *StorageConfig `json:"storage,omitempty"`
+
+	// WorkloadKind is the kind of workload the wallet runs in. defaults to Deployment.
+	// A StatefulSet creates the CHIA_ROOT volume claim from storage.chiaRoot.persistentVolumeClaim's storageClass and resourceRequest
+	// when no claimName is given, and never runs two wallet pods against the same volume during an update.
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
+	// +kubebuilder:default=Deployment
+	// +optional
+	WorkloadKind WorkloadKind `json:"workloadKind,omitempty"`
+
+	// Alerting creates a Prometheus Operator PrometheusRule with the wallet's alerts.
+	// Ignored if the PrometheusRule CRD wasn't installed when the operator started.
+	// +optional
+	Alerting *AlertingConfig `json:"alerting,omitempty"`
 }
 
+// WorkloadKind is the kind of kubernetes workload a chia component runs in
+type WorkloadKind string
+
+const (
+	// WorkloadKindDeployment runs the component in a Deployment
+	WorkloadKindDeployment WorkloadKind = "Deployment"
+
+	// WorkloadKindStatefulSet runs the component in a StatefulSet
+	WorkloadKindStatefulSet WorkloadKind = "StatefulSet"
+)
+
 // ChiaWalletSpecChia defines the desired state of Chia component configuration
 type ChiaWalletSpecChia struct {
 	CommonSpecChia `json:",inline"`
@@ -34,6 +62,10 @@ type ChiaWalletStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// Conditions report problems with the wallet's configuration or cluster that the operator works around. Each is removed once resolved.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
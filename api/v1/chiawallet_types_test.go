@@ -41,6 +41,12 @@ spec:
     enabled: true
     serviceLabels:
       network: testnet
+  workloadKind: StatefulSet
+  storage:
+    chiaRoot:
+      persistentVolumeClaim:
+        storageClass: standard
+        resourceRequest: 50Gi
 `)
 
 	var (
@@ -92,6 +98,15 @@ spec:
 					},
 				},
 			},
+			Storage: &StorageConfig{
+				ChiaRoot: &ChiaRootConfig{
+					PersistentVolumeClaim: &PersistentVolumeClaimConfig{
+						StorageClass:    "standard",
+						ResourceRequest: "50Gi",
+					},
+				},
+			},
+			WorkloadKind: WorkloadKindStatefulSet,
 		},
 	}
 
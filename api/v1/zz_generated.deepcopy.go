@@ -22,6 +22,8 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -54,6 +56,33 @@ func (in *AdditionalMetadata) DeepCopy() *AdditionalMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertingConfig) DeepCopyInto(out *AlertingConfig) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DisabledAlerts != nil {
+		in, out := &in.DisabledAlerts, &out.DisabledAlerts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertingConfig.
+func (in *AlertingConfig) DeepCopy() *AlertingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCA) DeepCopyInto(out *ChiaCA) {
 	*out = *in
@@ -143,13 +172,49 @@ func (in *ChiaCAStatus) DeepCopy() *ChiaCAStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaExporterMonitor) DeepCopyInto(out *ChiaExporterMonitor) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Relabelings != nil {
+		in, out := &in.Relabelings, &out.Relabelings
+		*out = make([]RelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricRelabelings != nil {
+		in, out := &in.MetricRelabelings, &out.MetricRelabelings
+		*out = make([]RelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaExporterMonitor.
+func (in *ChiaExporterMonitor) DeepCopy() *ChiaExporterMonitor {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaExporterMonitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaFarmer) DeepCopyInto(out *ChiaFarmer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmer.
@@ -170,6 +235,27 @@ func (in *ChiaFarmer) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerAlerting) DeepCopyInto(out *ChiaFarmerAlerting) {
+	*out = *in
+	in.AlertingConfig.DeepCopyInto(&out.AlertingConfig)
+	if in.MinHarvesters != nil {
+		in, out := &in.MinHarvesters, &out.MinHarvesters
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerAlerting.
+func (in *ChiaFarmerAlerting) DeepCopy() *ChiaFarmerAlerting {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerAlerting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaFarmerList) DeepCopyInto(out *ChiaFarmerList) {
 	*out = *in
@@ -202,11 +288,76 @@ func (in *ChiaFarmerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerPoolConfig) DeepCopyInto(out *ChiaFarmerPoolConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerPoolConfig.
+func (in *ChiaFarmerPoolConfig) DeepCopy() *ChiaFarmerPoolConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerPoolConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerRemoteHarvesters) DeepCopyInto(out *ChiaFarmerRemoteHarvesters) {
+	*out = *in
+	if in.NodePort != nil {
+		in, out := &in.NodePort, &out.NodePort
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerRemoteHarvesters.
+func (in *ChiaFarmerRemoteHarvesters) DeepCopy() *ChiaFarmerRemoteHarvesters {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerRemoteHarvesters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerRemoteHarvestersStatus) DeepCopyInto(out *ChiaFarmerRemoteHarvestersStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerRemoteHarvestersStatus.
+func (in *ChiaFarmerRemoteHarvestersStatus) DeepCopy() *ChiaFarmerRemoteHarvestersStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerRemoteHarvestersStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaFarmerSpec) DeepCopyInto(out *ChiaFarmerSpec) {
 	*out = *in
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemoteHarvesters != nil {
+		in, out := &in.RemoteHarvesters, &out.RemoteHarvesters
+		*out = new(ChiaFarmerRemoteHarvesters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(ChiaFarmerAlerting)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerSpec.
@@ -224,6 +375,11 @@ func (in *ChiaFarmerSpecChia) DeepCopyInto(out *ChiaFarmerSpecChia) {
 	*out = *in
 	in.CommonSpecChia.DeepCopyInto(&out.CommonSpecChia)
 	out.SecretKey = in.SecretKey
+	if in.PoolList != nil {
+		in, out := &in.PoolList, &out.PoolList
+		*out = make([]ChiaFarmerPoolConfig, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerSpecChia.
@@ -239,6 +395,18 @@ func (in *ChiaFarmerSpecChia) DeepCopy() *ChiaFarmerSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaFarmerStatus) DeepCopyInto(out *ChiaFarmerStatus) {
 	*out = *in
+	if in.RemoteHarvesters != nil {
+		in, out := &in.RemoteHarvesters, &out.RemoteHarvesters
+		*out = new(ChiaFarmerRemoteHarvestersStatus)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerStatus.
@@ -257,7 +425,7 @@ func (in *ChiaHarvester) DeepCopyInto(out *ChiaHarvester) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvester.
@@ -278,6 +446,42 @@ func (in *ChiaHarvester) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterAlerting) DeepCopyInto(out *ChiaHarvesterAlerting) {
+	*out = *in
+	in.AlertingConfig.DeepCopyInto(&out.AlertingConfig)
+	if in.PlotCountDropPercent != nil {
+		in, out := &in.PlotCountDropPercent, &out.PlotCountDropPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterAlerting.
+func (in *ChiaHarvesterAlerting) DeepCopy() *ChiaHarvesterAlerting {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterAlerting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterDaemonSet) DeepCopyInto(out *ChiaHarvesterDaemonSet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterDaemonSet.
+func (in *ChiaHarvesterDaemonSet) DeepCopy() *ChiaHarvesterDaemonSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterDaemonSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaHarvesterList) DeepCopyInto(out *ChiaHarvesterList) {
 	*out = *in
@@ -310,11 +514,150 @@ func (in *ChiaHarvesterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterNodeStatus) DeepCopyInto(out *ChiaHarvesterNodeStatus) {
+	*out = *in
+	if in.PlotDirectories != nil {
+		in, out := &in.PlotDirectories, &out.PlotDirectories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterNodeStatus.
+func (in *ChiaHarvesterNodeStatus) DeepCopy() *ChiaHarvesterNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterPlotCheck) DeepCopyInto(out *ChiaHarvesterPlotCheck) {
+	*out = *in
+	if in.Challenges != nil {
+		in, out := &in.Challenges, &out.Challenges
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LowQualityThreshold != nil {
+		in, out := &in.LowQualityThreshold, &out.LowQualityThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterPlotCheck.
+func (in *ChiaHarvesterPlotCheck) DeepCopy() *ChiaHarvesterPlotCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterPlotCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterPlotCheckStatus) DeepCopyInto(out *ChiaHarvesterPlotCheckStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BadPlots != nil {
+		in, out := &in.BadPlots, &out.BadPlots
+		*out = make([]PlotCheckResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.LowQualityPlots != nil {
+		in, out := &in.LowQualityPlots, &out.LowQualityPlots
+		*out = make([]PlotCheckResult, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterPlotCheckStatus.
+func (in *ChiaHarvesterPlotCheckStatus) DeepCopy() *ChiaHarvesterPlotCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterPlotCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterShardStatus) DeepCopyInto(out *ChiaHarvesterShardStatus) {
+	*out = *in
+	if in.PlotVolumes != nil {
+		in, out := &in.PlotVolumes, &out.PlotVolumes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterShardStatus.
+func (in *ChiaHarvesterShardStatus) DeepCopy() *ChiaHarvesterShardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterShardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterSharding) DeepCopyInto(out *ChiaHarvesterSharding) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterSharding.
+func (in *ChiaHarvesterSharding) DeepCopy() *ChiaHarvesterSharding {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterSharding)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaHarvesterSpec) DeepCopyInto(out *ChiaHarvesterSpec) {
 	*out = *in
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(ChiaHarvesterStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sharding != nil {
+		in, out := &in.Sharding, &out.Sharding
+		*out = new(ChiaHarvesterSharding)
+		**out = **in
+	}
+	if in.PlotCheck != nil {
+		in, out := &in.PlotCheck, &out.PlotCheck
+		*out = new(ChiaHarvesterPlotCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DaemonSet != nil {
+		in, out := &in.DaemonSet, &out.DaemonSet
+		*out = new(ChiaHarvesterDaemonSet)
+		**out = **in
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(ChiaHarvesterAlerting)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterSpec.
@@ -346,6 +689,32 @@ func (in *ChiaHarvesterSpecChia) DeepCopy() *ChiaHarvesterSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaHarvesterStatus) DeepCopyInto(out *ChiaHarvesterStatus) {
 	*out = *in
+	if in.PlotCheck != nil {
+		in, out := &in.PlotCheck, &out.PlotCheck
+		*out = new(ChiaHarvesterPlotCheckStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]ChiaHarvesterNodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]ChiaHarvesterShardStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterStatus.
@@ -358,13 +727,172 @@ func (in *ChiaHarvesterStatus) DeepCopy() *ChiaHarvesterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterStorageConfig) DeepCopyInto(out *ChiaHarvesterStorageConfig) {
+	*out = *in
+	in.StorageConfig.DeepCopyInto(&out.StorageConfig)
+	if in.Plots != nil {
+		in, out := &in.Plots, &out.Plots
+		*out = new(PlotsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterStorageConfig.
+func (in *ChiaHarvesterStorageConfig) DeepCopy() *ChiaHarvesterStorageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterStorageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaMaintenance) DeepCopyInto(out *ChiaMaintenance) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaMaintenance.
+func (in *ChiaMaintenance) DeepCopy() *ChiaMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChiaMaintenance) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaMaintenanceList) DeepCopyInto(out *ChiaMaintenanceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ChiaMaintenance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaMaintenanceList.
+func (in *ChiaMaintenanceList) DeepCopy() *ChiaMaintenanceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaMaintenanceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChiaMaintenanceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaMaintenanceSpec) DeepCopyInto(out *ChiaMaintenanceSpec) {
+	*out = *in
+	in.Target.DeepCopyInto(&out.Target)
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExclusiveAccess != nil {
+		in, out := &in.ExclusiveAccess, &out.ExclusiveAccess
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaMaintenanceSpec.
+func (in *ChiaMaintenanceSpec) DeepCopy() *ChiaMaintenanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaMaintenanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaMaintenanceStatus) DeepCopyInto(out *ChiaMaintenanceStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaMaintenanceStatus.
+func (in *ChiaMaintenanceStatus) DeepCopy() *ChiaMaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaMaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaMaintenanceTarget) DeepCopyInto(out *ChiaMaintenanceTarget) {
+	*out = *in
+	if in.Replica != nil {
+		in, out := &in.Replica, &out.Replica
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaMaintenanceTarget.
+func (in *ChiaMaintenanceTarget) DeepCopy() *ChiaMaintenanceTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaMaintenanceTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNode) DeepCopyInto(out *ChiaNode) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNode.
@@ -385,6 +913,119 @@ func (in *ChiaNode) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeBackup) DeepCopyInto(out *ChiaNodeBackup) {
+	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxCount != nil {
+		in, out := &in.MaxCount, &out.MaxCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeBackup.
+func (in *ChiaNodeBackup) DeepCopy() *ChiaNodeBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeBackupStatus) DeepCopyInto(out *ChiaNodeBackupStatus) {
+	*out = *in
+	if in.QuiescedReplica != nil {
+		in, out := &in.QuiescedReplica, &out.QuiescedReplica
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulSnapshots != nil {
+		in, out := &in.LastSuccessfulSnapshots, &out.LastSuccessfulSnapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeBackupStatus.
+func (in *ChiaNodeBackupStatus) DeepCopy() *ChiaNodeBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeDataSource) DeepCopyInto(out *ChiaNodeDataSource) {
+	*out = *in
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeDataSource.
+func (in *ChiaNodeDataSource) DeepCopy() *ChiaNodeDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeIntraClusterPeering) DeepCopyInto(out *ChiaNodeIntraClusterPeering) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ChiaNodes != nil {
+		in, out := &in.ChiaNodes, &out.ChiaNodes
+		*out = make([]ChiaNodePeerReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrustedCIDRs != nil {
+		in, out := &in.TrustedCIDRs, &out.TrustedCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeIntraClusterPeering.
+func (in *ChiaNodeIntraClusterPeering) DeepCopy() *ChiaNodeIntraClusterPeering {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeIntraClusterPeering)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNodeList) DeepCopyInto(out *ChiaNodeList) {
 	*out = *in
@@ -414,14 +1055,121 @@ func (in *ChiaNodeList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
-	return nil
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiaNodeSpec) DeepCopyInto(out *ChiaNodeSpec) {
-	*out = *in
-	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
-	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodePeerReference) DeepCopyInto(out *ChiaNodePeerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodePeerReference.
+func (in *ChiaNodePeerReference) DeepCopy() *ChiaNodePeerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodePeerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodePeerServiceStatus) DeepCopyInto(out *ChiaNodePeerServiceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodePeerServiceStatus.
+func (in *ChiaNodePeerServiceStatus) DeepCopy() *ChiaNodePeerServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodePeerServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodePeerServices) DeepCopyInto(out *ChiaNodePeerServices) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]ChiaNodeReplicaPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodePeerServices.
+func (in *ChiaNodePeerServices) DeepCopy() *ChiaNodePeerServices {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodePeerServices)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeReplicaPeer) DeepCopyInto(out *ChiaNodeReplicaPeer) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeReplicaPeer.
+func (in *ChiaNodeReplicaPeer) DeepCopy() *ChiaNodeReplicaPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeReplicaPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeSpec) DeepCopyInto(out *ChiaNodeSpec) {
+	*out = *in
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
+	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(DatabaseStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(ChiaNodeBackup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataSource != nil {
+		in, out := &in.DataSource, &out.DataSource
+		*out = new(ChiaNodeDataSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PeerServices != nil {
+		in, out := &in.PeerServices, &out.PeerServices
+		*out = new(ChiaNodePeerServices)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IntraClusterPeering != nil {
+		in, out := &in.IntraClusterPeering, &out.IntraClusterPeering
+		*out = new(ChiaNodeIntraClusterPeering)
+		(*in).DeepCopyInto(*out)
+	}
+	in.InternalService.DeepCopyInto(&out.InternalService)
+	in.HeadlessService.DeepCopyInto(&out.HeadlessService)
+	in.SyncedService.DeepCopyInto(&out.SyncedService)
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(AlertingConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeSpec.
@@ -453,6 +1201,28 @@ func (in *ChiaNodeSpecChia) DeepCopy() *ChiaNodeSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNodeStatus) DeepCopyInto(out *ChiaNodeStatus) {
 	*out = *in
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(ChiaNodeBackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeResize != nil {
+		in, out := &in.VolumeResize, &out.VolumeResize
+		*out = new(ChiaNodeVolumeResizeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PeerServices != nil {
+		in, out := &in.PeerServices, &out.PeerServices
+		*out = make([]ChiaNodePeerServiceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeStatus.
@@ -465,6 +1235,41 @@ func (in *ChiaNodeStatus) DeepCopy() *ChiaNodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeVolumeClaimResizeStatus) DeepCopyInto(out *ChiaNodeVolumeClaimResizeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeVolumeClaimResizeStatus.
+func (in *ChiaNodeVolumeClaimResizeStatus) DeepCopy() *ChiaNodeVolumeClaimResizeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeVolumeClaimResizeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeVolumeResizeStatus) DeepCopyInto(out *ChiaNodeVolumeResizeStatus) {
+	*out = *in
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make([]ChiaNodeVolumeClaimResizeStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeVolumeResizeStatus.
+func (in *ChiaNodeVolumeResizeStatus) DeepCopy() *ChiaNodeVolumeResizeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeVolumeResizeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaRootConfig) DeepCopyInto(out *ChiaRootConfig) {
 	*out = *in
@@ -511,7 +1316,7 @@ func (in *ChiaSeeder) DeepCopyInto(out *ChiaSeeder) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaSeeder.
@@ -569,6 +1374,16 @@ func (in *ChiaSeederSpec) DeepCopyInto(out *ChiaSeederSpec) {
 	*out = *in
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(DatabaseStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(AlertingConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaSeederSpec.
@@ -615,6 +1430,13 @@ func (in *ChiaSeederSpecChia) DeepCopy() *ChiaSeederSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaSeederStatus) DeepCopyInto(out *ChiaSeederStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaSeederStatus.
@@ -633,7 +1455,7 @@ func (in *ChiaTimelord) DeepCopyInto(out *ChiaTimelord) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaTimelord.
@@ -691,6 +1513,21 @@ func (in *ChiaTimelordSpec) DeepCopyInto(out *ChiaTimelordSpec) {
 	*out = *in
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdleThreshold != nil {
+		in, out := &in.IdleThreshold, &out.IdleThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(AlertingConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaTimelordSpec.
@@ -722,6 +1559,22 @@ func (in *ChiaTimelordSpecChia) DeepCopy() *ChiaTimelordSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaTimelordStatus) DeepCopyInto(out *ChiaTimelordStatus) {
 	*out = *in
+	if in.PeakHeight != nil {
+		in, out := &in.PeakHeight, &out.PeakHeight
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.LastPeakTime != nil {
+		in, out := &in.LastPeakTime, &out.LastPeakTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaTimelordStatus.
@@ -740,7 +1593,7 @@ func (in *ChiaWallet) DeepCopyInto(out *ChiaWallet) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaWallet.
@@ -798,6 +1651,16 @@ func (in *ChiaWalletSpec) DeepCopyInto(out *ChiaWalletSpec) {
 	*out = *in
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(AlertingConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaWalletSpec.
@@ -830,6 +1693,13 @@ func (in *ChiaWalletSpecChia) DeepCopy() *ChiaWalletSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaWalletStatus) DeepCopyInto(out *ChiaWalletStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaWalletStatus.
@@ -848,11 +1718,8 @@ func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
 	in.AdditionalMetadata.DeepCopyInto(&out.AdditionalMetadata)
 	in.ChiaExporterConfig.DeepCopyInto(&out.ChiaExporterConfig)
 	in.Sidecars.DeepCopyInto(&out.Sidecars)
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = new(StorageConfig)
-		(*in).DeepCopyInto(*out)
-	}
+	in.Service.DeepCopyInto(&out.Service)
+	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -865,6 +1732,11 @@ func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
 		*out = new(corev1.PodSecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonSpec.
@@ -952,6 +1824,52 @@ func (in *CommonSpecChia) DeepCopy() *CommonSpecChia {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseConfig) DeepCopyInto(out *DatabaseConfig) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(PersistentVolumeClaimConfig)
+		**out = **in
+	}
+	if in.HostPathVolume != nil {
+		in, out := &in.HostPathVolume, &out.HostPathVolume
+		*out = new(HostPathVolumeConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseConfig.
+func (in *DatabaseConfig) DeepCopy() *DatabaseConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseStorageConfig) DeepCopyInto(out *DatabaseStorageConfig) {
+	*out = *in
+	in.StorageConfig.DeepCopyInto(&out.StorageConfig)
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseStorageConfig.
+func (in *DatabaseStorageConfig) DeepCopy() *DatabaseStorageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseStorageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostPathVolumeConfig) DeepCopyInto(out *HostPathVolumeConfig) {
 	*out = *in
@@ -967,6 +1885,42 @@ func (in *HostPathVolumeConfig) DeepCopy() *HostPathVolumeConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfig) DeepCopyInto(out *NetworkPolicyConfig) {
+	*out = *in
+	if in.RPCFrom != nil {
+		in, out := &in.RPCFrom, &out.RPCFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricsFrom != nil {
+		in, out := &in.MetricsFrom, &out.MetricsFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdditionalIngress != nil {
+		in, out := &in.AdditionalIngress, &out.AdditionalIngress
+		*out = make([]networkingv1.NetworkPolicyIngressRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfig.
+func (in *NetworkPolicyConfig) DeepCopy() *NetworkPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PersistentVolumeClaimConfig) DeepCopyInto(out *PersistentVolumeClaimConfig) {
 	*out = *in
@@ -982,6 +1936,42 @@ func (in *PersistentVolumeClaimConfig) DeepCopy() *PersistentVolumeClaimConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlotCheckResult) DeepCopyInto(out *PlotCheckResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlotCheckResult.
+func (in *PlotCheckResult) DeepCopy() *PlotCheckResult {
+	if in == nil {
+		return nil
+	}
+	out := new(PlotCheckResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlotVolumeConfig) DeepCopyInto(out *PlotVolumeConfig) {
+	*out = *in
+	in.VolumeSource.DeepCopyInto(&out.VolumeSource)
+	if in.ReadOnly != nil {
+		in, out := &in.ReadOnly, &out.ReadOnly
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlotVolumeConfig.
+func (in *PlotVolumeConfig) DeepCopy() *PlotVolumeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PlotVolumeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlotsConfig) DeepCopyInto(out *PlotsConfig) {
 	*out = *in
@@ -1007,6 +1997,13 @@ func (in *PlotsConfig) DeepCopyInto(out *PlotsConfig) {
 			}
 		}
 	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]PlotVolumeConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlotsConfig.
@@ -1019,6 +2016,72 @@ func (in *PlotsConfig) DeepCopy() *PlotsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelabelConfig) DeepCopyInto(out *RelabelConfig) {
+	*out = *in
+	if in.SourceLabels != nil {
+		in, out := &in.SourceLabels, &out.SourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Separator != nil {
+		in, out := &in.Separator, &out.Separator
+		*out = new(string)
+		**out = **in
+	}
+	if in.Replacement != nil {
+		in, out := &in.Replacement, &out.Replacement
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelabelConfig.
+func (in *RelabelConfig) DeepCopy() *RelabelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RelabelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceConfig) DeepCopyInto(out *ServiceConfig) {
+	*out = *in
+	in.AdditionalMetadata.DeepCopyInto(&out.AdditionalMetadata)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LoadBalancerClass != nil {
+		in, out := &in.LoadBalancerClass, &out.LoadBalancerClass
+		*out = new(string)
+		**out = **in
+	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(corev1.IPFamilyPolicy)
+		**out = **in
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ServicePortName, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceConfig.
+func (in *ServiceConfig) DeepCopy() *ServiceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Sidecars) DeepCopyInto(out *Sidecars) {
 	*out = *in
@@ -1051,6 +2114,48 @@ func (in *Sidecars) DeepCopy() *Sidecars {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpecChiaExporter) DeepCopyInto(out *SpecChiaExporter) {
 	*out = *in
+	if in.MetricsPort != nil {
+		in, out := &in.MetricsPort, &out.MetricsPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ServiceLabels != nil {
 		in, out := &in.ServiceLabels, &out.ServiceLabels
 		*out = make(map[string]string, len(*in))
@@ -1058,6 +2163,12 @@ func (in *SpecChiaExporter) DeepCopyInto(out *SpecChiaExporter) {
 			(*out)[key] = val
 		}
 	}
+	in.Service.DeepCopyInto(&out.Service)
+	if in.Monitor != nil {
+		in, out := &in.Monitor, &out.Monitor
+		*out = new(ChiaExporterMonitor)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpecChiaExporter.
@@ -1078,11 +2189,6 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 		*out = new(ChiaRootConfig)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.Plots != nil {
-		in, out := &in.Plots, &out.Plots
-		*out = new(PlotsConfig)
-		(*in).DeepCopyInto(*out)
-	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageConfig.
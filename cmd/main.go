@@ -5,6 +5,7 @@ Copyright 2023 Chia Network Inc.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 
@@ -16,16 +17,20 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/chiaca"
 	"github.com/chia-network/chia-operator/internal/controller/chiafarmer"
 	"github.com/chia-network/chia-operator/internal/controller/chiaharvester"
+	"github.com/chia-network/chia-operator/internal/controller/chiamaintenance"
 	"github.com/chia-network/chia-operator/internal/controller/chianode"
 	"github.com/chia-network/chia-operator/internal/controller/chiaseeder"
 	"github.com/chia-network/chia-operator/internal/controller/chiatimelord"
 	"github.com/chia-network/chia-operator/internal/controller/chiawallet"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/metrics"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -83,26 +88,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	// CR totals and readiness are computed from the cache, so they're correct on every replica regardless of leader election
+	if err = metrics.RegisterResourceCollector(mgr.GetCache()); err != nil {
+		setupLog.Error(err, "unable to register resource metrics")
+		os.Exit(1)
+	}
+
+	// Optional integrations are disabled when their CRDs are not installed
+	volumeSnapshotsEnabled, err := kube.IsKindAvailable(mgr.GetRESTMapper(), kube.VolumeSnapshotGVK)
+	if err != nil {
+		setupLog.Error(err, "unable to discover VolumeSnapshot API")
+		os.Exit(1)
+	}
+	if !volumeSnapshotsEnabled {
+		setupLog.Info("VolumeSnapshot CRDs not found, ChiaNode backups are disabled")
+	}
+	prometheusOperator, err := kube.DiscoverPrometheusOperatorAPIs(mgr.GetRESTMapper())
+	if err != nil {
+		setupLog.Error(err, "unable to discover Prometheus Operator API")
+		os.Exit(1)
+	}
+	if len(prometheusOperator.MonitorKinds()) == 0 {
+		setupLog.Info("ServiceMonitor and PodMonitor CRDs not found, chia-exporter monitors are disabled")
+	}
+	if !prometheusOperator.PrometheusRules {
+		setupLog.Info("PrometheusRule CRD not found, alerting rules are disabled")
+	}
+
 	if err = (&chianode.ChiaNodeReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("chianode-controller"),
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		Recorder:               mgr.GetEventRecorderFor("chianode-controller"),
+		VolumeSnapshotsEnabled: volumeSnapshotsEnabled,
+		PrometheusOperator:     prometheusOperator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ChiaNode")
 		os.Exit(1)
 	}
 	if err = (&chiafarmer.ChiaFarmerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("chiafarmer-controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("chiafarmer-controller"),
+		PrometheusOperator: prometheusOperator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ChiaFarmer")
 		os.Exit(1)
 	}
 	if err = (&chiaharvester.ChiaHarvesterReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("chiaharvester-controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("chiaharvester-controller"),
+		PrometheusOperator: prometheusOperator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ChiaHarvester")
 		os.Exit(1)
@@ -116,31 +152,56 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&chiawallet.ChiaWalletReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("chiawallet-controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("chiawallet-controller"),
+		PrometheusOperator: prometheusOperator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ChiaWallet")
 		os.Exit(1)
 	}
 	if err = (&chiatimelord.ChiaTimelordReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("chiatimelord-controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("chiatimelord-controller"),
+		PrometheusOperator: prometheusOperator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ChiaTimelord")
 		os.Exit(1)
 	}
 	if err = (&chiaseeder.ChiaSeederReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("chiaseeder-controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("chiaseeder-controller"),
+		PrometheusOperator: prometheusOperator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ChiaSeeder")
 		os.Exit(1)
 	}
+	if err = (&chiamaintenance.ChiaMaintenanceReconciler{
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("chiamaintenance-controller"),
+		NodePodConfig:      chianode.GetMaintenancePodConfig,
+		HarvesterPodConfig: chiaharvester.GetMaintenancePodConfig,
+		WalletPodConfig:    chiawallet.GetMaintenancePodConfig,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ChiaMaintenance")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
+	// Remove chia-exporter monitors and PrometheusRules left in other namespaces by CRs deleted while the operator wasn't running
+	if err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if err := kube.RemoveOrphanedPrometheusObjects(ctx, mgr.GetClient(), mgr.GetAPIReader(), prometheusOperator); err != nil {
+			setupLog.Error(err, "unable to remove orphaned chia-exporter monitors and PrometheusRules")
+		}
+		return nil
+	})); err != nil {
+		setupLog.Error(err, "unable to set up orphaned Prometheus Operator object cleanup")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
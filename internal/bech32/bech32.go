@@ -0,0 +1,114 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package bech32 decodes the bech32m addresses chia uses for puzzle hashes, like xch1... and txch1... addresses.
+package bech32
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// bech32mConst is the checksum constant for bech32m, see BIP-350
+	bech32mConst = 0x2bc830a3
+)
+
+var generator = []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// DecodePuzzleHash decodes a chia address, returning its human readable prefix and the puzzle hash it encodes
+func DecodePuzzleHash(address string) (string, []byte, error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return "", nil, fmt.Errorf("address %s has mixed case", address)
+	}
+	address = strings.ToLower(address)
+
+	sep := strings.LastIndex(address, "1")
+	if sep < 1 || sep+7 > len(address) {
+		return "", nil, fmt.Errorf("address %s is not bech32m encoded", address)
+	}
+	hrp := address[:sep]
+
+	var data []byte
+	for _, c := range address[sep+1:] {
+		value := strings.IndexRune(charset, c)
+		if value < 0 {
+			return "", nil, fmt.Errorf("address %s contains invalid character %q", address, c)
+		}
+		data = append(data, byte(value))
+	}
+
+	if polymod(append(expandHRP(hrp), data...)) != bech32mConst {
+		return "", nil, fmt.Errorf("address %s has an invalid checksum", address)
+	}
+
+	puzzleHash, err := convertBits(data[:len(data)-6])
+	if err != nil {
+		return "", nil, fmt.Errorf("address %s: %v", address, err)
+	}
+	if len(puzzleHash) != 32 {
+		return "", nil, fmt.Errorf("address %s encodes %d bytes, puzzle hashes are 32 bytes", address, len(puzzleHash))
+	}
+
+	return hrp, puzzleHash, nil
+}
+
+// DecodePuzzleHashHex decodes a chia address, returning the puzzle hash it encodes as 0x prefixed hex
+func DecodePuzzleHashHex(address string) (string, error) {
+	_, puzzleHash, err := DecodePuzzleHash(address)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(puzzleHash), nil
+}
+
+// polymod computes the bech32 checksum over the expanded prefix and data
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+// expandHRP expands the human readable prefix for checksum computation
+func expandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c)>>5)
+	}
+	expanded = append(expanded, 0)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c)&31)
+	}
+	return expanded
+}
+
+// convertBits regroups 5-bit words into bytes, rejecting non-zero padding
+func convertBits(data []byte) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	var out []byte
+	for _, v := range data {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	if bits >= 5 || (acc<<(8-bits))&0xff != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
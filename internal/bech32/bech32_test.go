@@ -0,0 +1,53 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package bech32
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodePuzzleHash(t *testing.T) {
+	hrp, puzzleHash, err := DecodePuzzleHash("txch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqm6ksh7qddh")
+	if err != nil {
+		t.Fatalf("Error decoding address: %v", err)
+	}
+	if hrp != "txch" {
+		t.Errorf("Expected prefix txch, got %s", hrp)
+	}
+	expect := make([]byte, 32)
+	expect[30], expect[31] = 0xde, 0xad
+	if diff := cmp.Diff(expect, puzzleHash); diff != "" {
+		t.Errorf("Puzzle hash mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecodePuzzleHashHex(t *testing.T) {
+	puzzleHash, err := DecodePuzzleHashHex("XCH1QQQSYQCYQ5RQWZQFPG9SCRGWPUGPZYSNZS23V9CCRYDPK8QARC0SRG6DKM")
+	if err != nil {
+		t.Fatalf("Error decoding address: %v", err)
+	}
+	expect := "0x000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	if puzzleHash != expect {
+		t.Errorf("Expected puzzle hash %s, got %s", expect, puzzleHash)
+	}
+}
+
+func TestDecodePuzzleHashInvalid(t *testing.T) {
+	addresses := map[string]string{
+		"bad checksum":      "xch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqm6ks6e8mvq",
+		"mixed case":        "xch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqM6ks6e8mvy",
+		"invalid character": "xch1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqb6ks6e8mvy",
+		"no separator":      "xchqqqqqqqq",
+		"wrong length":      "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+	}
+	for name, address := range addresses {
+		_, _, err := DecodePuzzleHash(address)
+		if err == nil {
+			t.Errorf("%s: expected an error decoding %s", name, address)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// cachedClientMaxAge is how long a cached Client is reused. It's well under the client certificate's validity so certificates never expire in use.
+const cachedClientMaxAge = clientCertValidity / 2
+
+// cachedClient is a Client in a ClientCache with the CA Secret version it was created from
+type cachedClient struct {
+	client            *Client
+	caResourceVersion string
+	created           time.Time
+}
+
+// ClientCache reuses Clients across reconciles, so periodic RPC queries don't sign a new client certificate and open new connections each time.
+// Clients are recreated when their CA Secret changes or they get old. The zero value is ready to use.
+type ClientCache struct {
+	mu      sync.Mutex
+	clients map[string]cachedClient
+}
+
+// Get gives a Client for the RPC server at baseURL authenticated with the given ChiaCA Secret, reusing a cached one if it's still valid
+func (c *ClientCache) Get(ctx context.Context, k8sClient client.Reader, namespace, caSecretName, baseURL string, opts ...Option) (*Client, error) {
+	var caSecret corev1.Secret
+	err := k8sClient.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      caSecretName,
+	}, &caSecret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch CA Secret %s/%s: %v", namespace, caSecretName, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clients == nil {
+		c.clients = make(map[string]cachedClient)
+	}
+
+	// Drop expired clients, including ones for servers that are no longer queried, like the IPs of deleted pods
+	now := time.Now()
+	for url, cached := range c.clients {
+		if now.Sub(cached.created) > cachedClientMaxAge {
+			cached.client.Close()
+			delete(c.clients, url)
+		}
+	}
+
+	if cached, ok := c.clients[baseURL]; ok {
+		if cached.caResourceVersion == caSecret.ResourceVersion {
+			return cached.client, nil
+		}
+		cached.client.Close()
+	}
+
+	rpc, err := NewClient(baseURL, caSecret.Data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[baseURL] = cachedClient{
+		client:            rpc,
+		caResourceVersion: caSecret.ResourceVersion,
+		created:           now,
+	}
+	return rpc, nil
+}
+
+// Remove closes and forgets the cached Client for the RPC server at baseURL, like when the component it queries was deleted
+func (c *ClientCache) Remove(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.clients[baseURL]; ok {
+		cached.client.Close()
+		delete(c.clients, baseURL)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc_test
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/chiarpc/chiarpctest"
+)
+
+func TestClientCache(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "chiaca-secret", Namespace: "chia"},
+		Data:       caData,
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	var cache chiarpc.ClientCache
+	first, err := cache.Get(context.Background(), k8sClient, "chia", "chiaca-secret", "https://timelord.chia.svc:8557")
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	second, err := cache.Get(context.Background(), k8sClient, "chia", "chiaca-secret", "https://timelord.chia.svc:8557")
+	if err != nil {
+		t.Fatalf("Error getting cached client: %v", err)
+	}
+	if first != second {
+		t.Errorf("Expected the cached client to be reused")
+	}
+
+	secret.Data = caData
+	secret.Labels = map[string]string{"rotated": "true"}
+	err = k8sClient.Update(context.Background(), secret)
+	if err != nil {
+		t.Fatalf("Error updating CA Secret: %v", err)
+	}
+	third, err := cache.Get(context.Background(), k8sClient, "chia", "chiaca-secret", "https://timelord.chia.svc:8557")
+	if err != nil {
+		t.Fatalf("Error recreating client: %v", err)
+	}
+	if third == second {
+		t.Errorf("Expected a new client after the CA Secret changed")
+	}
+
+	_, err = cache.Get(context.Background(), k8sClient, "chia", "missing-secret", "https://timelord.chia.svc:8557")
+	if err == nil {
+		t.Errorf("Expected an error for a missing CA Secret")
+	}
+}
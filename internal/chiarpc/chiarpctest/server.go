@@ -0,0 +1,141 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package chiarpctest provides an in-process chia RPC server for tests of code that uses chiarpc.
+package chiarpctest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+)
+
+// NewCASecretData generates a throwaway private CA in the same layout as a ChiaCA Secret's data, for use with Server in tests
+func NewCASecretData() (map[string][]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:         "Chia CA",
+			Organization:       []string{"Chia"},
+			OrganizationalUnit: []string{"Organic Farming Division"},
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		chiarpc.PrivateCACertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		chiarpc.PrivateCAKeyKey:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}, nil
+}
+
+// Server is an in-process chia RPC server for tests.
+// Like a real chia service, it requires client certificates signed by the private CA it was created with.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]interface{}
+	hangUps   map[string]bool
+	requests  map[string]int
+}
+
+// NewServer starts a Server whose certificates are signed by the private CA in the given ChiaCA Secret data
+func NewServer(caSecretData map[string][]byte) (*Server, error) {
+	tlsConfig, err := chiarpc.NewServerTLSConfig(caSecretData, []string{"localhost"})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		responses: make(map[string]interface{}),
+		hangUps:   make(map[string]bool),
+		requests:  make(map[string]int),
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.Server.TLS = tlsConfig
+	s.Server.StartTLS()
+
+	return s, nil
+}
+
+// DialOption gives a chiarpc.Option that connects a Client to the Server whatever its base URL is.
+// This lets controllers that build RPC URLs from in-cluster Service names and pod IPs query the Server in tests.
+func (s *Server) DialOption() chiarpc.Option {
+	return chiarpc.WithDialContext(func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, s.Listener.Addr().String())
+	})
+}
+
+// SetResponse sets the JSON body returned for an endpoint. Endpoints without a response return 404, as chia does for unknown routes.
+func (s *Server) SetResponse(endpoint string, body interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[strings.TrimPrefix(endpoint, "/")] = body
+}
+
+// SetHangUp makes the Server close the connection without responding to requests for an endpoint, like a chia service that crashed mid-request
+func (s *Server) SetHangUp(endpoint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hangUps[strings.TrimPrefix(endpoint, "/")] = true
+}
+
+// Requests gives the number of requests the Server has received for an endpoint
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[strings.TrimPrefix(endpoint, "/")]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	endpoint := strings.TrimPrefix(req.URL.Path, "/")
+
+	s.mu.Lock()
+	s.requests[endpoint]++
+	body, ok := s.responses[endpoint]
+	hangUp := s.hangUps[endpoint]
+	s.mu.Unlock()
+
+	if hangUp {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+		return
+	}
+
+	if req.Method != http.MethodPost || !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
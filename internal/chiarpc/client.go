@@ -0,0 +1,198 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package chiarpc provides an mTLS client for the RPC servers of chia components managed by the operator.
+package chiarpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultTimeout is the default maximum amount of time a single RPC request may take
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultRetries is the default number of times a request is retried after failing to connect
+	DefaultRetries = 2
+
+	// retryBackoff is the wait between retried requests, multiplied by the attempt number
+	retryBackoff = 500 * time.Millisecond
+)
+
+// Client sends requests to a single chia component's RPC server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	transport  *http.Transport
+	retries    int
+}
+
+// Option configures optional Client settings
+type Option func(*Client)
+
+// WithTimeout sets the maximum amount of time a single RPC request may take
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries sets the number of times a request is retried after failing to connect
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// WithDialContext sets the function used to open connections to the RPC server, like to route requests to a fake RPC server in tests
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(c *Client) {
+		c.transport.DialContext = dial
+	}
+}
+
+// ServiceURL gives the in-cluster base URL for an RPC server behind a Service
+func ServiceURL(serviceName, namespace string, port int32) string {
+	return fmt.Sprintf("https://%s.%s.svc:%d", serviceName, namespace, port)
+}
+
+// NewClient creates a Client for the RPC server at baseURL, authenticating with the private CA in the given ChiaCA Secret data
+func NewClient(baseURL string, caSecretData map[string][]byte, opts ...Option) (*Client, error) {
+	tlsConfig, err := NewTLSConfig(caSecretData)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout:   DefaultTimeout,
+			Transport: transport,
+		},
+		transport: transport,
+		retries:   DefaultRetries,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// NewClientFromSecret fetches a ChiaCA Secret and creates a Client for the RPC server at baseURL with it
+func NewClientFromSecret(ctx context.Context, k8sClient client.Client, namespace, caSecretName, baseURL string, opts ...Option) (*Client, error) {
+	var caSecret corev1.Secret
+	err := k8sClient.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      caSecretName,
+	}, &caSecret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch CA Secret %s/%s: %v", namespace, caSecretName, err)
+	}
+
+	return NewClient(baseURL, caSecret.Data, opts...)
+}
+
+// Close releases the Client's idle connections
+func (c *Client) Close() {
+	c.transport.CloseIdleConnections()
+}
+
+// response is implemented by every RPC response body so failed requests can be detected
+type response interface {
+	succeeded() (bool, string)
+}
+
+// Response holds the fields chia includes in every RPC response body
+type Response struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (r Response) succeeded() (bool, string) {
+	return r.Success, r.Error
+}
+
+// isConnectError gives whether a request failed while establishing its connection, before anything was sent to the server.
+// Only these are safe to retry since RPC requests are POSTs and aren't all idempotent.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Do sends a request to an RPC endpoint and decodes the response body into out.
+// Failures to connect drop the Client's idle connections and are retried, so a restarted chia service is reconnected to transparently.
+// Requests that fail after the connection was established are not retried, since the server may have already acted on them.
+func (c *Client) Do(ctx context.Context, endpoint string, request interface{}, out response) error {
+	if request == nil {
+		request = map[string]interface{}{}
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/%s", c.baseURL, strings.TrimPrefix(endpoint, "/"))
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err = c.httpClient.Do(req)
+		if err == nil {
+			break
+		}
+		if attempt >= c.retries || ctx.Err() != nil || !isConnectError(err) {
+			return fmt.Errorf("RPC request to %s failed: %v", url, err)
+		}
+
+		c.transport.CloseIdleConnections()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("RPC request to %s returned status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("unable to decode RPC response from %s: %v", url, err)
+	}
+	if ok, msg := out.succeeded(); !ok {
+		if msg == "" {
+			msg = "unknown error"
+		}
+		return fmt.Errorf("RPC request to %s was unsuccessful: %s", url, msg)
+	}
+
+	return nil
+}
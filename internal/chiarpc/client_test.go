@@ -0,0 +1,211 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/chiarpc/chiarpctest"
+)
+
+func TestGetBlockchainState(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	server, err := chiarpctest.NewServer(caData)
+	if err != nil {
+		t.Fatalf("Error starting fake server: %v", err)
+	}
+	defer server.Close()
+
+	server.SetResponse("get_blockchain_state", map[string]interface{}{
+		"success": true,
+		"blockchain_state": map[string]interface{}{
+			"peak": map[string]interface{}{
+				"header_hash": "0xabc",
+				"height":      4500000,
+			},
+			"sync": map[string]interface{}{
+				"synced":               false,
+				"sync_mode":            true,
+				"sync_progress_height": 4400000,
+				"sync_tip_height":      4500000,
+			},
+		},
+	})
+
+	c, err := chiarpc.NewClient(server.URL, caData)
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	defer c.Close()
+
+	actual, err := c.GetBlockchainState(context.Background())
+	if err != nil {
+		t.Fatalf("Error querying fake server: %v", err)
+	}
+
+	expect := chiarpc.BlockchainState{
+		Peak: &chiarpc.BlockRecord{
+			HeaderHash: "0xabc",
+			Height:     4500000,
+		},
+		Sync: chiarpc.SyncState{
+			SyncMode:           true,
+			SyncProgressHeight: 4400000,
+			SyncTipHeight:      4500000,
+		},
+	}
+	diff := cmp.Diff(actual.BlockchainState, expect)
+	if diff != "" {
+		t.Errorf("Blockchain state does not match the expected state. Diff: %s", diff)
+	}
+}
+
+func TestUnsuccessfulResponse(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	server, err := chiarpctest.NewServer(caData)
+	if err != nil {
+		t.Fatalf("Error starting fake server: %v", err)
+	}
+	defer server.Close()
+
+	server.SetResponse("get_sync_status", map[string]interface{}{
+		"success": false,
+		"error":   "wallet is not running",
+	})
+
+	c, err := chiarpc.NewClient(server.URL, caData, chiarpc.WithRetries(0))
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.GetWalletSyncStatus(context.Background())
+	if err == nil {
+		t.Errorf("Expected an error for an unsuccessful RPC response")
+	}
+
+	_, err = c.GetPlots(context.Background())
+	if err == nil {
+		t.Errorf("Expected an error for an unknown RPC endpoint")
+	}
+}
+
+func TestUntrustedCA(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	otherCAData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	server, err := chiarpctest.NewServer(caData)
+	if err != nil {
+		t.Fatalf("Error starting fake server: %v", err)
+	}
+	defer server.Close()
+
+	server.SetResponse("healthz", map[string]interface{}{
+		"success": true,
+	})
+
+	c, err := chiarpc.NewClient(server.URL, otherCAData, chiarpc.WithRetries(0))
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	defer c.Close()
+
+	err = c.Healthz(context.Background())
+	if err == nil {
+		t.Errorf("Expected an error when the client and server use different CAs")
+	}
+	if server.Requests("healthz") != 0 {
+		t.Errorf("Expected the fake server to reject the request during the TLS handshake")
+	}
+}
+
+func TestLargeNetworkSpace(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	server, err := chiarpctest.NewServer(caData)
+	if err != nil {
+		t.Fatalf("Error starting fake server: %v", err)
+	}
+	defer server.Close()
+
+	// 40 EiB, well over the 2^64 bytes a uint64 can hold
+	space := "46116860184273879040000"
+	server.SetResponse("get_blockchain_state", json.RawMessage(`{"success": true, "blockchain_state": {"difficulty": 11136, "space": `+space+`}}`))
+
+	c, err := chiarpc.NewClient(server.URL, caData)
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	defer c.Close()
+
+	actual, err := c.GetBlockchainState(context.Background())
+	if err != nil {
+		t.Fatalf("Error querying fake server: %v", err)
+	}
+	if actual.BlockchainState.Space.String() != space {
+		t.Errorf("Expected space %s, got %s", space, actual.BlockchainState.Space)
+	}
+}
+
+func TestNoRetryAfterConnect(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	server, err := chiarpctest.NewServer(caData)
+	if err != nil {
+		t.Fatalf("Error starting fake server: %v", err)
+	}
+	defer server.Close()
+
+	server.SetHangUp("get_reward_targets")
+
+	c, err := chiarpc.NewClient(server.URL, caData, chiarpc.WithRetries(2))
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.GetRewardTargets(context.Background())
+	if err == nil {
+		t.Errorf("Expected an error when the server closes the connection")
+	}
+	if server.Requests("get_reward_targets") != 1 {
+		t.Errorf("Expected a request that reached the server not to be retried, got %d requests", server.Requests("get_reward_targets"))
+	}
+}
+
+func TestRetryConnectError(t *testing.T) {
+	if !chiarpc.IsConnectError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}) {
+		t.Errorf("Expected dial errors to be retried")
+	}
+	if chiarpc.IsConnectError(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}) {
+		t.Errorf("Expected read errors not to be retried")
+	}
+	if chiarpc.IsConnectError(io.ErrUnexpectedEOF) {
+		t.Errorf("Expected unexpected EOF not to be retried")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import "context"
+
+// NodeType is the type of a chia peer as reported in get_connections responses
+type NodeType int
+
+const (
+	// NodeTypeFullNode is the NodeType of full_node peers
+	NodeTypeFullNode NodeType = 1
+
+	// NodeTypeHarvester is the NodeType of harvester peers
+	NodeTypeHarvester NodeType = 2
+
+	// NodeTypeFarmer is the NodeType of farmer peers
+	NodeTypeFarmer NodeType = 3
+
+	// NodeTypeTimelord is the NodeType of timelord peers
+	NodeTypeTimelord NodeType = 4
+
+	// NodeTypeIntroducer is the NodeType of introducer peers
+	NodeTypeIntroducer NodeType = 5
+
+	// NodeTypeWallet is the NodeType of wallet peers
+	NodeTypeWallet NodeType = 6
+)
+
+// Connection is a single peer connection reported by get_connections
+type Connection struct {
+	NodeID     string   `json:"node_id"`
+	Type       NodeType `json:"type"`
+	PeerHost   string   `json:"peer_host"`
+	PeerPort   int      `json:"peer_port"`
+	PeakHeight *uint32  `json:"peak_height,omitempty"`
+}
+
+// GetConnectionsResponse is the response body of the get_connections endpoint
+type GetConnectionsResponse struct {
+	Response
+	Connections []Connection `json:"connections"`
+}
+
+// GetConnections lists the peers the chia service is connected to. This endpoint is served by every chia RPC server.
+func (c *Client) GetConnections(ctx context.Context) (GetConnectionsResponse, error) {
+	var resp GetConnectionsResponse
+	err := c.Do(ctx, "get_connections", nil, &resp)
+	return resp, err
+}
+
+// Healthz checks that the chia service's RPC server is responding
+func (c *Client) Healthz(ctx context.Context) error {
+	var resp Response
+	return c.Do(ctx, "healthz", nil, &resp)
+}
+
+// GetNetworkInfoResponse is the response body of the get_network_info endpoint
+type GetNetworkInfoResponse struct {
+	Response
+	NetworkName   string `json:"network_name"`
+	NetworkPrefix string `json:"network_prefix"`
+}
+
+// GetNetworkInfo gives the network the chia service is configured for
+func (c *Client) GetNetworkInfo(ctx context.Context) (GetNetworkInfoResponse, error) {
+	var resp GetNetworkInfoResponse
+	err := c.Do(ctx, "get_network_info", nil, &resp)
+	return resp, err
+}
@@ -0,0 +1,29 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import "context"
+
+// PeerCounts holds the peer statistics gathered by a crawler
+type PeerCounts struct {
+	TotalLast5Days int            `json:"total_last_5_days"`
+	ReliableNodes  int            `json:"reliable_nodes"`
+	IPv4Last5Days  int            `json:"ipv4_last_5_days"`
+	IPv6Last5Days  int            `json:"ipv6_last_5_days"`
+	Versions       map[string]int `json:"versions"`
+}
+
+// GetPeerCountsResponse is the response body of the crawler get_peer_counts endpoint
+type GetPeerCountsResponse struct {
+	Response
+	PeerCounts PeerCounts `json:"peer_counts"`
+}
+
+// GetPeerCounts gives the crawler's peer statistics
+func (c *Client) GetPeerCounts(ctx context.Context) (GetPeerCountsResponse, error) {
+	var resp GetPeerCountsResponse
+	err := c.Do(ctx, "get_peer_counts", nil, &resp)
+	return resp, err
+}
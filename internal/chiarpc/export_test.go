@@ -0,0 +1,8 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+// IsConnectError exposes isConnectError to the chiarpc_test package
+var IsConnectError = isConnectError
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import "context"
+
+// HarvesterConnection identifies the harvester a farmer's harvester summary belongs to
+type HarvesterConnection struct {
+	NodeID string `json:"node_id"`
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+}
+
+// HarvesterSummary is a farmer's view of a single connected harvester
+type HarvesterSummary struct {
+	Connection            HarvesterConnection `json:"connection"`
+	Plots                 int                 `json:"plots"`
+	FailedToOpenFilenames int                 `json:"failed_to_open_filenames"`
+	NoKeyFilenames        int                 `json:"no_key_filenames"`
+	Duplicates            int                 `json:"duplicates"`
+	TotalPlotSize         uint64              `json:"total_plot_size"`
+	Syncing               *HarvesterSyncing   `json:"syncing,omitempty"`
+}
+
+// HarvesterSyncing describes a harvester's in-progress plot sync with its farmer
+type HarvesterSyncing struct {
+	Initial            bool `json:"initial"`
+	PlotFilesProcessed int  `json:"plot_files_processed"`
+	PlotFilesTotal     int  `json:"plot_files_total"`
+}
+
+// GetHarvestersSummaryResponse is the response body of the farmer get_harvesters_summary endpoint
+type GetHarvestersSummaryResponse struct {
+	Response
+	Harvesters []HarvesterSummary `json:"harvesters"`
+}
+
+// GetHarvestersSummary gives a summary of each harvester connected to the farmer
+func (c *Client) GetHarvestersSummary(ctx context.Context) (GetHarvestersSummaryResponse, error) {
+	var resp GetHarvestersSummaryResponse
+	err := c.Do(ctx, "get_harvesters_summary", nil, &resp)
+	return resp, err
+}
+
+// GetRewardTargetsResponse is the response body of the farmer get_reward_targets endpoint
+type GetRewardTargetsResponse struct {
+	Response
+	FarmerTarget string `json:"farmer_target"`
+	PoolTarget   string `json:"pool_target"`
+}
+
+// GetRewardTargets gives the farmer and pool reward addresses the farmer is configured with
+func (c *Client) GetRewardTargets(ctx context.Context) (GetRewardTargetsResponse, error) {
+	var resp GetRewardTargetsResponse
+	err := c.Do(ctx, "get_reward_targets", map[string]interface{}{
+		"search_for_private_key": false,
+	}, &resp)
+	return resp, err
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BlockRecord holds the subset of a full_node block record the operator uses
+type BlockRecord struct {
+	HeaderHash string  `json:"header_hash"`
+	Height     uint32  `json:"height"`
+	Timestamp  *uint64 `json:"timestamp,omitempty"`
+}
+
+// SyncState describes the sync progress of a full_node
+type SyncState struct {
+	Synced             bool   `json:"synced"`
+	SyncMode           bool   `json:"sync_mode"`
+	SyncProgressHeight uint32 `json:"sync_progress_height"`
+	SyncTipHeight      uint32 `json:"sync_tip_height"`
+}
+
+// BlockchainState describes the state of a full_node's blockchain
+type BlockchainState struct {
+	Peak       *BlockRecord `json:"peak"`
+	Sync       SyncState    `json:"sync"`
+	Difficulty uint64       `json:"difficulty"`
+	// Space is the estimated network space in bytes, which doesn't fit in a uint64
+	Space  json.Number `json:"space"`
+	NodeID string      `json:"node_id"`
+}
+
+// GetBlockchainStateResponse is the response body of the full_node get_blockchain_state endpoint
+type GetBlockchainStateResponse struct {
+	Response
+	BlockchainState BlockchainState `json:"blockchain_state"`
+}
+
+// GetBlockchainState gives the full_node's peak and sync state
+func (c *Client) GetBlockchainState(ctx context.Context) (GetBlockchainStateResponse, error) {
+	var resp GetBlockchainStateResponse
+	err := c.Do(ctx, "get_blockchain_state", nil, &resp)
+	return resp, err
+}
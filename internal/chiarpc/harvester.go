@@ -0,0 +1,43 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import "context"
+
+// Plot is a single plot reported by a harvester
+type Plot struct {
+	Filename string `json:"filename"`
+	PlotID   string `json:"plot_id"`
+	Size     int    `json:"size"`
+	FileSize uint64 `json:"file_size"`
+}
+
+// GetPlotsResponse is the response body of the harvester get_plots endpoint
+type GetPlotsResponse struct {
+	Response
+	Plots                 []Plot   `json:"plots"`
+	FailedToOpenFilenames []string `json:"failed_to_open_filenames"`
+	NotFoundFilenames     []string `json:"not_found_filenames"`
+}
+
+// GetPlots lists the plots the harvester has loaded, and the plot files it could not load
+func (c *Client) GetPlots(ctx context.Context) (GetPlotsResponse, error) {
+	var resp GetPlotsResponse
+	err := c.Do(ctx, "get_plots", nil, &resp)
+	return resp, err
+}
+
+// GetPlotDirectoriesResponse is the response body of the harvester get_plot_directories endpoint
+type GetPlotDirectoriesResponse struct {
+	Response
+	Directories []string `json:"directories"`
+}
+
+// GetPlotDirectories lists the plot directories the harvester is configured to scan
+func (c *Client) GetPlotDirectories(ctx context.Context) (GetPlotDirectoriesResponse, error) {
+	var resp GetPlotDirectoriesResponse
+	err := c.Do(ctx, "get_plot_directories", nil, &resp)
+	return resp, err
+}
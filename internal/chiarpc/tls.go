@@ -0,0 +1,159 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// PrivateCACertKey is the key in a ChiaCA Secret that holds the private CA certificate
+	PrivateCACertKey = "private_ca.crt"
+
+	// PrivateCAKeyKey is the key in a ChiaCA Secret that holds the private CA private key
+	PrivateCAKeyKey = "private_ca.key"
+
+	// clientCertValidity is how long a generated RPC client certificate is valid for
+	clientCertValidity = 24 * time.Hour
+)
+
+// NewTLSConfig assembles a TLS config for chia RPC servers from the data of a ChiaCA Secret.
+// Chia RPC servers require a client certificate signed by the private CA, so a short-lived one is generated from private_ca.key.
+// Chia server certificates are not issued for a hostname, so the server's chain is verified against the private CA without a hostname check.
+func NewTLSConfig(secretData map[string][]byte) (*tls.Config, error) {
+	caCert, caKey, err := parseCA(secretData)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCert, err := signCertificate(caCert, caKey, x509.ExtKeyUsageClientAuth, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign RPC client certificate: %v", err)
+	}
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	return &tls.Config{
+		Certificates:       []tls.Certificate{clientCert},
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, caPool)
+		},
+	}, nil
+}
+
+// NewServerTLSConfig assembles a TLS config for serving chia RPC from the data of a ChiaCA Secret, like a chia service does.
+// The server certificate is signed by the private CA for the given DNS names, and clients must present a certificate signed by it too.
+func NewServerTLSConfig(secretData map[string][]byte, dnsNames []string) (*tls.Config, error) {
+	caCert, caKey, err := parseCA(secretData)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCert, err := signCertificate(caCert, caKey, x509.ExtKeyUsageServerAuth, dnsNames)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign RPC server certificate: %v", err)
+	}
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    caPool,
+	}, nil
+}
+
+// parseCA decodes the private CA certificate and key from ChiaCA Secret data
+func parseCA(secretData map[string][]byte) (*x509.Certificate, crypto.Signer, error) {
+	caCertBlock, _ := pem.Decode(secretData[PrivateCACertKey])
+	if caCertBlock == nil {
+		return nil, nil, fmt.Errorf("CA Secret does not contain a PEM encoded %s", PrivateCACertKey)
+	}
+	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse %s: %v", PrivateCACertKey, err)
+	}
+
+	caKeyBlock, _ := pem.Decode(secretData[PrivateCAKeyKey])
+	if caKeyBlock == nil {
+		return nil, nil, fmt.Errorf("CA Secret does not contain a PEM encoded %s", PrivateCAKeyKey)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(caKeyBlock.Bytes); err == nil {
+		return caCert, key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse %s: %v", PrivateCAKeyKey, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a signing key", PrivateCAKeyKey)
+	}
+
+	return caCert, signer, nil
+}
+
+// signCertificate generates a key pair and a certificate for it signed by the given CA
+func signCertificate(caCert *x509.Certificate, caKey crypto.Signer, usage x509.ExtKeyUsage, dnsNames []string) (tls.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         "Chia",
+			Organization:       []string{"Chia"},
+			OrganizationalUnit: []string{"Organic Farming Division"},
+		},
+		DNSNames:    dnsNames,
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(clientCertValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
+
+// verifyChain verifies the leaf certificate presented by a chia service against the private CA
+func verifyChain(rawCerts [][]byte, caPool *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("peer presented no certificates")
+	}
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return err
+	}
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     caPool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import "context"
+
+// GetSyncStatusResponse is the response body of the wallet get_sync_status endpoint
+type GetSyncStatusResponse struct {
+	Response
+	Synced             bool `json:"synced"`
+	Syncing            bool `json:"syncing"`
+	GenesisInitialized bool `json:"genesis_initialized"`
+}
+
+// GetWalletSyncStatus gives the wallet's sync status
+func (c *Client) GetWalletSyncStatus(ctx context.Context) (GetSyncStatusResponse, error) {
+	var resp GetSyncStatusResponse
+	err := c.Do(ctx, "get_sync_status", nil, &resp)
+	return resp, err
+}
+
+// GetHeightInfoResponse is the response body of the wallet get_height_info endpoint
+type GetHeightInfoResponse struct {
+	Response
+	Height uint32 `json:"height"`
+}
+
+// GetWalletHeightInfo gives the height the wallet is synced to
+func (c *Client) GetWalletHeightInfo(ctx context.Context) (GetHeightInfoResponse, error) {
+	var resp GetHeightInfoResponse
+	err := c.Do(ctx, "get_height_info", nil, &resp)
+	return resp, err
+}
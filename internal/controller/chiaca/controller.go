@@ -31,8 +31,6 @@ type ChiaCAReconciler struct {
 	Recorder record.EventRecorder
 }
 
-var chiacas map[string]bool = make(map[string]bool)
-
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas/finalizers,verbs=update
@@ -46,6 +44,7 @@ var chiacas map[string]bool = make(map[string]bool)
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaCA", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaCAReconciler ChiaCA=%s", req.NamespacedName.String()))
@@ -54,27 +53,14 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	var ca k8schianetv1.ChiaCA
 	err := r.Get(ctx, req.NamespacedName, &ca)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiacas[req.NamespacedName.String()]
-		if exists {
-			delete(chiacas, req.NamespacedName.String())
-			metrics.ChiaCAs.Sub(1.0)
-		}
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaCA", "ChiaCA")
 		log.Error(err, fmt.Sprintf("ChiaCAReconciler ChiaCA=%s unable to fetch ChiaCA resource", req.NamespacedName))
 		return ctrl.Result{}, err
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiacas[req.NamespacedName.String()]
-	if !exists {
-		chiacas[req.NamespacedName.String()] = true
-		metrics.ChiaCAs.Add(1.0)
-	}
-
 	// Reconcile resources, creating them if they don't exist
 	sa := r.assembleServiceAccount(ctx, ca)
 	res, err := kube.ReconcileServiceAccount(ctx, resourceReconciler, sa)
@@ -82,7 +68,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaCA", "ServiceAccount")
 		r.Recorder.Event(&ca, corev1.EventTypeWarning, "Failed", "Failed to create ServiceAccount -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaCAReconciler ChiaCA=%s encountered error reconciling CA generator ServiceAccount: %v", req.NamespacedName, err)
 	}
@@ -93,7 +79,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaCA", "Role")
 		r.Recorder.Event(&ca, corev1.EventTypeWarning, "Failed", "Failed to create Role -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaCAReconciler ChiaCA=%s encountered error reconciling CA generator Role: %v", req.NamespacedName, err)
 	}
@@ -104,7 +90,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaCA", "RoleBinding")
 		r.Recorder.Event(&ca, corev1.EventTypeWarning, "Failed", "Failed to create RoleBinding -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaCAReconciler ChiaCA=%s encountered error reconciling CA generator RoleBinding: %v", req.NamespacedName, err)
 	}
@@ -112,7 +98,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	// Query CA Secret
 	_, notFound, err := r.getCASecret(ctx, ca)
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaCA", "Secret")
 		log.Error(err, fmt.Sprintf("ChiaCAReconciler ChiaCA=%s unable to query for ChiaCA secret", req.NamespacedName))
 		return ctrl.Result{}, err
 	}
@@ -124,7 +110,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			if res == nil {
 				res = &reconcile.Result{}
 			}
-			metrics.OperatorErrors.Add(1.0)
+			metrics.RecordReconcileError("ChiaCA", "Job")
 			r.Recorder.Event(&ca, corev1.EventTypeWarning, "Failed", "Failed to create the CA generating Job -- Check operator logs.")
 			return *res, fmt.Errorf("ChiaCAReconciler ChiaCA=%s encountered error reconciling CA generator Job: %v", req.NamespacedName, err)
 		}
@@ -135,7 +121,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 			_, notFound, err := r.getCASecret(ctx, ca)
 			if err != nil {
-				metrics.OperatorErrors.Add(1.0)
+				metrics.RecordReconcileError("ChiaCA", "Secret")
 				log.Error(err, fmt.Sprintf("ChiaCAReconciler ChiaCA=%s unable to query for ChiaCA secret", req.NamespacedName))
 				return ctrl.Result{}, err
 			}
@@ -147,7 +133,7 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 				ca.Status.Ready = true
 				err = r.Status().Update(ctx, &ca)
 				if err != nil {
-					metrics.OperatorErrors.Add(1.0)
+					metrics.RecordReconcileError("ChiaCA", "ChiaCA")
 					log.Error(err, fmt.Sprintf("ChiaCAReconciler ChiaCA=%s unable to update ChiaCA status", req.NamespacedName))
 					return ctrl.Result{}, err
 				}
@@ -10,7 +10,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -22,7 +24,7 @@ const chiafarmerNamePattern = "%s-farmer"
 
 // assembleBaseService assembles the main Service resource for a Chiafarmer CR
 func (r *ChiaFarmerReconciler) assembleBaseService(ctx context.Context, farmer k8schianetv1.ChiaFarmer) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiafarmerNamePattern, farmer.Name),
 			Namespace:       farmer.Namespace,
@@ -55,11 +57,14 @@ func (r *ChiaFarmerReconciler) assembleBaseService(ctx context.Context, farmer k
 			Selector: kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, farmer.Spec.Service)
+	return srv
 }
 
 // assembleChiaExporterService assembles the chia-exporter Service resource for a ChiaFarmer CR
 func (r *ChiaFarmerReconciler) assembleChiaExporterService(ctx context.Context, farmer k8schianetv1.ChiaFarmer) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiafarmerNamePattern, farmer.Name) + "-metrics",
 			Namespace:       farmer.Namespace,
@@ -80,6 +85,45 @@ func (r *ChiaFarmerReconciler) assembleChiaExporterService(ctx context.Context,
 			Selector: kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, farmer.Spec.ChiaExporterConfig.Service)
+	return srv
+}
+
+// assembleNetworkPolicy assembles the NetworkPolicy resource for a ChiaFarmer CR
+func (r *ChiaFarmerReconciler) assembleNetworkPolicy(ctx context.Context, farmer k8schianetv1.ChiaFarmer) networkingv1.NetworkPolicy {
+	meta := metav1.ObjectMeta{
+		Name:            fmt.Sprintf(chiafarmerNamePattern, farmer.Name),
+		Namespace:       farmer.Namespace,
+		Labels:          kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels),
+		Annotations:     farmer.Spec.AdditionalMetadata.Annotations,
+		OwnerReferences: r.getOwnerReference(ctx, farmer),
+	}
+	return kube.AssembleNetworkPolicy(ctx, meta, kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta), farmer.Spec.NetworkPolicy, kube.NamedPort("peers", corev1.ProtocolTCP))
+}
+
+// assembleChiaExporterMonitor assembles the Prometheus Operator monitor for a ChiaFarmer CR's chia-exporter, or gives nil if it doesn't want one
+func (r *ChiaFarmerReconciler) assembleChiaExporterMonitor(ctx context.Context, farmer k8schianetv1.ChiaFarmer) (*unstructured.Unstructured, error) {
+	return kube.AssembleChiaExporterMonitor(ctx, farmer.Kind, farmer.ObjectMeta, fmt.Sprintf(chiafarmerNamePattern, farmer.Name), farmer.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, farmer), farmer.Spec.ChiaExporterConfig)
+}
+
+// assemblePrometheusRule assembles the PrometheusRule with a ChiaFarmer CR's alerts, or gives nil if it doesn't want one.
+// The farmer alerts when fewer harvesters than expected are connected to it.
+func (r *ChiaFarmerReconciler) assemblePrometheusRule(ctx context.Context, farmer k8schianetv1.ChiaFarmer) *unstructured.Unstructured {
+	if farmer.Spec.Alerting == nil {
+		return nil
+	}
+	minHarvesters := int32(1)
+	if farmer.Spec.Alerting.MinHarvesters != nil {
+		minHarvesters = *farmer.Spec.Alerting.MinHarvesters
+	}
+	return kube.AssemblePrometheusRule(ctx, farmer.Kind, farmer.ObjectMeta, fmt.Sprintf(chiafarmerNamePattern, farmer.Name), farmer.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, farmer), &farmer.Spec.Alerting.AlertingConfig,
+		kube.AlertingRule{
+			Alert:   "ChiaFarmerHarvestersMissing",
+			Expr:    fmt.Sprintf(`sum(chia_farmer_connection_count{%s,node_type="harvester"}) < %d`, kube.GetMetricSelector(ctx, farmer.Kind, farmer.ObjectMeta), minHarvesters),
+			Summary: fmt.Sprintf("ChiaFarmer %s/%s has fewer than %d harvesters connected", farmer.Namespace, farmer.Name, minHarvesters),
+		},
+	)
 }
 
 // assembleDeployment assembles the farmer Deployment resource for a ChiaFarmer CR
@@ -108,6 +152,7 @@ func (r *ChiaFarmerReconciler) assembleDeployment(ctx context.Context, farmer k8
 							Name:            "chia",
 							Image:           farmer.Spec.ChiaConfig.Image,
 							ImagePullPolicy: farmer.Spec.ImagePullPolicy,
+							Lifecycle:       kube.GetChiaLifecycle(ctx),
 							Env:             r.getChiaEnv(ctx, farmer),
 							Ports: []corev1.ContainerPort{
 								{
@@ -126,24 +171,13 @@ func (r *ChiaFarmerReconciler) assembleDeployment(ctx context.Context, farmer k8
 									Protocol:      "TCP",
 								},
 							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "secret-ca",
-									MountPath: "/chia-ca",
-								},
-								{
-									Name:      "key",
-									MountPath: "/key",
-								},
-								{
-									Name:      "chiaroot",
-									MountPath: "/chia-data",
-								},
-							},
+							VolumeMounts: r.getChiaVolumeMounts(ctx, farmer),
 						},
 					},
-					NodeSelector: farmer.Spec.NodeSelector,
-					Volumes:      r.getChiaVolumes(ctx, farmer),
+					InitContainers:                r.getInitContainers(ctx, farmer),
+					NodeSelector:                  farmer.Spec.NodeSelector,
+					TerminationGracePeriodSeconds: kube.GetTerminationGracePeriodSeconds(ctx, farmer.Spec.TerminationGracePeriodSeconds, consts.FarmerTerminationGracePeriodSeconds),
+					Volumes:                       r.getChiaVolumes(ctx, farmer),
 				},
 			},
 		},
@@ -167,14 +201,12 @@ func (r *ChiaFarmerReconciler) assembleDeployment(ctx context.Context, farmer k8
 		deploy.Spec.Template.Spec.Containers[0].StartupProbe = farmer.Spec.ChiaConfig.StartupProbe
 	}
 
-	var containerResorces corev1.ResourceRequirements
 	if farmer.Spec.ChiaConfig.Resources != nil {
-		containerResorces = *farmer.Spec.ChiaConfig.Resources
 		deploy.Spec.Template.Spec.Containers[0].Resources = *farmer.Spec.ChiaConfig.Resources
 	}
 
 	if farmer.Spec.ChiaExporterConfig.Enabled {
-		exporterContainer := kube.GetChiaExporterContainer(ctx, farmer.Spec.ChiaExporterConfig.Image, containerSecurityContext, farmer.Spec.ImagePullPolicy, containerResorces)
+		exporterContainer := kube.GetChiaExporterContainer(ctx, farmer.Spec.ChiaExporterConfig, containerSecurityContext, farmer.Spec.ImagePullPolicy)
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, exporterContainer)
 	}
 
@@ -186,7 +218,19 @@ func (r *ChiaFarmerReconciler) assembleDeployment(ctx context.Context, farmer k8
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, farmer.Spec.Sidecars.Containers...)
 	}
 
+	// A rolling update would briefly run two farmers against the same ReadWriteOnce claim
+	if kube.GetGeneratedChiaRootClaimConfig(farmer.Spec.Storage) != nil {
+		deploy.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+
 	// TODO add pod affinity, tolerations
 
 	return deploy
 }
+
+// assembleChiaRootClaim assembles the CHIA_ROOT PersistentVolumeClaim for a ChiaFarmer CR that gives a storage class and size instead of an existing claim
+func (r *ChiaFarmerReconciler) assembleChiaRootClaim(ctx context.Context, farmer k8schianetv1.ChiaFarmer, config k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	return kube.AssembleChiaRootClaim(ctx, fmt.Sprintf(chiafarmerNamePattern, farmer.Name), farmer.Namespace, kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels), r.getOwnerReference(ctx, farmer), config)
+}
@@ -7,9 +7,11 @@ package chiafarmer
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -18,6 +20,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	"github.com/cisco-open/operator-tools/pkg/reconciler"
@@ -28,20 +31,27 @@ type ChiaFarmerReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
-}
 
-var chiafarmers map[string]bool = make(map[string]bool)
+	// PrometheusOperator says which Prometheus Operator kinds the cluster serves. chia-exporter monitors and alerting rules require their kind's CRD.
+	PrometheusOperator kube.PrometheusOperatorAPIs
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarmers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarmers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarmers/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *ChiaFarmerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaFarmer", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaFarmerReconciler ChiaFarmer=%s", req.NamespacedName.String()))
@@ -50,67 +60,225 @@ func (r *ChiaFarmerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	var farmer k8schianetv1.ChiaFarmer
 	err := r.Get(ctx, req.NamespacedName, &farmer)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiafarmers[req.NamespacedName.String()]
-		if exists {
-			delete(chiafarmers, req.NamespacedName.String())
-			metrics.ChiaFarmers.Sub(1.0)
+		// chia-exporter monitors and PrometheusRules in other namespaces aren't garbage collected with the ChiaFarmer
+		err = kube.RemoveChiaExporterMonitors(ctx, r.Client, r.PrometheusOperator, kube.GetCommonLabels(ctx, "ChiaFarmer", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaFarmer", "Monitor")
+			return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error removing farmer chia-exporter monitors: %v", req.NamespacedName, err)
+		}
+		if r.PrometheusOperator.PrometheusRules {
+			err = kube.ReconcilePrometheusRule(ctx, r.Client, nil, kube.GetCommonLabels(ctx, "ChiaFarmer", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+			if err != nil {
+				metrics.RecordReconcileError("ChiaFarmer", "PrometheusRule")
+				return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error removing farmer PrometheusRules: %v", req.NamespacedName, err)
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaFarmer", "ChiaFarmer")
 		log.Error(err, fmt.Sprintf("ChiaFarmerReconciler ChiaFarmer=%s unable to fetch ChiaFarmer resource", req.NamespacedName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiafarmers[req.NamespacedName.String()]
-	if !exists {
-		chiafarmers[req.NamespacedName.String()] = true
-		metrics.ChiaFarmers.Add(1.0)
-	}
-
 	// Reconcile ChiaFarmer owned objects
 	srv := r.assembleBaseService(ctx, farmer)
-	res, err := kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err := kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, farmer.Spec.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaFarmer", "Service")
 		r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer Service: %v", req.NamespacedName, err)
 	}
 
 	srv = r.assembleChiaExporterService(ctx, farmer)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, farmer.Spec.ChiaExporterConfig.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaFarmer", "Service")
 		r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer metrics Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer chia-exporter Service: %v", req.NamespacedName, err)
 	}
 
+	policy := r.assembleNetworkPolicy(ctx, farmer)
+	if farmer.Spec.NetworkPolicy.Enabled {
+		res, err = kube.ReconcileNetworkPolicy(ctx, resourceReconciler, policy)
+	} else {
+		res, err = kube.RemoveNetworkPolicy(ctx, resourceReconciler, policy)
+	}
+	if err != nil {
+		if res == nil {
+			res = &reconcile.Result{}
+		}
+		metrics.RecordReconcileError("ChiaFarmer", "NetworkPolicy")
+		r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer NetworkPolicy -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer NetworkPolicy: %v", req.NamespacedName, err)
+	}
+
+	// Scrape chia-exporter with a Prometheus Operator monitor if its CRD is installed
+	if len(r.PrometheusOperator.MonitorKinds()) > 0 {
+		monitor, err := r.assembleChiaExporterMonitor(ctx, farmer)
+		if err == nil {
+			err = kube.ReconcileChiaExporterMonitor(ctx, r.Client, r.PrometheusOperator, monitor, kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta))
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaFarmer", "Monitor")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer chia-exporter monitor -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer chia-exporter monitor: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &farmer, &farmer.Status.Conditions, consts.MonitorsDisabledCondition, !r.PrometheusOperator.ServesMonitor(farmer.Spec.ChiaExporterConfig.Monitor),
+		"A chia-exporter monitor is configured but its Prometheus Operator CRD was not found when the operator started.")
+
+	// Create the PrometheusRule with the farmer's alerts if the CRD is installed
+	if r.PrometheusOperator.PrometheusRules {
+		err = kube.ReconcilePrometheusRule(ctx, r.Client, r.assemblePrometheusRule(ctx, farmer), kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaFarmer", "PrometheusRule")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer PrometheusRule -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer PrometheusRule: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &farmer, &farmer.Status.Conditions, consts.AlertingDisabledCondition, farmer.Spec.Alerting != nil && !r.PrometheusOperator.PrometheusRules,
+		"Alerting is configured but the PrometheusRule CRD was not found when the operator started.")
+	kube.SetWarningCondition(r.Recorder, &farmer, &farmer.Status.Conditions, consts.AlertingWithoutMonitorCondition, farmer.Spec.Alerting != nil && (farmer.Spec.ChiaExporterConfig.Monitor == nil || !farmer.Spec.ChiaExporterConfig.Enabled),
+		"Alerting is configured without a chia-exporter monitor. Alerts select metrics by their k8s_chia_net_provenance label, which your own scrape config must add.")
+
+	err = validateRewardsConfig(farmer)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaFarmer", "ChiaFarmer")
+		r.Recorder.Event(&farmer, corev1.EventTypeWarning, "InvalidRewardsConfig", fmt.Sprintf("Invalid reward address or pool configuration: %v", err))
+		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s has an invalid reward address or pool configuration: %v", req.NamespacedName, err)
+	}
+
+	// Create the CHIA_ROOT PersistentVolumeClaim if a storage class and size were given instead of an existing claim
+	if pvcConfig := kube.GetGeneratedChiaRootClaimConfig(farmer.Spec.Storage); pvcConfig != nil {
+		pvc, err := r.assembleChiaRootClaim(ctx, farmer, *pvcConfig)
+		if err == nil {
+			err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, pvc)
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaFarmer", "PersistentVolumeClaim")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer CHIA_ROOT PersistentVolumeClaim -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer CHIA_ROOT PersistentVolumeClaim: %v", req.NamespacedName, err)
+		}
+	}
+
 	deploy := r.assembleDeployment(ctx, farmer)
 	res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaFarmer", "Deployment")
 		r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer Deployment -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer Deployment: %v", req.NamespacedName, err)
 	}
 
+	// Expose the farmer to harvesters outside of the cluster, or remove the external Service and bundle Secret if it isn't enabled.
+	// The bundle Secret is removed by the name recorded in status, so a renamed secretName doesn't leave the old bundle behind.
+	var previousBundleName string
+	if farmer.Status.RemoteHarvesters != nil {
+		previousBundleName = farmer.Status.RemoteHarvesters.SecretName
+	}
+	if farmer.Spec.RemoteHarvesters != nil {
+		bundleName := getRemoteHarvesterSecretName(farmer)
+		if bundleName == farmer.Spec.ChiaConfig.CASecretName {
+			metrics.RecordReconcileError("ChiaFarmer", "Secret")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "remoteHarvesters.secretName must not be the same as chia.caSecretName.")
+			return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s remoteHarvesters.secretName %q is the farmer's CA Secret", req.NamespacedName, bundleName)
+		}
+
+		if previousBundleName != "" && previousBundleName != bundleName {
+			res, err = r.removeRemoteHarvesterSecret(ctx, resourceReconciler, farmer, previousBundleName)
+			if err != nil {
+				if res == nil {
+					res = &reconcile.Result{}
+				}
+				metrics.RecordReconcileError("ChiaFarmer", "Secret")
+				r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to remove previous remote harvester bundle Secret -- Check operator logs.")
+				return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error removing previous remote harvester bundle Secret: %v", req.NamespacedName, err)
+			}
+			previousBundleName = ""
+		}
+
+		srv = r.assembleExternalService(ctx, farmer)
+		res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaFarmer", "Service")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create farmer external Service -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling farmer external Service: %v", req.NamespacedName, err)
+		}
+
+		host, port, err := r.getExternalFarmerAddress(ctx, farmer)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaFarmer", "Service")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to get farmer external address -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error getting farmer external address: %v", req.NamespacedName, err)
+		}
+
+		// Keep tracking a bundle that was already created while the external address is unknown
+		farmer.Status.RemoteHarvesters = &k8schianetv1.ChiaFarmerRemoteHarvestersStatus{
+			SecretName: previousBundleName,
+		}
+		if host == "" {
+			// LoadBalancers are reconciled again once they're assigned an ingress address, NodePort Services need an external address
+			if srv.Spec.Type == corev1.ServiceTypeNodePort {
+				r.Recorder.Event(&farmer, corev1.EventTypeWarning, "ExternalAddressRequired", "remoteHarvesters.externalAddress is required to generate a remote harvester bundle for NodePort Services.")
+			}
+		} else {
+			bundle, err := r.assembleRemoteHarvesterSecret(ctx, farmer, host, port)
+			if err == nil {
+				res, err = kube.ReconcileSecret(ctx, resourceReconciler, bundle)
+			}
+			if err != nil {
+				if res == nil {
+					res = &reconcile.Result{}
+				}
+				metrics.RecordReconcileError("ChiaFarmer", "Secret")
+				r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to create remote harvester bundle Secret -- Check operator logs.")
+				return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error reconciling remote harvester bundle Secret: %v", req.NamespacedName, err)
+			}
+			farmer.Status.RemoteHarvesters.FarmerAddress = string(bundle.Data["farmer-address"])
+			farmer.Status.RemoteHarvesters.SecretName = bundle.Name
+		}
+	} else {
+		res, err = kube.RemoveService(ctx, resourceReconciler, corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf(chiafarmerExternalNamePattern, farmer.Name),
+				Namespace: farmer.Namespace,
+			},
+		})
+		if err == nil {
+			if previousBundleName == "" {
+				previousBundleName = getRemoteHarvesterSecretName(farmer)
+			}
+			res, err = r.removeRemoteHarvesterSecret(ctx, resourceReconciler, farmer, previousBundleName)
+		}
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaFarmer", "Secret")
+			r.Recorder.Event(&farmer, corev1.EventTypeWarning, "Failed", "Failed to remove farmer external Service -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error removing farmer external Service and bundle Secret: %v", req.NamespacedName, err)
+		}
+		farmer.Status.RemoteHarvesters = nil
+	}
+
 	// Update CR status
 	r.Recorder.Event(&farmer, corev1.EventTypeNormal, "Created", "Successfully created ChiaFarmer resources.")
 	farmer.Status.Ready = true
 	err = r.Status().Update(ctx, &farmer)
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaFarmer", "ChiaFarmer")
 		log.Error(err, fmt.Sprintf("ChiaFarmerReconciler ChiaFarmer=%s unable to update ChiaFarmer status", req.NamespacedName))
 		return ctrl.Result{}, err
 	}
@@ -122,5 +290,6 @@ func (r *ChiaFarmerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 func (r *ChiaFarmerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaFarmer{}).
+		Owns(&corev1.Service{}).
 		Complete(r)
 }
@@ -6,16 +6,44 @@ package chiafarmer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/bech32"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
+// configureRewardsScript renders the farmer's reward addresses and pool list into the chia config. The image entrypoint creates
+// the chia config before running this script, and it runs on every start so the config matches the ChiaFarmer even on an emptyDir CHIA_ROOT.
+const configureRewardsScript = `set -o errexit
+config="${CHIA_ROOT}/config/config.yaml"
+if [ -n "${FARMER_XCH_TARGET_ADDRESS}" ]; then
+  yq -i '.farmer.xch_target_address = strenv(FARMER_XCH_TARGET_ADDRESS)' "${config}"
+fi
+if [ -n "${POOL_XCH_TARGET_ADDRESS}" ]; then
+  yq -i '.pool.xch_target_address = strenv(POOL_XCH_TARGET_ADDRESS)' "${config}"
+fi
+if [ -n "${POOL_LIST}" ]; then
+  yq -i '.pool.pool_list = env(POOL_LIST)' "${config}"
+fi`
+
+// poolListEntry is an entry in the pool_list of the chia config
+type poolListEntry struct {
+	LauncherID            string `json:"launcher_id"`
+	OwnerPublicKey        string `json:"owner_public_key"`
+	P2SingletonPuzzleHash string `json:"p2_singleton_puzzle_hash"`
+	PayoutInstructions    string `json:"payout_instructions"`
+	PoolURL               string `json:"pool_url"`
+	TargetPuzzleHash      string `json:"target_puzzle_hash"`
+}
+
 // getChiaVolumes retrieves the requisite volumes from the Chia config struct
 func (r *ChiaFarmerReconciler) getChiaVolumes(ctx context.Context, farmer k8schianetv1.ChiaFarmer) []corev1.Volume {
 	var v []corev1.Volume
@@ -49,7 +77,7 @@ func (r *ChiaFarmerReconciler) getChiaVolumes(ctx context.Context, farmer k8schi
 				Name: "chiaroot",
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: farmer.Spec.Storage.ChiaRoot.PersistentVolumeClaim.ClaimName,
+						ClaimName: kube.GetChiaRootClaimName(fmt.Sprintf(chiafarmerNamePattern, farmer.Name), farmer.Spec.Storage),
 					},
 				},
 			})
@@ -176,6 +204,133 @@ func (r *ChiaFarmerReconciler) getChiaEnv(ctx context.Context, farmer k8schianet
 	return env
 }
 
+// hasRewardsConfig returns true if the farmer's reward addresses or pool list are configured in the ChiaFarmer
+func hasRewardsConfig(farmer k8schianetv1.ChiaFarmer) bool {
+	return farmer.Spec.ChiaConfig.XCHTargetAddress != "" || farmer.Spec.ChiaConfig.PoolXCHTargetAddress != "" || len(farmer.Spec.ChiaConfig.PoolList) > 0
+}
+
+// validateRewardsConfig checks that the farmer's reward and pool addresses are valid bech32m addresses
+func validateRewardsConfig(farmer k8schianetv1.ChiaFarmer) error {
+	for _, address := range []string{farmer.Spec.ChiaConfig.XCHTargetAddress, farmer.Spec.ChiaConfig.PoolXCHTargetAddress} {
+		if address == "" {
+			continue
+		}
+		_, _, err := bech32.DecodePuzzleHash(address)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := getPoolList(farmer)
+	return err
+}
+
+// getPoolList renders the farmer's pool list as JSON for the pool_list of the chia config, with addresses decoded to puzzle hashes.
+// Returns an empty string if the ChiaFarmer doesn't define a pool list.
+func getPoolList(farmer k8schianetv1.ChiaFarmer) (string, error) {
+	if len(farmer.Spec.ChiaConfig.PoolList) == 0 {
+		return "", nil
+	}
+
+	var entries []poolListEntry
+	for _, pool := range farmer.Spec.ChiaConfig.PoolList {
+		p2SingletonPuzzleHash, err := bech32.DecodePuzzleHashHex(pool.PoolContractAddress)
+		if err != nil {
+			return "", fmt.Errorf("pool %s poolContractAddress: %v", pool.LauncherID, err)
+		}
+
+		payoutAddress := pool.PayoutAddress
+		if payoutAddress == "" {
+			payoutAddress = farmer.Spec.ChiaConfig.XCHTargetAddress
+		}
+		if payoutAddress == "" {
+			return "", fmt.Errorf("pool %s requires a payoutAddress or xchTargetAddress", pool.LauncherID)
+		}
+		payoutInstructions, err := bech32.DecodePuzzleHashHex(payoutAddress)
+		if err != nil {
+			return "", fmt.Errorf("pool %s payoutAddress: %v", pool.LauncherID, err)
+		}
+
+		entries = append(entries, poolListEntry{
+			LauncherID:            withHexPrefix(pool.LauncherID),
+			OwnerPublicKey:        withHexPrefix(pool.OwnerPublicKey),
+			P2SingletonPuzzleHash: p2SingletonPuzzleHash,
+			PayoutInstructions:    strings.TrimPrefix(payoutInstructions, "0x"),
+			PoolURL:               pool.PoolURL,
+			TargetPuzzleHash:      withHexPrefix(pool.TargetPuzzleHash),
+		})
+	}
+
+	poolList, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return string(poolList), nil
+}
+
+// withHexPrefix gives a lower case hex string with the 0x prefix the chia config uses
+func withHexPrefix(value string) string {
+	return "0x" + strings.TrimPrefix(strings.ToLower(value), "0x")
+}
+
+// getInitContainers assembles the init containers that prepare the chia config before the chia container starts
+func (r *ChiaFarmerReconciler) getInitContainers(ctx context.Context, farmer k8schianetv1.ChiaFarmer) []corev1.Container {
+	var containers []corev1.Container
+	if !hasRewardsConfig(farmer) {
+		return containers
+	}
+
+	// The pool list was validated before the farmer's resources were assembled
+	poolList, _ := getPoolList(farmer)
+	poolXCHTargetAddress := farmer.Spec.ChiaConfig.PoolXCHTargetAddress
+	if poolXCHTargetAddress == "" {
+		poolXCHTargetAddress = farmer.Spec.ChiaConfig.XCHTargetAddress
+	}
+
+	containers = append(containers, corev1.Container{
+		Name:            "configure-rewards",
+		Image:           farmer.Spec.ChiaConfig.Image,
+		ImagePullPolicy: farmer.Spec.ImagePullPolicy,
+		Args:            []string{"/bin/bash", "-c", configureRewardsScript},
+		Env: append(r.getChiaEnv(ctx, farmer),
+			corev1.EnvVar{
+				Name:  "FARMER_XCH_TARGET_ADDRESS",
+				Value: farmer.Spec.ChiaConfig.XCHTargetAddress,
+			},
+			corev1.EnvVar{
+				Name:  "POOL_XCH_TARGET_ADDRESS",
+				Value: poolXCHTargetAddress,
+			},
+			corev1.EnvVar{
+				Name:  "POOL_LIST",
+				Value: poolList,
+			},
+		),
+		VolumeMounts:    r.getChiaVolumeMounts(ctx, farmer),
+		SecurityContext: farmer.Spec.ChiaConfig.SecurityContext,
+	})
+
+	return containers
+}
+
+// getChiaVolumeMounts retrieves the requisite volume mounts from the Chia config struct
+func (r *ChiaFarmerReconciler) getChiaVolumeMounts(ctx context.Context, farmer k8schianetv1.ChiaFarmer) []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			Name:      "secret-ca",
+			MountPath: "/chia-ca",
+		},
+		{
+			Name:      "key",
+			MountPath: "/key",
+		},
+		{
+			Name:      "chiaroot",
+			MountPath: "/chia-data",
+		},
+	}
+}
+
 // getOwnerReference gives the common owner reference spec for ChiaFarmer related objects
 func (r *ChiaFarmerReconciler) getOwnerReference(ctx context.Context, farmer k8schianetv1.ChiaFarmer) []metav1.OwnerReference {
 	return []metav1.OwnerReference{
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiafarmer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/cisco-open/operator-tools/pkg/reconciler"
+)
+
+const (
+	// chiafarmerExternalNamePattern is the name of the Service that exposes the farmer's peer port to remote harvesters
+	chiafarmerExternalNamePattern = "%s-farmer-external"
+
+	// chiafarmerRemoteHarvesterNamePattern is the default name of the remote harvester bundle Secret
+	chiafarmerRemoteHarvesterNamePattern = "%s-farmer-remote-harvester"
+)
+
+// caSecretKeys are the certificate authority files copied from the CA Secret into the remote harvester bundle, which `chia init -c` needs
+var caSecretKeys = []string{"chia_ca.crt", "chia_ca.key", "private_ca.crt", "private_ca.key"}
+
+// remoteHarvesterConfig is the harvester section of the chia config for a remote harvester, given the farmer's host and port
+const remoteHarvesterConfig = `harvester:
+  farmer_peers:
+  - host: %s
+    port: %s
+`
+
+// remoteHarvesterSetupScript initializes a remote harvester's chia config from the bundle, given the farmer's host:port
+const remoteHarvesterSetupScript = `#!/bin/sh
+# Initializes a harvester's chia config with this bundle's certificate authority and points it at the farmer.
+# Run this from anywhere after extracting the bundle, then start the harvester with "chia start harvester".
+set -e
+chia init -c "$(cd "$(dirname "$0")" && pwd)"
+chia configure --set-farmer-peer %s
+`
+
+// assembleExternalService assembles the Service that exposes the farmer's peer port to harvesters outside of the cluster
+func (r *ChiaFarmerReconciler) assembleExternalService(ctx context.Context, farmer k8schianetv1.ChiaFarmer) corev1.Service {
+	serviceType := farmer.Spec.RemoteHarvesters.ServiceType
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeLoadBalancer
+	}
+
+	port := corev1.ServicePort{
+		Port:       consts.FarmerPort,
+		TargetPort: intstr.FromString("peers"),
+		Protocol:   "TCP",
+		Name:       "peers",
+	}
+	if serviceType == corev1.ServiceTypeNodePort && farmer.Spec.RemoteHarvesters.NodePort != nil {
+		port.NodePort = *farmer.Spec.RemoteHarvesters.NodePort
+	}
+
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chiafarmerExternalNamePattern, farmer.Name),
+			Namespace:       farmer.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels),
+			Annotations:     farmer.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, farmer),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Ports:    []corev1.ServicePort{port},
+			Selector: kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels),
+		},
+	}
+}
+
+// getRemoteHarvesterSecretName gives the name of the farmer's remote harvester bundle Secret
+func getRemoteHarvesterSecretName(farmer k8schianetv1.ChiaFarmer) string {
+	if farmer.Spec.RemoteHarvesters != nil && farmer.Spec.RemoteHarvesters.SecretName != "" {
+		return farmer.Spec.RemoteHarvesters.SecretName
+	}
+	return fmt.Sprintf(chiafarmerRemoteHarvesterNamePattern, farmer.Name)
+}
+
+// removeRemoteHarvesterSecret removes a remote harvester bundle Secret by name. The farmer's CA Secret is never removed,
+// even if it was once recorded as the bundle's name.
+func (r *ChiaFarmerReconciler) removeRemoteHarvesterSecret(ctx context.Context, rec reconciler.ResourceReconciler, farmer k8schianetv1.ChiaFarmer, name string) (*reconcile.Result, error) {
+	if name == farmer.Spec.ChiaConfig.CASecretName {
+		return nil, nil
+	}
+	return kube.RemoveSecret(ctx, rec, corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: farmer.Namespace,
+		},
+	})
+}
+
+// getExternalFarmerAddress gives the host and port remote harvesters reach the farmer on, read from the external Service.
+// Returns an empty host if it isn't known yet, which is the case until a LoadBalancer has been assigned an ingress address.
+func (r *ChiaFarmerReconciler) getExternalFarmerAddress(ctx context.Context, farmer k8schianetv1.ChiaFarmer) (string, int32, error) {
+	var srv corev1.Service
+	err := r.Get(ctx, types.NamespacedName{Namespace: farmer.Namespace, Name: fmt.Sprintf(chiafarmerExternalNamePattern, farmer.Name)}, &srv)
+	if err != nil && errors.IsNotFound(err) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if len(srv.Spec.Ports) == 0 {
+		return "", 0, nil
+	}
+
+	host := farmer.Spec.RemoteHarvesters.ExternalAddress
+	var port int32 = consts.FarmerPort
+	if srv.Spec.Type == corev1.ServiceTypeNodePort {
+		port = srv.Spec.Ports[0].NodePort
+	} else if host == "" {
+		for _, ingress := range srv.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				host = ingress.Hostname
+				break
+			}
+			if ingress.IP != "" {
+				host = ingress.IP
+				break
+			}
+		}
+	}
+
+	return host, port, nil
+}
+
+// assembleRemoteHarvesterSecret assembles the bundle Secret for harvesters outside of the cluster, with the certificate authority files
+// from the farmer's CA Secret, a harvester chia config snippet, and a setup script
+func (r *ChiaFarmerReconciler) assembleRemoteHarvesterSecret(ctx context.Context, farmer k8schianetv1.ChiaFarmer, host string, port int32) (corev1.Secret, error) {
+	var ca corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Namespace: farmer.Namespace, Name: farmer.Spec.ChiaConfig.CASecretName}, &ca)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+
+	farmerAddress := net.JoinHostPort(host, strconv.Itoa(int(port)))
+	data := map[string][]byte{
+		"farmer-address":        []byte(farmerAddress),
+		"harvester-config.yaml": []byte(fmt.Sprintf(remoteHarvesterConfig, host, strconv.Itoa(int(port)))),
+		"setup.sh":              []byte(fmt.Sprintf(remoteHarvesterSetupScript, farmerAddress)),
+	}
+	for _, key := range caSecretKeys {
+		value, exists := ca.Data[key]
+		if !exists {
+			return corev1.Secret{}, fmt.Errorf("CA Secret %s is missing %s", ca.Name, key)
+		}
+		data[key] = value
+	}
+
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            getRemoteHarvesterSecretName(farmer),
+			Namespace:       farmer.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, farmer.Kind, farmer.ObjectMeta, farmer.Spec.AdditionalMetadata.Labels),
+			Annotations:     farmer.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, farmer),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}, nil
+}
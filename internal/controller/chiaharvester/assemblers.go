@@ -7,10 +7,13 @@ package chiaharvester
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -22,7 +25,7 @@ const chiaharvesterNamePattern = "%s-harvester"
 
 // assembleBaseService reconciles the main Service resource for a ChiaHarvester CR
 func (r *ChiaHarvesterReconciler) assembleBaseService(ctx context.Context, harvester k8schianetv1.ChiaHarvester) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
 			Namespace:       harvester.Namespace,
@@ -55,11 +58,14 @@ func (r *ChiaHarvesterReconciler) assembleBaseService(ctx context.Context, harve
 			Selector: kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, harvester.Spec.Service)
+	return srv
 }
 
 // assembleChiaExporterService assembles the chia-exporter Service resource for a ChiaHarvester CR
 func (r *ChiaHarvesterReconciler) assembleChiaExporterService(ctx context.Context, harvester k8schianetv1.ChiaHarvester) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiaharvesterNamePattern, harvester.Name) + "-metrics",
 			Namespace:       harvester.Namespace,
@@ -80,6 +86,46 @@ func (r *ChiaHarvesterReconciler) assembleChiaExporterService(ctx context.Contex
 			Selector: kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, harvester.Spec.ChiaExporterConfig.Service)
+	return srv
+}
+
+// assembleNetworkPolicy assembles the NetworkPolicy resource for a ChiaHarvester CR
+func (r *ChiaHarvesterReconciler) assembleNetworkPolicy(ctx context.Context, harvester k8schianetv1.ChiaHarvester) networkingv1.NetworkPolicy {
+	meta := metav1.ObjectMeta{
+		Name:            fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
+		Namespace:       harvester.Namespace,
+		Labels:          kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
+		Annotations:     harvester.Spec.AdditionalMetadata.Annotations,
+		OwnerReferences: r.getOwnerReference(ctx, harvester),
+	}
+	return kube.AssembleNetworkPolicy(ctx, meta, kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta), harvester.Spec.NetworkPolicy, kube.NamedPort("peers", corev1.ProtocolTCP))
+}
+
+// assembleChiaExporterMonitor assembles the Prometheus Operator monitor for a ChiaHarvester CR's chia-exporter, or gives nil if it doesn't want one
+func (r *ChiaHarvesterReconciler) assembleChiaExporterMonitor(ctx context.Context, harvester k8schianetv1.ChiaHarvester) (*unstructured.Unstructured, error) {
+	return kube.AssembleChiaExporterMonitor(ctx, harvester.Kind, harvester.ObjectMeta, fmt.Sprintf(chiaharvesterNamePattern, harvester.Name), harvester.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, harvester), harvester.Spec.ChiaExporterConfig)
+}
+
+// assemblePrometheusRule assembles the PrometheusRule with a ChiaHarvester CR's alerts, or gives nil if it doesn't want one.
+// The harvester alerts when its plot count drops by more than the configured percentage within an hour, like when a disk fails.
+func (r *ChiaHarvesterReconciler) assemblePrometheusRule(ctx context.Context, harvester k8schianetv1.ChiaHarvester) *unstructured.Unstructured {
+	if harvester.Spec.Alerting == nil {
+		return nil
+	}
+	dropPercent := int32(5)
+	if harvester.Spec.Alerting.PlotCountDropPercent != nil {
+		dropPercent = *harvester.Spec.Alerting.PlotCountDropPercent
+	}
+	selector := kube.GetMetricSelector(ctx, harvester.Kind, harvester.ObjectMeta)
+	return kube.AssemblePrometheusRule(ctx, harvester.Kind, harvester.ObjectMeta, fmt.Sprintf(chiaharvesterNamePattern, harvester.Name), harvester.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, harvester), &harvester.Spec.Alerting.AlertingConfig,
+		kube.AlertingRule{
+			Alert:   "ChiaHarvesterPlotCountDropped",
+			Expr:    fmt.Sprintf("sum(chia_harvester_plot_count{%s}) < sum(chia_harvester_plot_count{%s} offset 1h) * %s", selector, selector, strconv.FormatFloat(float64(100-dropPercent)/100, 'f', -1, 64)),
+			Summary: fmt.Sprintf("ChiaHarvester %s/%s lost more than %d%% of its plots in the last hour", harvester.Namespace, harvester.Name, dropPercent),
+		},
+	)
 }
 
 // assembleDeployment assembles the harvester Deployment resource for a ChiaHarvester CR
@@ -96,84 +142,97 @@ func (r *ChiaHarvesterReconciler) assembleDeployment(ctx context.Context, harves
 			Selector: &metav1.LabelSelector{
 				MatchLabels: kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta),
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels:      kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
-					Annotations: harvester.Spec.AdditionalMetadata.Annotations,
-				},
-				Spec: corev1.PodSpec{
-					// TODO add: imagePullSecret, serviceAccountName config
-					Containers: []corev1.Container{
+			Template: r.assemblePodTemplate(ctx, harvester),
+		},
+	}
+
+	// Scale to zero while a ChiaMaintenance has exclusive access to this Deployment's volumes
+	if kube.IsPausedForMaintenance(harvester.ObjectMeta) {
+		var zero int32 = 0
+		deploy.Spec.Replicas = &zero
+	}
+
+	return deploy
+}
+
+// assemblePodTemplate assembles the harvester pod template shared by the harvester Deployment and DaemonSet
+func (r *ChiaHarvesterReconciler) assemblePodTemplate(ctx context.Context, harvester k8schianetv1.ChiaHarvester) corev1.PodTemplateSpec {
+	var template corev1.PodTemplateSpec = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
+			Annotations: harvester.Spec.AdditionalMetadata.Annotations,
+		},
+		Spec: corev1.PodSpec{
+			// TODO add: imagePullSecret, serviceAccountName config
+			Containers: []corev1.Container{
+				{
+					Name:            "chia",
+					Image:           harvester.Spec.ChiaConfig.Image,
+					ImagePullPolicy: harvester.Spec.ImagePullPolicy,
+					Lifecycle:       kube.GetChiaLifecycle(ctx),
+					Env:             r.getChiaEnv(ctx, harvester),
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "daemon",
+							ContainerPort: consts.DaemonPort,
+							Protocol:      "TCP",
+						},
 						{
-							Name:            "chia",
-							Image:           harvester.Spec.ChiaConfig.Image,
-							ImagePullPolicy: harvester.Spec.ImagePullPolicy,
-							Env:             r.getChiaEnv(ctx, harvester),
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "daemon",
-									ContainerPort: consts.DaemonPort,
-									Protocol:      "TCP",
-								},
-								{
-									Name:          "peers",
-									ContainerPort: consts.HarvesterPort,
-									Protocol:      "TCP",
-								},
-								{
-									Name:          "rpc",
-									ContainerPort: consts.HarvesterRPCPort,
-									Protocol:      "TCP",
-								},
-							},
-							VolumeMounts: r.getChiaVolumeMounts(ctx, harvester),
+							Name:          "peers",
+							ContainerPort: consts.HarvesterPort,
+							Protocol:      "TCP",
+						},
+						{
+							Name:          "rpc",
+							ContainerPort: consts.HarvesterRPCPort,
+							Protocol:      "TCP",
 						},
 					},
-					NodeSelector: harvester.Spec.NodeSelector,
-					Volumes:      r.getChiaVolumes(ctx, harvester),
+					VolumeMounts: r.getChiaVolumeMounts(ctx, harvester),
 				},
 			},
+			NodeSelector:                  harvester.Spec.NodeSelector,
+			TerminationGracePeriodSeconds: kube.GetTerminationGracePeriodSeconds(ctx, harvester.Spec.TerminationGracePeriodSeconds, consts.HarvesterTerminationGracePeriodSeconds),
+			Volumes:                       r.getChiaVolumes(ctx, harvester),
 		},
 	}
 
 	var containerSecurityContext *corev1.SecurityContext
 	if harvester.Spec.ChiaConfig.SecurityContext != nil {
 		containerSecurityContext = harvester.Spec.ChiaConfig.SecurityContext
-		deploy.Spec.Template.Spec.Containers[0].SecurityContext = harvester.Spec.ChiaConfig.SecurityContext
+		template.Spec.Containers[0].SecurityContext = harvester.Spec.ChiaConfig.SecurityContext
 	}
 
 	if harvester.Spec.ChiaConfig.LivenessProbe != nil {
-		deploy.Spec.Template.Spec.Containers[0].LivenessProbe = harvester.Spec.ChiaConfig.LivenessProbe
+		template.Spec.Containers[0].LivenessProbe = harvester.Spec.ChiaConfig.LivenessProbe
 	}
 
 	if harvester.Spec.ChiaConfig.ReadinessProbe != nil {
-		deploy.Spec.Template.Spec.Containers[0].ReadinessProbe = harvester.Spec.ChiaConfig.ReadinessProbe
+		template.Spec.Containers[0].ReadinessProbe = harvester.Spec.ChiaConfig.ReadinessProbe
 	}
 
 	if harvester.Spec.ChiaConfig.StartupProbe != nil {
-		deploy.Spec.Template.Spec.Containers[0].StartupProbe = harvester.Spec.ChiaConfig.StartupProbe
+		template.Spec.Containers[0].StartupProbe = harvester.Spec.ChiaConfig.StartupProbe
 	}
 
-	var containerResorces corev1.ResourceRequirements
 	if harvester.Spec.ChiaConfig.Resources != nil {
-		containerResorces = *harvester.Spec.ChiaConfig.Resources
-		deploy.Spec.Template.Spec.Containers[0].Resources = *harvester.Spec.ChiaConfig.Resources
+		template.Spec.Containers[0].Resources = *harvester.Spec.ChiaConfig.Resources
 	}
 
 	if harvester.Spec.ChiaExporterConfig.Enabled {
-		exporterContainer := kube.GetChiaExporterContainer(ctx, harvester.Spec.ChiaExporterConfig.Image, containerSecurityContext, harvester.Spec.ImagePullPolicy, containerResorces)
-		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, exporterContainer)
+		exporterContainer := kube.GetChiaExporterContainer(ctx, harvester.Spec.ChiaExporterConfig, containerSecurityContext, harvester.Spec.ImagePullPolicy)
+		template.Spec.Containers = append(template.Spec.Containers, exporterContainer)
 	}
 
 	if harvester.Spec.PodSecurityContext != nil {
-		deploy.Spec.Template.Spec.SecurityContext = harvester.Spec.PodSecurityContext
+		template.Spec.SecurityContext = harvester.Spec.PodSecurityContext
 	}
 
 	if len(harvester.Spec.Sidecars.Containers) > 0 {
-		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, harvester.Spec.Sidecars.Containers...)
+		template.Spec.Containers = append(template.Spec.Containers, harvester.Spec.Sidecars.Containers...)
 	}
 
 	// TODO add pod affinity, tolerations
 
-	return deploy
+	return template
 }
@@ -7,17 +7,27 @@ package chiaharvester
 import (
 	"context"
 	"fmt"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	"github.com/cisco-open/operator-tools/pkg/reconciler"
@@ -28,20 +38,33 @@ type ChiaHarvesterReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
-}
 
-var chiaharvesters map[string]bool = make(map[string]bool)
+	// PrometheusOperator says which Prometheus Operator kinds the cluster serves. chia-exporter monitors and alerting rules require their kind's CRD.
+	PrometheusOperator kube.PrometheusOperatorAPIs
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=delete
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *ChiaHarvesterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaHarvester", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s", req.NamespacedName.String()))
@@ -50,69 +73,246 @@ func (r *ChiaHarvesterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	var harvester k8schianetv1.ChiaHarvester
 	err := r.Get(ctx, req.NamespacedName, &harvester)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiaharvesters[req.NamespacedName.String()]
-		if exists {
-			delete(chiaharvesters, req.NamespacedName.String())
-			metrics.ChiaHarvesters.Sub(1.0)
+		// chia-exporter monitors and PrometheusRules in other namespaces aren't garbage collected with the ChiaHarvester
+		err = kube.RemoveChiaExporterMonitors(ctx, r.Client, r.PrometheusOperator, kube.GetCommonLabels(ctx, "ChiaHarvester", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "Monitor")
+			return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error removing harvester chia-exporter monitors: %v", req.NamespacedName, err)
+		}
+		if r.PrometheusOperator.PrometheusRules {
+			err = kube.ReconcilePrometheusRule(ctx, r.Client, nil, kube.GetCommonLabels(ctx, "ChiaHarvester", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+			if err != nil {
+				metrics.RecordReconcileError("ChiaHarvester", "PrometheusRule")
+				return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error removing harvester PrometheusRules: %v", req.NamespacedName, err)
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaHarvester", "ChiaHarvester")
 		log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to fetch ChiaHarvester resource", req.NamespacedName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiaharvesters[req.NamespacedName.String()]
-	if !exists {
-		chiaharvesters[req.NamespacedName.String()] = true
-		metrics.ChiaHarvesters.Add(1.0)
-	}
+	previousStatus := harvester.Status.DeepCopy()
 
 	// Reconcile ChiaHarvester owned objects
 	srv := r.assembleBaseService(ctx, harvester)
-	res, err := kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err := kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, harvester.Spec.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaHarvester", "Service")
 		r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester Service: %v", req.NamespacedName, err)
 	}
 
 	srv = r.assembleChiaExporterService(ctx, harvester)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, harvester.Spec.ChiaExporterConfig.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaHarvester", "Service")
 		r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester metrics Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester chia-exporter Service: %v", req.NamespacedName, err)
 	}
 
-	deploy := r.assembleDeployment(ctx, harvester)
-	res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
+	policy := r.assembleNetworkPolicy(ctx, harvester)
+	if harvester.Spec.NetworkPolicy.Enabled {
+		res, err = kube.ReconcileNetworkPolicy(ctx, resourceReconciler, policy)
+	} else {
+		res, err = kube.RemoveNetworkPolicy(ctx, resourceReconciler, policy)
+	}
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
-		r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester Deployment -- Check operator logs.")
-		return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester Deployment: %v", req.NamespacedName, err)
+		metrics.RecordReconcileError("ChiaHarvester", "NetworkPolicy")
+		r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester NetworkPolicy -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester NetworkPolicy: %v", req.NamespacedName, err)
 	}
 
-	// Update CR status
-	r.Recorder.Event(&harvester, corev1.EventTypeNormal, "Created", "Successfully created ChiaHarvester resources.")
-	harvester.Status.Ready = true
-	err = r.Status().Update(ctx, &harvester)
+	// Scrape chia-exporter with a Prometheus Operator monitor if its CRD is installed
+	if len(r.PrometheusOperator.MonitorKinds()) > 0 {
+		monitor, err := r.assembleChiaExporterMonitor(ctx, harvester)
+		if err == nil {
+			err = kube.ReconcileChiaExporterMonitor(ctx, r.Client, r.PrometheusOperator, monitor, kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta))
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "Monitor")
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester chia-exporter monitor -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester chia-exporter monitor: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &harvester, &harvester.Status.Conditions, consts.MonitorsDisabledCondition, !r.PrometheusOperator.ServesMonitor(harvester.Spec.ChiaExporterConfig.Monitor),
+		"A chia-exporter monitor is configured but its Prometheus Operator CRD was not found when the operator started.")
+
+	// Create the PrometheusRule with the harvester's alerts if the CRD is installed
+	if r.PrometheusOperator.PrometheusRules {
+		err = kube.ReconcilePrometheusRule(ctx, r.Client, r.assemblePrometheusRule(ctx, harvester), kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "PrometheusRule")
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester PrometheusRule -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester PrometheusRule: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &harvester, &harvester.Status.Conditions, consts.AlertingDisabledCondition, harvester.Spec.Alerting != nil && !r.PrometheusOperator.PrometheusRules,
+		"Alerting is configured but the PrometheusRule CRD was not found when the operator started.")
+	kube.SetWarningCondition(r.Recorder, &harvester, &harvester.Status.Conditions, consts.AlertingWithoutMonitorCondition, harvester.Spec.Alerting != nil && (harvester.Spec.ChiaExporterConfig.Monitor == nil || !harvester.Spec.ChiaExporterConfig.Enabled),
+		"Alerting is configured without a chia-exporter monitor. Alerts select metrics by their k8s_chia_net_provenance label, which your own scrape config must add.")
+
+	// The harvester runs as either Deployments or a DaemonSet, the other is removed in case the ChiaHarvester switched between them
+	workloadMeta := metav1.ObjectMeta{
+		Name:      fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
+		Namespace: harvester.Namespace,
+	}
+	plotDirsConfigMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(chiaharvesterPlotDirectoriesNamePattern, harvester.Name),
+			Namespace: harvester.Namespace,
+		},
+	}
+	var plotDirs map[string][]string
+	var shards []plotShard
+	desiredDeployments := make(map[string]bool)
+	if harvester.Spec.DaemonSet != nil {
+		plotDirs, err = r.discoverNodePlotDirectories(ctx, harvester)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "Node")
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to discover harvester plot directories -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error discovering plot directories on nodes: %v", req.NamespacedName, err)
+		}
+
+		plotDirsConfigMap = r.assemblePlotDirectoriesConfigMap(ctx, harvester, plotDirs)
+		res, err = kube.ReconcileConfigMap(ctx, resourceReconciler, plotDirsConfigMap)
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaHarvester", "ConfigMap")
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester plot directories ConfigMap -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester plot directories ConfigMap: %v", req.NamespacedName, err)
+		}
+
+		daemonSet := r.assembleDaemonSet(ctx, harvester)
+		res, err = kube.ReconcileDaemonSet(ctx, resourceReconciler, daemonSet)
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaHarvester", "DaemonSet")
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester DaemonSet -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester DaemonSet: %v", req.NamespacedName, err)
+		}
+
+	} else {
+		// Sharded harvesters run a Deployment for each shard, with a disjoint subset of the plot volumes
+		var deployments []appsv1.Deployment
+		if harvester.Spec.Replicas > 1 {
+			shards, err = r.assignPlotShards(ctx, harvester)
+			if err != nil {
+				metrics.RecordReconcileError("ChiaHarvester", "ChiaHarvester")
+				r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to assign plot volumes to harvester shards -- Check operator logs.")
+				return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assigning plot volumes to shards: %v", req.NamespacedName, err)
+			}
+			for _, shard := range shards {
+				deployments = append(deployments, r.assembleShardDeployment(ctx, harvester, shard))
+			}
+		} else {
+			deployments = append(deployments, r.assembleDeployment(ctx, harvester))
+		}
+
+		for _, deploy := range deployments {
+			res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
+			if err != nil {
+				if res == nil {
+					res = &reconcile.Result{}
+				}
+				metrics.RecordReconcileError("ChiaHarvester", "Deployment")
+				r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester Deployment -- Check operator logs.")
+				return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester Deployment %s: %v", req.NamespacedName, deploy.Name, err)
+			}
+			desiredDeployments[deploy.Name] = true
+		}
+
+		res, err = kube.RemoveDaemonSet(ctx, resourceReconciler, appsv1.DaemonSet{ObjectMeta: workloadMeta})
+		if err == nil {
+			res, err = kube.RemoveConfigMap(ctx, resourceReconciler, plotDirsConfigMap)
+		}
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaHarvester", "DaemonSet")
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to remove harvester DaemonSet -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error removing harvester DaemonSet: %v", req.NamespacedName, err)
+		}
+	}
+
+	err = r.removeStaleDeployments(ctx, harvester, desiredDeployments)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaHarvester", "Deployment")
+		r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to remove harvester Deployment -- Check operator logs.")
+		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error removing stale harvester Deployments: %v", req.NamespacedName, err)
+	}
+
+	// Plot checks mount every plot volume in a single pod, so they're only supported for harvesters running in a single Deployment
+	cronJob := batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(chiaharvesterPlotCheckNamePattern, harvester.Name),
+			Namespace: harvester.Namespace,
+		},
+	}
+	if harvester.Spec.PlotCheck != nil && RunsSingleDeployment(harvester) {
+		cronJob = r.assemblePlotCheckCronJob(ctx, harvester)
+		res, err = kube.ReconcileCronJob(ctx, resourceReconciler, cronJob)
+	} else {
+		harvester.Status.PlotCheck = nil
+		res, err = kube.RemoveCronJob(ctx, resourceReconciler, cronJob)
+	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
-		log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to update ChiaHarvester status", req.NamespacedName))
-		return ctrl.Result{}, err
+		if res == nil {
+			res = &reconcile.Result{}
+		}
+		metrics.RecordReconcileError("ChiaHarvester", "CronJob")
+		r.Recorder.Event(&harvester, corev1.EventTypeWarning, "Failed", "Failed to create harvester plot check CronJob -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error reconciling harvester plot check CronJob: %v", req.NamespacedName, err)
+	}
+	kube.SetWarningCondition(r.Recorder, &harvester, &harvester.Status.Conditions, consts.PlotCheckUnsupportedCondition, harvester.Spec.PlotCheck != nil && !RunsSingleDeployment(harvester),
+		"Scheduled plot checks are not supported for harvesters running as a DaemonSet or in shards.")
+
+	// Update CR status, only writing it and recording the event when something changed since Job and Node changes reconcile the harvester too
+	if !harvester.Status.Ready {
+		r.Recorder.Event(&harvester, corev1.EventTypeNormal, "Created", "Successfully created ChiaHarvester resources.")
+	}
+	harvester.Status.Ready = true
+	harvester.Status.Nodes = nil
+	harvester.Status.Shards = getShardStatus(harvester, shards)
+	if harvester.Spec.DaemonSet != nil {
+		err = r.updateNodeStatus(ctx, &harvester, plotDirs)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "Pod")
+			log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to read harvester pod readiness", req.NamespacedName))
+		}
+	}
+	if harvester.Spec.PlotCheck != nil && RunsSingleDeployment(harvester) {
+		updated, err := r.updatePlotCheckStatus(ctx, &harvester)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "Job")
+			log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to read plot check results", req.NamespacedName))
+		}
+		if updated && (len(harvester.Status.PlotCheck.BadPlots) > 0 || len(harvester.Status.PlotCheck.LowQualityPlots) > 0) {
+			r.Recorder.Event(&harvester, corev1.EventTypeWarning, "PlotCheck", fmt.Sprintf("Plot check found %d bad and %d low quality plots -- Check the ChiaHarvester status.", len(harvester.Status.PlotCheck.BadPlots), len(harvester.Status.PlotCheck.LowQualityPlots)))
+		}
+	}
+	if !equality.Semantic.DeepEqual(*previousStatus, harvester.Status) {
+		err = r.Status().Update(ctx, &harvester)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaHarvester", "ChiaHarvester")
+			log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to update ChiaHarvester status", req.NamespacedName))
+			return ctrl.Result{}, err
+		}
 	}
 
 	return ctrl.Result{}, nil
@@ -122,5 +322,25 @@ func (r *ChiaHarvesterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 func (r *ChiaHarvesterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaHarvester{}).
+		Owns(&appsv1.DaemonSet{}).
+		Owns(&batchv1.CronJob{}).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(plotCheckJobToHarvester)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.nodeToHarvesters), builder.WithPredicates(predicate.Or(predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		Complete(r)
 }
+
+// plotCheckJobToHarvester maps plot check Jobs, which are owned by the plot check CronJob, to the ChiaHarvester they check
+func plotCheckJobToHarvester(ctx context.Context, obj client.Object) []reconcile.Request {
+	name, exists := obj.GetLabels()[plotCheckLabel]
+	if !exists {
+		return nil
+	}
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Namespace: obj.GetNamespace(),
+				Name:      name,
+			},
+		},
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// chiaharvesterPlotDirectoriesNamePattern is the name of the ConfigMap listing each kubernetes node's discovered plot directories
+	chiaharvesterPlotDirectoriesNamePattern = "%s-harvester-plot-directories"
+
+	// nodePlotsPath is where the DaemonSet's hostPath is mounted in the harvester container
+	nodePlotsPath = "/node-plots"
+
+	// plotDirectoriesPath is where the plot directories ConfigMap is mounted in the harvester container
+	plotDirectoriesPath = "/plot-directories"
+)
+
+// discoverPlotsScript resolves the plot directories on the harvester's kubernetes node and adds them to plots_dir before running the image's entrypoint.
+// Directories from the node's labels and annotations are read from the plot directories ConfigMap, which may lag behind a newly added node for a
+// little while, and the glob is expanded in the container otherwise. Falls back to the whole hostPath if neither is set.
+const discoverPlotsScript = `set -o errexit
+dirs_file="` + plotDirectoriesPath + `/${NODE_NAME}"
+for i in $(seq 1 60); do
+  [ -f "${dirs_file}" ] && break
+  sleep 2
+done
+discovered=""
+if [ -s "${dirs_file}" ]; then
+  discovered="$(cat "${dirs_file}")"
+elif [ -n "${PLOT_DIRECTORY_GLOB}" ]; then
+  shopt -s nullglob
+  for dir in ` + nodePlotsPath + `/${PLOT_DIRECTORY_GLOB}; do
+    [ -d "${dir}" ] && discovered="${discovered:+${discovered}:}${dir}"
+  done
+else
+  discovered="` + nodePlotsPath + `"
+fi
+echo "Discovered plot directories on ${NODE_NAME}: ${discovered}"
+export plots_dir="${plots_dir:+${plots_dir}:}${discovered}"
+exec docker-entrypoint.sh docker-start.sh`
+
+// assembleDaemonSet assembles the harvester DaemonSet resource for a ChiaHarvester CR running a harvester on each kubernetes node
+func (r *ChiaHarvesterReconciler) assembleDaemonSet(ctx context.Context, harvester k8schianetv1.ChiaHarvester) appsv1.DaemonSet {
+	template := r.assemblePodTemplate(ctx, harvester)
+
+	hostToContainer := corev1.MountPropagationHostToContainer
+	template.Spec.Volumes = append(template.Spec.Volumes,
+		corev1.Volume{
+			Name: "node-plots",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: harvester.Spec.DaemonSet.HostPath,
+				},
+			},
+		},
+		corev1.Volume{
+			Name: "plot-directories",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fmt.Sprintf(chiaharvesterPlotDirectoriesNamePattern, harvester.Name),
+					},
+				},
+			},
+		},
+	)
+
+	chia := &template.Spec.Containers[0]
+	chia.Command = []string{"/bin/bash", "-c", discoverPlotsScript}
+	chia.Env = append(chia.Env,
+		corev1.EnvVar{
+			Name: "NODE_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "spec.nodeName",
+				},
+			},
+		},
+		corev1.EnvVar{
+			Name:  "PLOT_DIRECTORY_GLOB",
+			Value: harvester.Spec.DaemonSet.PlotDirectoryGlob,
+		},
+	)
+	chia.VolumeMounts = append(chia.VolumeMounts,
+		corev1.VolumeMount{
+			Name:             "node-plots",
+			ReadOnly:         true,
+			MountPath:        nodePlotsPath,
+			MountPropagation: &hostToContainer,
+		},
+		corev1.VolumeMount{
+			Name:      "plot-directories",
+			ReadOnly:  true,
+			MountPath: plotDirectoriesPath,
+		},
+	)
+
+	return appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
+			Namespace:       harvester.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
+			Annotations:     harvester.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, harvester),
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta),
+			},
+			Template: template,
+		},
+	}
+}
+
+// assemblePlotDirectoriesConfigMap assembles the ConfigMap listing the plot directories discovered from each kubernetes node's labels and annotations.
+// Every node gets a key, which is empty if nothing was discovered, so harvester pods can tell a node without plot directories from a stale ConfigMap.
+func (r *ChiaHarvesterReconciler) assemblePlotDirectoriesConfigMap(ctx context.Context, harvester k8schianetv1.ChiaHarvester, plotDirs map[string][]string) corev1.ConfigMap {
+	data := make(map[string]string)
+	for nodeName, dirs := range plotDirs {
+		data[nodeName] = strings.Join(dirs, ":")
+	}
+
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chiaharvesterPlotDirectoriesNamePattern, harvester.Name),
+			Namespace:       harvester.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
+			Annotations:     harvester.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, harvester),
+		},
+		Data: data,
+	}
+}
+
+// discoverNodePlotDirectories gives the plot directories discovered from the labels and annotations of each kubernetes node the harvester DaemonSet runs on
+func (r *ChiaHarvesterReconciler) discoverNodePlotDirectories(ctx context.Context, harvester k8schianetv1.ChiaHarvester) (map[string][]string, error) {
+	var nodes corev1.NodeList
+	err := r.List(ctx, &nodes, client.MatchingLabels(harvester.Spec.NodeSelector))
+	if err != nil {
+		return nil, err
+	}
+
+	plotDirs := make(map[string][]string)
+	for _, node := range nodes.Items {
+		plotDirs[node.Name] = getNodePlotDirectories(ctx, harvester, node)
+	}
+	return plotDirs, nil
+}
+
+// getNodePlotDirectories gives the plot directories from a kubernetes node's labels and annotations, as paths in the harvester container.
+// Annotated paths outside of the DaemonSet's hostPath are skipped.
+func getNodePlotDirectories(ctx context.Context, harvester k8schianetv1.ChiaHarvester, node corev1.Node) []string {
+	hostPath := path.Clean(harvester.Spec.DaemonSet.HostPath)
+	dirSet := make(map[string]bool)
+
+	if harvester.Spec.DaemonSet.PlotDirectoryAnnotation != "" {
+		for _, dir := range strings.Split(node.Annotations[harvester.Spec.DaemonSet.PlotDirectoryAnnotation], ",") {
+			dir = path.Clean(strings.TrimSpace(dir))
+			if dir == "." {
+				continue
+			}
+			if dir != hostPath && !strings.HasPrefix(dir, strings.TrimSuffix(hostPath, "/")+"/") {
+				log.FromContext(ctx).Info(fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s skipping plot directory %s on node %s, it isn't under %s", harvester.Name, dir, node.Name, hostPath))
+				continue
+			}
+			dirSet[path.Join(nodePlotsPath, strings.TrimPrefix(dir, hostPath))] = true
+		}
+	}
+
+	if harvester.Spec.DaemonSet.PlotDirectoryLabelPrefix != "" {
+		for key := range node.Labels {
+			name := strings.TrimPrefix(key, harvester.Spec.DaemonSet.PlotDirectoryLabelPrefix)
+			if name == key || name == "" {
+				continue
+			}
+			dirSet[path.Join(nodePlotsPath, name)] = true
+		}
+	}
+
+	var dirs []string
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// updateNodeStatus records the readiness and discovered plot directories of the harvester pod on each kubernetes node in the ChiaHarvester's status
+func (r *ChiaHarvesterReconciler) updateNodeStatus(ctx context.Context, harvester *k8schianetv1.ChiaHarvester, plotDirs map[string][]string) error {
+	var pods corev1.PodList
+	err := r.List(ctx, &pods, client.InNamespace(harvester.Namespace), client.MatchingLabels(kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta)))
+	if err != nil {
+		return err
+	}
+
+	var nodes []k8schianetv1.ChiaHarvesterNodeStatus
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		ready := false
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready = true
+			}
+		}
+
+		nodes = append(nodes, k8schianetv1.ChiaHarvesterNodeStatus{
+			NodeName:        pod.Spec.NodeName,
+			PodName:         pod.Name,
+			Ready:           ready,
+			PlotDirectories: plotDirs[pod.Spec.NodeName],
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].NodeName < nodes[j].NodeName
+	})
+
+	harvester.Status.Nodes = nodes
+	return nil
+}
+
+// nodeToHarvesters maps kubernetes nodes to the ChiaHarvesters running as a DaemonSet, so plot directories are rediscovered when nodes change
+func (r *ChiaHarvesterReconciler) nodeToHarvesters(ctx context.Context, obj client.Object) []reconcile.Request {
+	var harvesters k8schianetv1.ChiaHarvesterList
+	err := r.List(ctx, &harvesters)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "ChiaHarvesterReconciler unable to list ChiaHarvesters for node change")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, harvester := range harvesters.Items {
+		if harvester.Spec.DaemonSet == nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: harvester.Namespace,
+				Name:      harvester.Name,
+			},
+		})
+	}
+	return requests
+}
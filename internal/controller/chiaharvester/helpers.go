@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -65,7 +66,43 @@ func (r *ChiaHarvesterReconciler) getChiaVolumes(ctx context.Context, harvester
 		})
 	}
 
-	// hostPath and PVC plot volumes
+	// plot volumes
+	v = append(v, r.getPlotVolumes(ctx, harvester)...)
+
+	// Add sidecar volumes if any exist
+	if len(harvester.Spec.Sidecars.Volumes) > 0 {
+		v = append(v, harvester.Spec.Sidecars.Volumes...)
+	}
+
+	return v
+}
+
+// getChiaVolumeMounts retrieves the requisite volume mounts from the Chia config struct
+func (r *ChiaHarvesterReconciler) getChiaVolumeMounts(ctx context.Context, harvester k8schianetv1.ChiaHarvester) []corev1.VolumeMount {
+	var v []corev1.VolumeMount
+
+	// secret ca volume
+	v = append(v, corev1.VolumeMount{
+		Name:      "secret-ca",
+		MountPath: "/chia-ca",
+	})
+
+	// CHIA_ROOT volume
+	v = append(v, corev1.VolumeMount{
+		Name:      "chiaroot",
+		MountPath: "/chia-data",
+	})
+
+	// plot volumemounts
+	v = append(v, r.getPlotVolumeMounts(ctx, harvester)...)
+
+	return v
+}
+
+// getPlotVolumes retrieves the hostPath, PVC, and generic plot volumes from the storage config
+func (r *ChiaHarvesterReconciler) getPlotVolumes(ctx context.Context, harvester k8schianetv1.ChiaHarvester) []corev1.Volume {
+	var v []corev1.Volume
+
 	if harvester.Spec.Storage != nil {
 		if harvester.Spec.Storage.Plots != nil {
 			// PVC plot volumes
@@ -99,34 +136,25 @@ func (r *ChiaHarvesterReconciler) getChiaVolumes(ctx context.Context, harvester
 					}
 				}
 			}
-		}
-	}
 
-	// Add sidecar volumes if any exist
-	if len(harvester.Spec.Sidecars.Volumes) > 0 {
-		v = append(v, harvester.Spec.Sidecars.Volumes...)
+			// generic plot volumes
+			for _, vol := range harvester.Spec.Storage.Plots.Volumes {
+				v = append(v, corev1.Volume{
+					Name:         getPlotVolumeName(vol),
+					VolumeSource: vol.VolumeSource,
+				})
+			}
+		}
 	}
 
 	return v
 }
 
-// getChiaVolumeMounts retrieves the requisite volume mounts from the Chia config struct
-func (r *ChiaHarvesterReconciler) getChiaVolumeMounts(ctx context.Context, harvester k8schianetv1.ChiaHarvester) []corev1.VolumeMount {
+// getPlotVolumeMounts retrieves the hostPath, PVC, and generic plot volume mounts from the storage config.
+// hostPath and PVC plots are always mounted read-only.
+func (r *ChiaHarvesterReconciler) getPlotVolumeMounts(ctx context.Context, harvester k8schianetv1.ChiaHarvester) []corev1.VolumeMount {
 	var v []corev1.VolumeMount
 
-	// secret ca volume
-	v = append(v, corev1.VolumeMount{
-		Name:      "secret-ca",
-		MountPath: "/chia-ca",
-	})
-
-	// CHIA_ROOT volume
-	v = append(v, corev1.VolumeMount{
-		Name:      "chiaroot",
-		MountPath: "/chia-data",
-	})
-
-	// hostPath and PVC plot volumemounts
 	if harvester.Spec.Storage != nil {
 		if harvester.Spec.Storage.Plots != nil {
 			// PVC plot volume mounts
@@ -154,12 +182,31 @@ func (r *ChiaHarvesterReconciler) getChiaVolumeMounts(ctx context.Context, harve
 					}
 				}
 			}
+
+			// generic plot volume mounts
+			for _, vol := range harvester.Spec.Storage.Plots.Volumes {
+				mountPath := vol.MountPath
+				if mountPath == "" {
+					mountPath = fmt.Sprintf("/plots/%s", vol.Name)
+				}
+				v = append(v, corev1.VolumeMount{
+					Name:      getPlotVolumeName(vol),
+					ReadOnly:  vol.ReadOnly == nil || *vol.ReadOnly,
+					SubPath:   vol.SubPath,
+					MountPath: mountPath,
+				})
+			}
 		}
 	}
 
 	return v
 }
 
+// getPlotVolumeName gives the pod volume name of a generic plot volume, prefixed so it can't collide with the harvester's other volumes
+func getPlotVolumeName(vol k8schianetv1.PlotVolumeConfig) string {
+	return fmt.Sprintf("plots-%s", vol.Name)
+}
+
 // getChiaEnv retrieves the environment variables from the Chia config struct
 func (r *ChiaHarvesterReconciler) getChiaEnv(ctx context.Context, harvester k8schianetv1.ChiaHarvester) []corev1.EnvVar {
 	var env []corev1.EnvVar
@@ -238,13 +285,24 @@ func (r *ChiaHarvesterReconciler) getChiaEnv(ctx context.Context, harvester k8sc
 		})
 	}
 
-	// recursive_plot_scan env var -- needed because all plot drives are just mounted as subdirs under `/plots`.
-	// TODO make plot mount paths configurable -- make this var optional
+	// recursive_plot_scan env var -- needed because plot volumes often keep plots in sub-directories
 	env = append(env, corev1.EnvVar{
 		Name:  "recursive_plot_scan",
 		Value: "true",
 	})
 
+	// plots_dir env var -- the harvester's plot_directories, generated from the plot volume mounts
+	var plotDirs []string
+	for _, mount := range r.getPlotVolumeMounts(ctx, harvester) {
+		plotDirs = append(plotDirs, mount.MountPath)
+	}
+	if len(plotDirs) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "plots_dir",
+			Value: strings.Join(plotDirs, ":"),
+		})
+	}
+
 	// farmer peer env vars
 	env = append(env, corev1.EnvVar{
 		Name:  "farmer_address",
@@ -0,0 +1,35 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"fmt"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// GetMaintenancePodConfig gives the pod configuration of a ChiaHarvester for ChiaMaintenance tasks
+func GetMaintenancePodConfig(ctx context.Context, harvester k8schianetv1.ChiaHarvester) kube.ChiaPodConfig {
+	r := &ChiaHarvesterReconciler{}
+	var unsupported string
+	if !RunsSingleDeployment(harvester) {
+		// ChiaHarvesters running as a DaemonSet or in shards don't have a single workload to scale down, or a single CHIA_ROOT to run the task against
+		unsupported = "ChiaHarvesters running as a DaemonSet or in shards are not supported"
+	}
+
+	return kube.ChiaPodConfig{
+		WorkloadName:    fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
+		Unsupported:     unsupported,
+		Image:           harvester.Spec.ChiaConfig.Image,
+		ImagePullPolicy: harvester.Spec.ImagePullPolicy,
+		Env:             r.getChiaEnv(ctx, harvester),
+		Volumes:         r.getChiaVolumes(ctx, harvester),
+		VolumeMounts:    r.getChiaVolumeMounts(ctx, harvester),
+		NodeSelector:    harvester.Spec.NodeSelector,
+		SecurityContext: harvester.Spec.PodSecurityContext,
+	}
+}
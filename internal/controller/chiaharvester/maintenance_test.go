@@ -0,0 +1,50 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"testing"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestGetMaintenancePodConfigUnsupported(t *testing.T) {
+	daemonSet := newShardedHarvester(1, nil, nil)
+	daemonSet.Spec.DaemonSet = &k8schianetv1.ChiaHarvesterDaemonSet{}
+
+	tests := []struct {
+		name        string
+		harvester   k8schianetv1.ChiaHarvester
+		unsupported bool
+	}{
+		{
+			name:      "single deployment",
+			harvester: newShardedHarvester(1, nil, nil),
+		},
+		{
+			name:        "shards",
+			harvester:   newShardedHarvester(3, nil, nil),
+			unsupported: true,
+		},
+		{
+			name:        "daemonset",
+			harvester:   daemonSet,
+			unsupported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podConfig := GetMaintenancePodConfig(context.Background(), tt.harvester)
+			if actual := podConfig.Unsupported != ""; actual != tt.unsupported {
+				t.Errorf("Expected unsupported to be %t, got reason %q", tt.unsupported, podConfig.Unsupported)
+			}
+			if podConfig.WorkloadName != "harvester-harvester" {
+				t.Errorf("Expected workload name harvester-harvester, got %s", podConfig.WorkloadName)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	chiaharvesterPlotCheckNamePattern = "%s-harvester-plot-check"
+
+	// plotCheckLabel is set on plot check Jobs and Pods with the name of the ChiaHarvester they check
+	plotCheckLabel = "k8s.chia.net/plot-check"
+
+	// defaultPlotCheckChallenges is the number of challenges used when a plot check does not specify one, matching chia's own default
+	defaultPlotCheckChallenges int32 = 30
+
+	// defaultLowQualityThreshold is the low quality percentage used when a plot check does not specify one
+	defaultLowQualityThreshold int32 = 50
+)
+
+// plotCheckScript runs `chia plots check` and writes the plots that are bad or below the low quality threshold to the termination log.
+// The first line of the termination log is "total <plots checked>", and the rest are "<proofs> <challenges> <plot path>".
+// Plots chia fails to open are never tested, so they're written with 0 proofs of 0 challenges to be counted as bad.
+const plotCheckScript = `set -o pipefail
+chia plots check -n %d 2>&1 | tee /tmp/plots-check.log
+rc=$?
+awk -v min=%d '
+/Failed to open file / { for (i = 1; i < NF; i++) if ($i == "file") { plot = $(i + 1); sub(/\.$/, "", plot); if (!(plot in failed)) { failed[plot] = 1; total++; out = out "0 0 " plot "\n" } } }
+/Testing plot / { for (i = 1; i < NF; i++) if ($i == "plot") { plot = $(i + 1) } }
+/Proofs [0-9]+ \/ [0-9]+/ { for (i = 1; i < NF; i++) if ($i == "Proofs") { total++; proofs = $(i + 1); challenges = $(i + 3); sub(",", "", challenges); if (proofs * 100 < challenges * min) { out = out proofs " " challenges " " plot "\n" } } }
+END { printf "total %%d\n%%s", total, out }
+' /tmp/plots-check.log | head -c 4096 > /dev/termination-log
+exit $rc`
+
+// assemblePlotCheckCronJob assembles the plot check CronJob resource for a ChiaHarvester CR
+func (r *ChiaHarvesterReconciler) assemblePlotCheckCronJob(ctx context.Context, harvester k8schianetv1.ChiaHarvester) batchv1.CronJob {
+	var backoffLimit int32 = 0
+
+	challenges := defaultPlotCheckChallenges
+	if harvester.Spec.PlotCheck.Challenges != nil {
+		challenges = *harvester.Spec.PlotCheck.Challenges
+	}
+	threshold := defaultLowQualityThreshold
+	if harvester.Spec.PlotCheck.LowQualityThreshold != nil {
+		threshold = *harvester.Spec.PlotCheck.LowQualityThreshold
+	}
+
+	// The plot check gets its own CHIA_ROOT so it doesn't compete with the harvester for a ReadWriteOnce claim
+	volumes := []corev1.Volume{
+		{
+			Name: "secret-ca",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: harvester.Spec.ChiaConfig.CASecretName,
+				},
+			},
+		},
+		{
+			Name: "chiaroot",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	volumes = append(volumes, r.getPlotVolumes(ctx, harvester)...)
+
+	// Plot check pods don't use the harvester's labels so they aren't selected by the harvester's Services
+	podLabels := map[string]string{
+		plotCheckLabel: harvester.Name,
+	}
+
+	var cronJob batchv1.CronJob = batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chiaharvesterPlotCheckNamePattern, harvester.Name),
+			Namespace:       harvester.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta, harvester.Spec.AdditionalMetadata.Labels),
+			Annotations:     harvester.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, harvester),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          harvester.Spec.PlotCheck.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: podLabels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels:      podLabels,
+							Annotations: harvester.Spec.AdditionalMetadata.Annotations,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:                     "plot-check",
+									Image:                    harvester.Spec.ChiaConfig.Image,
+									ImagePullPolicy:          harvester.Spec.ImagePullPolicy,
+									Args:                     []string{"/bin/bash", "-c", fmt.Sprintf(plotCheckScript, challenges, threshold)},
+									Env:                      r.getChiaEnv(ctx, harvester),
+									VolumeMounts:             r.getChiaVolumeMounts(ctx, harvester),
+									TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+								},
+							},
+							// Prefer the harvester's node so hostPath and ReadWriteOnce plot volumes are available. This isn't required, since the plot check
+							// would never schedule while the harvester is scaled down, like during a ChiaMaintenance. Persistent volumes keep their own node affinity.
+							Affinity: &corev1.Affinity{
+								PodAffinity: &corev1.PodAffinity{
+									PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+										{
+											Weight: 100,
+											PodAffinityTerm: corev1.PodAffinityTerm{
+												LabelSelector: &metav1.LabelSelector{
+													MatchLabels: kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta),
+												},
+												TopologyKey: "kubernetes.io/hostname",
+											},
+										},
+									},
+								},
+							},
+							NodeSelector:    harvester.Spec.NodeSelector,
+							SecurityContext: harvester.Spec.PodSecurityContext,
+							Volumes:         volumes,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if harvester.Spec.ChiaConfig.SecurityContext != nil {
+		cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].SecurityContext = harvester.Spec.ChiaConfig.SecurityContext
+	}
+
+	if harvester.Spec.PlotCheck.Resources != nil {
+		cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources = *harvester.Spec.PlotCheck.Resources
+	}
+
+	return cronJob
+}
+
+// updatePlotCheckStatus records the results of the most recent successful plot check Job in the ChiaHarvester's status.
+// Returns true if new results were recorded.
+func (r *ChiaHarvesterReconciler) updatePlotCheckStatus(ctx context.Context, harvester *k8schianetv1.ChiaHarvester) (bool, error) {
+	var jobs batchv1.JobList
+	err := r.List(ctx, &jobs, client.InNamespace(harvester.Namespace), client.MatchingLabels{plotCheckLabel: harvester.Name})
+	if err != nil {
+		return false, err
+	}
+
+	var latest *batchv1.Job
+	for i, job := range jobs.Items {
+		if job.Status.Succeeded == 0 || job.Status.CompletionTime == nil {
+			continue
+		}
+		if latest == nil || latest.Status.CompletionTime.Before(job.Status.CompletionTime) {
+			latest = &jobs.Items[i]
+		}
+	}
+	if latest == nil {
+		return false, nil
+	}
+	if harvester.Status.PlotCheck != nil && harvester.Status.PlotCheck.JobName == latest.Name {
+		return false, nil
+	}
+
+	var pods corev1.PodList
+	err = r.List(ctx, &pods, client.InNamespace(harvester.Namespace), client.MatchingLabels{"job-name": latest.Name})
+	if err != nil {
+		return false, err
+	}
+
+	var message string
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "plot-check" && status.State.Terminated != nil {
+				message = status.State.Terminated.Message
+			}
+		}
+	}
+
+	threshold := defaultLowQualityThreshold
+	if harvester.Spec.PlotCheck != nil && harvester.Spec.PlotCheck.LowQualityThreshold != nil {
+		threshold = *harvester.Spec.PlotCheck.LowQualityThreshold
+	}
+
+	total, bad, low := parsePlotCheckResults(message, threshold)
+	harvester.Status.PlotCheck = &k8schianetv1.ChiaHarvesterPlotCheckStatus{
+		JobName:         latest.Name,
+		CompletionTime:  latest.Status.CompletionTime,
+		TotalPlots:      total,
+		BadPlots:        bad,
+		LowQualityPlots: low,
+	}
+
+	return true, nil
+}
+
+// parsePlotCheckResults parses the termination message written by plotCheckScript. Returns the total number of plots checked,
+// the plots that found no proofs or couldn't be opened, and the plots that found proofs for less than the threshold percentage of challenges.
+func parsePlotCheckResults(message string, threshold int32) (int32, []k8schianetv1.PlotCheckResult, []k8schianetv1.PlotCheckResult) {
+	var total int32
+	var bad, low []k8schianetv1.PlotCheckResult
+
+	// The termination log is truncated if there are too many results, so an unterminated last line is dropped
+	lines := strings.Split(message, "\n")
+	lines = lines[:len(lines)-1]
+
+	for _, line := range lines {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) == 2 && fields[0] == "total" {
+			n, err := strconv.ParseInt(fields[1], 10, 32)
+			if err == nil {
+				total = int32(n)
+			}
+			continue
+		}
+		if len(fields) != 3 {
+			continue
+		}
+
+		proofs, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		challenges, err := strconv.ParseInt(fields[1], 10, 32)
+		if err != nil {
+			continue
+		}
+
+		result := k8schianetv1.PlotCheckResult{
+			Path:       fields[2],
+			Proofs:     int32(proofs),
+			Challenges: int32(challenges),
+		}
+		if proofs == 0 {
+			bad = append(bad, result)
+		} else if proofs*100 < challenges*int64(threshold) {
+			low = append(low, result)
+		}
+	}
+
+	return total, bad, low
+}
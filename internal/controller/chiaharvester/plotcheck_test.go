@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+func TestParsePlotCheckResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		threshold int32
+		total     int32
+		bad       []k8schianetv1.PlotCheckResult
+		low       []k8schianetv1.PlotCheckResult
+	}{
+		{
+			name:    "empty",
+			message: "",
+		},
+		{
+			name:      "all good",
+			message:   "total 2\n",
+			threshold: 50,
+			total:     2,
+		},
+		{
+			name:      "bad and low quality plots",
+			message:   "total 3\n0 30 /plots/a/plot-1.plot\n10 30 /plots/a/plot-2.plot\n",
+			threshold: 50,
+			total:     3,
+			bad: []k8schianetv1.PlotCheckResult{
+				{Path: "/plots/a/plot-1.plot", Proofs: 0, Challenges: 30},
+			},
+			low: []k8schianetv1.PlotCheckResult{
+				{Path: "/plots/a/plot-2.plot", Proofs: 10, Challenges: 30},
+			},
+		},
+		{
+			name:      "plots that failed to open are bad",
+			message:   "total 2\n0 0 /plots/b/plot 3.plot\n",
+			threshold: 50,
+			total:     2,
+			bad: []k8schianetv1.PlotCheckResult{
+				{Path: "/plots/b/plot 3.plot", Proofs: 0, Challenges: 0},
+			},
+		},
+		{
+			name:      "threshold changes low quality plots",
+			message:   "total 1\n20 30 /plots/a/plot-2.plot\n",
+			threshold: 50,
+			total:     1,
+		},
+		{
+			name:      "truncated last line is dropped",
+			message:   "total 2\n0 30 /plots/a/plot-1.plot\n0 30 /plots/a/plo",
+			threshold: 50,
+			total:     2,
+			bad: []k8schianetv1.PlotCheckResult{
+				{Path: "/plots/a/plot-1.plot", Proofs: 0, Challenges: 30},
+			},
+		},
+		{
+			name:      "malformed lines are skipped",
+			message:   "total x\nsome 30 /plots/a/plot-1.plot\n3\n",
+			threshold: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, bad, low := parsePlotCheckResults(tt.message, tt.threshold)
+			if total != tt.total {
+				t.Errorf("Expected %d total plots, got %d", tt.total, total)
+			}
+			if diff := cmp.Diff(tt.bad, bad); diff != "" {
+				t.Errorf("Bad plots do not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff(tt.low, low); diff != "" {
+				t.Errorf("Low quality plots do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAssemblePlotCheckCronJobAffinity(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{
+		TypeMeta:   metav1.TypeMeta{Kind: "ChiaHarvester", APIVersion: "k8s.chia.net/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "harvester", Namespace: "chia"},
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			PlotCheck: &k8schianetv1.ChiaHarvesterPlotCheck{Schedule: "0 4 * * 0"},
+		},
+	}
+	r := &ChiaHarvesterReconciler{}
+
+	cronJob := r.assemblePlotCheckCronJob(context.Background(), harvester)
+
+	// The plot check must still schedule while the harvester is scaled down, so it may only prefer the harvester's node
+	affinity := cronJob.Spec.JobTemplate.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.PodAffinity == nil {
+		t.Fatal("Expected the plot check to have pod affinity to the harvester")
+	}
+	if len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 0 {
+		t.Errorf("Expected no required pod affinity, got %d terms", len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution))
+	}
+	if len(affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Fatalf("Expected 1 preferred pod affinity term, got %d", len(affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution))
+	}
+	term := affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	expected := kube.GetCommonLabels(context.Background(), harvester.Kind, harvester.ObjectMeta)
+	if diff := cmp.Diff(expected, term.LabelSelector.MatchLabels); diff != "" {
+		t.Errorf("Pod affinity selector does not match the harvester's labels. Diff: %s", diff)
+	}
+	if term.TopologyKey != "kubernetes.io/hostname" {
+		t.Errorf("Expected the kubernetes.io/hostname topology key, got %s", term.TopologyKey)
+	}
+}
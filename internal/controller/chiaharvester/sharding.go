@@ -0,0 +1,211 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// chiaharvesterShardNamePattern is the name of each harvester shard's Deployment
+	chiaharvesterShardNamePattern = "%s-harvester-%d"
+
+	// shardLabel is set on harvester shard Deployments and pods with the index of their shard, and is the default sharding label on plot claims
+	shardLabel = "k8s.chia.net/harvester-shard"
+)
+
+// plotShard is a harvester shard and the plot volumes assigned to it, by their position in the storage config
+type plotShard struct {
+	index    int32
+	pvc      map[int]bool
+	hostPath map[int]bool
+	volumes  map[string]bool
+
+	// names describes the assigned plot volumes by claim name, host path, or volume name
+	names []string
+}
+
+// RunsSingleDeployment returns true if the harvester runs in a single Deployment, rather than as a DaemonSet or in shards
+func RunsSingleDeployment(harvester k8schianetv1.ChiaHarvester) bool {
+	return harvester.Spec.DaemonSet == nil && harvester.Spec.Replicas <= 1
+}
+
+// assignPlotShards assigns each of the harvester's plot volumes to one of its shards. With the Label strategy, plot claims with a valid shard index
+// label are assigned to that shard, and all other plot volumes are assigned round-robin in the order they're listed in the storage config.
+func (r *ChiaHarvesterReconciler) assignPlotShards(ctx context.Context, harvester k8schianetv1.ChiaHarvester) ([]plotShard, error) {
+	shards := make([]plotShard, harvester.Spec.Replicas)
+	for i := range shards {
+		shards[i] = plotShard{
+			index:    int32(i),
+			pvc:      make(map[int]bool),
+			hostPath: make(map[int]bool),
+			volumes:  make(map[string]bool),
+		}
+	}
+	if harvester.Spec.Storage == nil || harvester.Spec.Storage.Plots == nil {
+		return shards, nil
+	}
+	plots := harvester.Spec.Storage.Plots
+
+	byLabel := harvester.Spec.Sharding != nil && harvester.Spec.Sharding.Strategy == k8schianetv1.ShardingStrategyLabel
+	labelKey := shardLabel
+	if harvester.Spec.Sharding != nil && harvester.Spec.Sharding.LabelKey != "" {
+		labelKey = harvester.Spec.Sharding.LabelKey
+	}
+
+	next := 0
+	roundRobin := func() *plotShard {
+		shard := &shards[next%len(shards)]
+		next++
+		return shard
+	}
+
+	for i, vol := range plots.PersistentVolumeClaim {
+		if vol == nil {
+			continue
+		}
+		var shard *plotShard
+		if byLabel {
+			index, err := r.getClaimShardIndex(ctx, harvester, vol.ClaimName, labelKey)
+			if err != nil {
+				return nil, err
+			}
+			if index >= 0 {
+				shard = &shards[index]
+			}
+		}
+		if shard == nil {
+			shard = roundRobin()
+		}
+		shard.pvc[i] = true
+		shard.names = append(shard.names, vol.ClaimName)
+	}
+
+	for i, vol := range plots.HostPathVolume {
+		if vol == nil {
+			continue
+		}
+		shard := roundRobin()
+		shard.hostPath[i] = true
+		shard.names = append(shard.names, vol.Path)
+	}
+
+	for _, vol := range plots.Volumes {
+		shard := roundRobin()
+		shard.volumes[vol.Name] = true
+		shard.names = append(shard.names, vol.Name)
+	}
+
+	return shards, nil
+}
+
+// getClaimShardIndex gives the shard index from a plot claim's sharding label, or -1 if the claim doesn't have a valid one
+func (r *ChiaHarvesterReconciler) getClaimShardIndex(ctx context.Context, harvester k8schianetv1.ChiaHarvester, claimName string, labelKey string) (int, error) {
+	var pvc corev1.PersistentVolumeClaim
+	err := r.Get(ctx, types.NamespacedName{Namespace: harvester.Namespace, Name: claimName}, &pvc)
+	if err != nil && errors.IsNotFound(err) {
+		return -1, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	index, err := strconv.Atoi(pvc.Labels[labelKey])
+	if err != nil || index < 0 || index >= int(harvester.Spec.Replicas) {
+		return -1, nil
+	}
+	return index, nil
+}
+
+// getShardHarvester gives a copy of the harvester with only the plot volumes assigned to the shard. Unassigned plot claims and hostPaths
+// are set to nil rather than removed, so the assigned ones keep the same volume names and mount paths they'd have in an unsharded harvester.
+func getShardHarvester(harvester k8schianetv1.ChiaHarvester, shard plotShard) k8schianetv1.ChiaHarvester {
+	shardHarvester := *harvester.DeepCopy()
+	if shardHarvester.Spec.Storage == nil || shardHarvester.Spec.Storage.Plots == nil {
+		return shardHarvester
+	}
+	plots := shardHarvester.Spec.Storage.Plots
+
+	for i := range plots.PersistentVolumeClaim {
+		if !shard.pvc[i] {
+			plots.PersistentVolumeClaim[i] = nil
+		}
+	}
+
+	for i := range plots.HostPathVolume {
+		if !shard.hostPath[i] {
+			plots.HostPathVolume[i] = nil
+		}
+	}
+
+	var volumes []k8schianetv1.PlotVolumeConfig
+	for _, vol := range plots.Volumes {
+		if shard.volumes[vol.Name] {
+			volumes = append(volumes, vol)
+		}
+	}
+	plots.Volumes = volumes
+
+	return shardHarvester
+}
+
+// assembleShardDeployment assembles the Deployment for one of a ChiaHarvester's shards
+func (r *ChiaHarvesterReconciler) assembleShardDeployment(ctx context.Context, harvester k8schianetv1.ChiaHarvester, shard plotShard) appsv1.Deployment {
+	deploy := r.assembleDeployment(ctx, getShardHarvester(harvester, shard))
+
+	index := strconv.Itoa(int(shard.index))
+	deploy.Name = fmt.Sprintf(chiaharvesterShardNamePattern, harvester.Name, shard.index)
+	deploy.Labels[shardLabel] = index
+	deploy.Spec.Selector.MatchLabels[shardLabel] = index
+	deploy.Spec.Template.Labels[shardLabel] = index
+
+	return deploy
+}
+
+// getShardStatus gives the status of each of the harvester's shards
+func getShardStatus(harvester k8schianetv1.ChiaHarvester, shards []plotShard) []k8schianetv1.ChiaHarvesterShardStatus {
+	var status []k8schianetv1.ChiaHarvesterShardStatus
+	for _, shard := range shards {
+		status = append(status, k8schianetv1.ChiaHarvesterShardStatus{
+			Index:          shard.index,
+			DeploymentName: fmt.Sprintf(chiaharvesterShardNamePattern, harvester.Name, shard.index),
+			PlotVolumes:    shard.names,
+		})
+	}
+	return status
+}
+
+// removeStaleDeployments deletes the harvester's Deployments that aren't in the desired set, left over from a change in replicas or a switch to a DaemonSet
+func (r *ChiaHarvesterReconciler) removeStaleDeployments(ctx context.Context, harvester k8schianetv1.ChiaHarvester, desired map[string]bool) error {
+	var deployments appsv1.DeploymentList
+	err := r.List(ctx, &deployments, client.InNamespace(harvester.Namespace), client.MatchingLabels(kube.GetCommonLabels(ctx, harvester.Kind, harvester.ObjectMeta)))
+	if err != nil {
+		return err
+	}
+
+	for i, deploy := range deployments.Items {
+		if desired[deploy.Name] || !metav1.IsControlledBy(&deploy, &harvester) {
+			continue
+		}
+		err = r.Delete(ctx, &deployments.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaharvester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// newShardedHarvester gives a ChiaHarvester with the given number of replicas and plot volumes
+func newShardedHarvester(replicas int32, sharding *k8schianetv1.ChiaHarvesterSharding, plots *k8schianetv1.PlotsConfig) k8schianetv1.ChiaHarvester {
+	return k8schianetv1.ChiaHarvester{
+		ObjectMeta: metav1.ObjectMeta{Name: "harvester", Namespace: "chia"},
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			Replicas: replicas,
+			Sharding: sharding,
+			Storage: &k8schianetv1.ChiaHarvesterStorageConfig{
+				Plots: plots,
+			},
+		},
+	}
+}
+
+// newPlotClaim gives a plot PersistentVolumeClaim with the given labels
+func newPlotClaim(name string, labels map[string]string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "chia", Labels: labels},
+	}
+}
+
+func TestAssignPlotShards(t *testing.T) {
+	plots := &k8schianetv1.PlotsConfig{
+		PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+			{ClaimName: "plots-1"},
+			{ClaimName: "plots-2"},
+			nil,
+			{ClaimName: "plots-3"},
+		},
+		HostPathVolume: []*k8schianetv1.HostPathVolumeConfig{
+			{Path: "/mnt/plots-4"},
+		},
+		Volumes: []k8schianetv1.PlotVolumeConfig{
+			{Name: "nfs-plots"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		harvester k8schianetv1.ChiaHarvester
+		claims    []*corev1.PersistentVolumeClaim
+		expected  [][]string
+	}{
+		{
+			name:      "no plots",
+			harvester: newShardedHarvester(2, nil, nil),
+			expected:  [][]string{nil, nil},
+		},
+		{
+			name:      "round robin",
+			harvester: newShardedHarvester(2, nil, plots),
+			expected: [][]string{
+				{"plots-1", "plots-3", "nfs-plots"},
+				{"plots-2", "/mnt/plots-4"},
+			},
+		},
+		{
+			name: "label",
+			harvester: newShardedHarvester(2, &k8schianetv1.ChiaHarvesterSharding{
+				Strategy: k8schianetv1.ShardingStrategyLabel,
+			}, plots),
+			claims: []*corev1.PersistentVolumeClaim{
+				newPlotClaim("plots-1", map[string]string{shardLabel: "1"}),
+				newPlotClaim("plots-2", map[string]string{shardLabel: "1"}),
+				newPlotClaim("plots-3", map[string]string{shardLabel: "5"}),
+			},
+			expected: [][]string{
+				{"plots-3", "nfs-plots"},
+				{"plots-1", "plots-2", "/mnt/plots-4"},
+			},
+		},
+		{
+			name: "custom label key",
+			harvester: newShardedHarvester(3, &k8schianetv1.ChiaHarvesterSharding{
+				Strategy: k8schianetv1.ShardingStrategyLabel,
+				LabelKey: "example.com/shard",
+			}, plots),
+			claims: []*corev1.PersistentVolumeClaim{
+				newPlotClaim("plots-1", map[string]string{shardLabel: "1"}),
+				newPlotClaim("plots-3", map[string]string{"example.com/shard": "2"}),
+			},
+			expected: [][]string{
+				{"plots-1", "nfs-plots"},
+				{"plots-2"},
+				{"plots-3", "/mnt/plots-4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			for _, claim := range tt.claims {
+				builder = builder.WithObjects(claim)
+			}
+			r := &ChiaHarvesterReconciler{Client: builder.Build()}
+
+			shards, err := r.assignPlotShards(context.Background(), tt.harvester)
+			if err != nil {
+				t.Fatalf("Error assigning plot shards: %v", err)
+			}
+
+			var actual [][]string
+			for i, shard := range shards {
+				if shard.index != int32(i) {
+					t.Errorf("Expected shard %d to have index %d, got %d", i, i, shard.index)
+				}
+				actual = append(actual, shard.names)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("Plot shard assignments do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGetShardHarvester(t *testing.T) {
+	harvester := newShardedHarvester(2, nil, &k8schianetv1.PlotsConfig{
+		PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+			{ClaimName: "plots-1"},
+			{ClaimName: "plots-2"},
+		},
+		HostPathVolume: []*k8schianetv1.HostPathVolumeConfig{
+			{Path: "/mnt/plots-3"},
+			{Path: "/mnt/plots-4"},
+		},
+		Volumes: []k8schianetv1.PlotVolumeConfig{
+			{Name: "nfs-plots-5"},
+			{Name: "nfs-plots-6"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		shard    plotShard
+		expected k8schianetv1.PlotsConfig
+	}{
+		{
+			name: "unassigned volumes keep their positions",
+			shard: plotShard{
+				pvc:      map[int]bool{1: true},
+				hostPath: map[int]bool{0: true},
+				volumes:  map[string]bool{"nfs-plots-6": true},
+			},
+			expected: k8schianetv1.PlotsConfig{
+				PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+					nil,
+					{ClaimName: "plots-2"},
+				},
+				HostPathVolume: []*k8schianetv1.HostPathVolumeConfig{
+					{Path: "/mnt/plots-3"},
+					nil,
+				},
+				Volumes: []k8schianetv1.PlotVolumeConfig{
+					{Name: "nfs-plots-6"},
+				},
+			},
+		},
+		{
+			name:  "empty shard",
+			shard: plotShard{},
+			expected: k8schianetv1.PlotsConfig{
+				PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{nil, nil},
+				HostPathVolume:        []*k8schianetv1.HostPathVolumeConfig{nil, nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shardHarvester := getShardHarvester(harvester, tt.shard)
+			if diff := cmp.Diff(tt.expected, *shardHarvester.Spec.Storage.Plots); diff != "" {
+				t.Errorf("Shard plot volumes do not match. Diff: %s", diff)
+			}
+		})
+	}
+
+	// The original harvester must not be modified
+	if harvester.Spec.Storage.Plots.PersistentVolumeClaim[0] == nil || len(harvester.Spec.Storage.Plots.Volumes) != 2 {
+		t.Errorf("getShardHarvester modified the original harvester's plot volumes")
+	}
+}
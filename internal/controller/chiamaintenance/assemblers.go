@@ -0,0 +1,77 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiamaintenance
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const chiamaintenanceNamePattern = "%s-maintenance"
+
+// resultBytes is the number of bytes from the end of a task's output that are kept as its result
+const resultBytes = 3072
+
+// assembleJob assembles the Job resource that runs the task for a ChiaMaintenance CR
+func (r *ChiaMaintenanceReconciler) assembleJob(ctx context.Context, m k8schianetv1.ChiaMaintenance, podConfig kube.ChiaPodConfig, command string) batchv1.Job {
+	var backoffLimit int32 = 0
+
+	image := podConfig.Image
+	if m.Spec.Image != "" {
+		image = m.Spec.Image
+	}
+
+	// Run the task through the chia image's entrypoint so CHIA_ROOT is initialized from the target's environment first.
+	// The tail of the task's output is written to the termination log so it can be recorded in the CR's status.
+	script := fmt.Sprintf("set -o pipefail; { %s; } 2>&1 | tee /tmp/maintenance.log; rc=$?; tail -c %d /tmp/maintenance.log > /dev/termination-log; exit $rc", command, resultBytes)
+
+	var job batchv1.Job = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chiamaintenanceNamePattern, m.Name),
+			Namespace:       m.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, m.Kind, m.ObjectMeta),
+			OwnerReferences: r.getOwnerReference(ctx, m),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: m.Spec.ActiveDeadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: kube.GetCommonLabels(ctx, m.Kind, m.ObjectMeta),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:                     "maintenance",
+							Image:                    image,
+							ImagePullPolicy:          podConfig.ImagePullPolicy,
+							Args:                     []string{"/bin/bash", "-c", script},
+							Env:                      podConfig.Env,
+							VolumeMounts:             podConfig.VolumeMounts,
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						},
+					},
+					NodeSelector:    podConfig.NodeSelector,
+					SecurityContext: podConfig.SecurityContext,
+					Volumes:         podConfig.Volumes,
+				},
+			},
+		},
+	}
+
+	if m.Spec.Resources != nil {
+		job.Spec.Template.Spec.Containers[0].Resources = *m.Spec.Resources
+	}
+
+	return job
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiamaintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/metrics"
+	"github.com/cisco-open/operator-tools/pkg/reconciler"
+)
+
+// ChiaMaintenanceReconciler reconciles a ChiaMaintenance object
+type ChiaMaintenanceReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// NodePodConfig, HarvesterPodConfig, and WalletPodConfig give the pod configuration of each kind of target.
+	// They're provided by each component's controller package, which owns how its pods are assembled.
+	NodePodConfig      func(ctx context.Context, node k8schianetv1.ChiaNode, replica int32) (kube.ChiaPodConfig, error)
+	HarvesterPodConfig func(ctx context.Context, harvester k8schianetv1.ChiaHarvester) kube.ChiaPodConfig
+	WalletPodConfig    func(ctx context.Context, wallet k8schianetv1.ChiaWallet) kube.ChiaPodConfig
+}
+
+const (
+	// maintenanceFinalizer makes sure a deleted ChiaMaintenance releases its target before it goes away
+	maintenanceFinalizer = "k8s.chia.net/maintenance-finalizer"
+
+	// scaleDownInterval is how often a ChiaMaintenance is requeued while waiting for its target to scale down
+	scaleDownInterval = 10 * time.Second
+
+	// jobPollInterval is how often a ChiaMaintenance is requeued while its Job is running
+	jobPollInterval = 15 * time.Second
+
+	// jobDeadlineMessage is the status message of a ChiaMaintenance whose Job ran past its activeDeadlineSeconds
+	jobDeadlineMessage = "Task exceeded its activeDeadlineSeconds"
+)
+
+//+kubebuilder:rbac:groups=k8s.chia.net,resources=chiamaintenances,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=k8s.chia.net,resources=chiamaintenances/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=k8s.chia.net,resources=chiamaintenances/finalizers,verbs=update
+//+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes;chiaharvesters;chiawallets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *ChiaMaintenanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaMaintenance", time.Now())
+	log := log.FromContext(ctx)
+	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
+	log.Info(fmt.Sprintf("ChiaMaintenanceController ChiaMaintenance=%s", req.NamespacedName.String()))
+
+	// Get the custom resource
+	var m k8schianetv1.ChiaMaintenance
+	err := r.Get(ctx, req.NamespacedName, &m)
+	if err != nil && errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		metrics.RecordReconcileError("ChiaMaintenance", "ChiaMaintenance")
+		log.Error(err, fmt.Sprintf("ChiaMaintenanceController ChiaMaintenance=%s unable to fetch ChiaMaintenance resource", req.NamespacedName))
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Stop the task and release the target before the CR is removed
+	if !m.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(&m, maintenanceFinalizer) {
+			stopped, err := r.stopJob(ctx, m)
+			if err != nil {
+				metrics.RecordReconcileError("ChiaMaintenance", "Job")
+				return ctrl.Result{}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error stopping maintenance Job: %v", req.NamespacedName, err)
+			}
+			if !stopped {
+				return ctrl.Result{RequeueAfter: scaleDownInterval}, nil
+			}
+
+			err = r.releaseTarget(ctx, m)
+			if err != nil {
+				metrics.RecordReconcileError("ChiaMaintenance", m.Spec.Target.Kind)
+				return ctrl.Result{}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error releasing target: %v", req.NamespacedName, err)
+			}
+			controllerutil.RemoveFinalizer(&m, maintenanceFinalizer)
+			err = r.Update(ctx, &m)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Finished tasks only need to make sure the target was released
+	if m.Status.Phase == k8schianetv1.MaintenancePhaseSucceeded || m.Status.Phase == k8schianetv1.MaintenancePhaseFailed {
+		err = r.releaseTarget(ctx, m)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaMaintenance", m.Spec.Target.Kind)
+			return ctrl.Result{}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error releasing target: %v", req.NamespacedName, err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(&m, maintenanceFinalizer) {
+		controllerutil.AddFinalizer(&m, maintenanceFinalizer)
+		err = r.Update(ctx, &m)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Determine the task's command and find its target
+	command, err := getTaskCommand(m)
+	if err != nil {
+		return r.finish(ctx, m, false, err.Error())
+	}
+
+	target, podConfig, notFound, err := r.getTarget(ctx, m)
+	if notFound {
+		return r.finish(ctx, m, false, fmt.Sprintf("%s %s not found", m.Spec.Target.Kind, m.Spec.Target.Name))
+	}
+	if err != nil {
+		metrics.RecordReconcileError("ChiaMaintenance", m.Spec.Target.Kind)
+		log.Error(err, fmt.Sprintf("ChiaMaintenanceController ChiaMaintenance=%s unable to fetch target", req.NamespacedName))
+		return ctrl.Result{}, err
+	}
+
+	if podConfig.Unsupported != "" {
+		return r.finish(ctx, m, false, podConfig.Unsupported)
+	}
+
+	err = validateReplica(m, target)
+	if err != nil {
+		return r.finish(ctx, m, false, err.Error())
+	}
+
+	// Scale the target's workload down before starting the task if it needs exclusive access to CHIA_ROOT
+	if needsExclusiveAccess(m) && m.Status.JobName == "" {
+		err = r.claimTarget(ctx, m, target)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaMaintenance", m.Spec.Target.Kind)
+			r.Recorder.Event(&m, corev1.EventTypeWarning, "Failed", "Failed to claim target for exclusive access -- Check operator logs.")
+			return ctrl.Result{RequeueAfter: scaleDownInterval}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error claiming target: %v", req.NamespacedName, err)
+		}
+
+		scaledDown, err := r.isTargetScaledDown(ctx, m, podConfig)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaMaintenance", m.Spec.Target.Kind)
+			return ctrl.Result{}, err
+		}
+		if !scaledDown {
+			m.Status.Phase = k8schianetv1.MaintenancePhaseScalingDown
+			m.Status.Message = fmt.Sprintf("Waiting for %s %s to scale down", m.Spec.Target.Kind, m.Spec.Target.Name)
+			err = r.Status().Update(ctx, &m)
+			if err != nil {
+				metrics.RecordReconcileError("ChiaMaintenance", "ChiaMaintenance")
+				log.Error(err, fmt.Sprintf("ChiaMaintenanceController ChiaMaintenance=%s unable to update ChiaMaintenance status", req.NamespacedName))
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: scaleDownInterval}, nil
+		}
+	}
+
+	// Create the Job if it doesn't exist yet, its pod template can not be changed once it's created
+	var job batchv1.Job
+	err = r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: fmt.Sprintf(chiamaintenanceNamePattern, m.Name)}, &job)
+	if err != nil && errors.IsNotFound(err) && m.Status.JobName != "" {
+		// The task was already started, don't run it a second time. Its pods may outlive the Job, so wait for them before releasing the target.
+		stopped, err := r.stopJob(ctx, m)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaMaintenance", "Job")
+			return ctrl.Result{}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error stopping maintenance Job: %v", req.NamespacedName, err)
+		}
+		if !stopped {
+			return ctrl.Result{RequeueAfter: scaleDownInterval}, nil
+		}
+		if m.Status.Message == jobDeadlineMessage {
+			return r.finish(ctx, m, false, jobDeadlineMessage)
+		}
+		return r.finish(ctx, m, false, fmt.Sprintf("Job %s was deleted before the task finished", m.Status.JobName))
+	} else if err != nil && errors.IsNotFound(err) {
+		job = r.assembleJob(ctx, m, podConfig, command)
+		res, err := kube.ReconcileJob(ctx, resourceReconciler, job)
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaMaintenance", "Job")
+			r.Recorder.Event(&m, corev1.EventTypeWarning, "Failed", "Failed to create maintenance Job -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error reconciling maintenance Job: %v", req.NamespacedName, err)
+		}
+		r.Recorder.Event(&m, corev1.EventTypeNormal, "Created", "Successfully created maintenance Job.")
+	} else if err != nil {
+		metrics.RecordReconcileError("ChiaMaintenance", "Job")
+		return ctrl.Result{}, err
+	}
+
+	finished, succeeded, deadlineExceeded := getJobOutcome(job)
+	if finished {
+		result, err := r.getJobResult(ctx, job)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaMaintenance", "Job")
+			return ctrl.Result{}, err
+		}
+		if result != "" {
+			m.Status.Result = result
+		}
+		if deadlineExceeded {
+			// The Job's pods may still be terminating, only give the target back once they're gone
+			if m.Status.Message != jobDeadlineMessage {
+				m.Status.Message = jobDeadlineMessage
+				err = r.Status().Update(ctx, &m)
+				if err != nil {
+					metrics.RecordReconcileError("ChiaMaintenance", "ChiaMaintenance")
+					log.Error(err, fmt.Sprintf("ChiaMaintenanceController ChiaMaintenance=%s unable to update ChiaMaintenance status", req.NamespacedName))
+					return ctrl.Result{}, err
+				}
+			}
+			stopped, err := r.stopJob(ctx, m)
+			if err != nil {
+				metrics.RecordReconcileError("ChiaMaintenance", "Job")
+				return ctrl.Result{}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s encountered error stopping maintenance Job: %v", req.NamespacedName, err)
+			}
+			if !stopped {
+				return ctrl.Result{RequeueAfter: scaleDownInterval}, nil
+			}
+			return r.finish(ctx, m, false, jobDeadlineMessage)
+		}
+		if succeeded {
+			return r.finish(ctx, m, true, "Task completed successfully")
+		}
+		return r.finish(ctx, m, false, "Task failed -- Check the result or the Job's logs")
+	}
+
+	// Update CR status
+	if m.Status.Phase != k8schianetv1.MaintenancePhaseRunning {
+		now := metav1.Now()
+		m.Status.Phase = k8schianetv1.MaintenancePhaseRunning
+		m.Status.JobName = job.Name
+		m.Status.StartTime = &now
+		m.Status.Message = "Task is running"
+		err = r.Status().Update(ctx, &m)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaMaintenance", "ChiaMaintenance")
+			log.Error(err, fmt.Sprintf("ChiaMaintenanceController ChiaMaintenance=%s unable to update ChiaMaintenance status", req.NamespacedName))
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: jobPollInterval}, nil
+}
+
+// finish releases the target and records the final phase of a ChiaMaintenance
+func (r *ChiaMaintenanceReconciler) finish(ctx context.Context, m k8schianetv1.ChiaMaintenance, succeeded bool, message string) (ctrl.Result, error) {
+	err := r.releaseTarget(ctx, m)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaMaintenance", m.Spec.Target.Kind)
+		return ctrl.Result{}, fmt.Errorf("ChiaMaintenanceController ChiaMaintenance=%s/%s encountered error releasing target: %v", m.Namespace, m.Name, err)
+	}
+
+	now := metav1.Now()
+	m.Status.CompletionTime = &now
+	m.Status.Message = message
+	if succeeded {
+		m.Status.Phase = k8schianetv1.MaintenancePhaseSucceeded
+		r.Recorder.Event(&m, corev1.EventTypeNormal, "Succeeded", message)
+	} else {
+		m.Status.Phase = k8schianetv1.MaintenancePhaseFailed
+		r.Recorder.Event(&m, corev1.EventTypeWarning, "Failed", message)
+	}
+
+	err = r.Status().Update(ctx, &m)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaMaintenance", "ChiaMaintenance")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ChiaMaintenanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&k8schianetv1.ChiaMaintenance{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
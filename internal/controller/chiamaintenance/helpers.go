@@ -0,0 +1,278 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiamaintenance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// getTarget fetches the ChiaMaintenance's target CR and its pod configuration. Returns the target, its pod config, a not found boolean, and error (if any).
+func (r *ChiaMaintenanceReconciler) getTarget(ctx context.Context, m k8schianetv1.ChiaMaintenance) (client.Object, kube.ChiaPodConfig, bool, error) {
+	key := types.NamespacedName{
+		Namespace: m.Namespace,
+		Name:      m.Spec.Target.Name,
+	}
+
+	var target client.Object
+	var podConfig kube.ChiaPodConfig
+	var err error
+	switch m.Spec.Target.Kind {
+	case "ChiaNode":
+		var node k8schianetv1.ChiaNode
+		err = r.Get(ctx, key, &node)
+		if err != nil {
+			break
+		}
+		var replica int32 = 0
+		if m.Spec.Target.Replica != nil {
+			replica = *m.Spec.Target.Replica
+		}
+		target = &node
+		podConfig, err = r.NodePodConfig(ctx, node, replica)
+	case "ChiaHarvester":
+		var harvester k8schianetv1.ChiaHarvester
+		err = r.Get(ctx, key, &harvester)
+		target, podConfig = &harvester, r.HarvesterPodConfig(ctx, harvester)
+	case "ChiaWallet":
+		var wallet k8schianetv1.ChiaWallet
+		err = r.Get(ctx, key, &wallet)
+		target, podConfig = &wallet, r.WalletPodConfig(ctx, wallet)
+	default:
+		return nil, podConfig, false, fmt.Errorf("unsupported target kind %s", m.Spec.Target.Kind)
+	}
+	if err != nil && errors.IsNotFound(err) {
+		return nil, podConfig, true, nil
+	}
+	if err != nil {
+		return nil, podConfig, false, err
+	}
+
+	return target, podConfig, false, nil
+}
+
+// getTaskCommand gives the shell command that runs a ChiaMaintenance's task, or an error if the task can not run against the target's kind
+func getTaskCommand(m k8schianetv1.ChiaMaintenance) (string, error) {
+	var command string
+	switch m.Spec.Task {
+	case k8schianetv1.MaintenanceTaskDBUpgrade:
+		command = "chia db upgrade"
+	case k8schianetv1.MaintenanceTaskDBValidate:
+		command = "chia db validate"
+	case k8schianetv1.MaintenanceTaskPlotsCheck:
+		command = "chia plots check"
+	case k8schianetv1.MaintenanceTaskPrunePeers:
+		command = `rm -fv "${CHIA_ROOT}/db/peers.dat" "${CHIA_ROOT}/wallet/db/wallet_peers.dat"`
+	case k8schianetv1.MaintenanceTaskCustom:
+		if m.Spec.Command == "" {
+			return "", fmt.Errorf("custom tasks require a command")
+		}
+		command = m.Spec.Command
+	default:
+		return "", fmt.Errorf("unsupported task %s", m.Spec.Task)
+	}
+
+	switch m.Spec.Task {
+	case k8schianetv1.MaintenanceTaskDBUpgrade, k8schianetv1.MaintenanceTaskDBValidate:
+		if m.Spec.Target.Kind != "ChiaNode" {
+			return "", fmt.Errorf("task %s can only run against a ChiaNode", m.Spec.Task)
+		}
+	case k8schianetv1.MaintenanceTaskPlotsCheck:
+		if m.Spec.Target.Kind != "ChiaHarvester" {
+			return "", fmt.Errorf("task %s can only run against a ChiaHarvester", m.Spec.Task)
+		}
+	case k8schianetv1.MaintenanceTaskPrunePeers:
+		if m.Spec.Target.Kind == "ChiaHarvester" {
+			return "", fmt.Errorf("task %s can not run against a ChiaHarvester", m.Spec.Task)
+		}
+	}
+
+	for _, arg := range m.Spec.Args {
+		command += " " + shellQuote(arg)
+	}
+
+	return command, nil
+}
+
+// shellQuote quotes a string so it is passed to a shell command as a single argument
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// needsExclusiveAccess returns true if the target's workload should be scaled down while the task runs
+func needsExclusiveAccess(m k8schianetv1.ChiaMaintenance) bool {
+	if m.Spec.ExclusiveAccess != nil {
+		return *m.Spec.ExclusiveAccess
+	}
+	switch m.Spec.Task {
+	case k8schianetv1.MaintenanceTaskDBUpgrade, k8schianetv1.MaintenanceTaskDBValidate, k8schianetv1.MaintenanceTaskPrunePeers:
+		return true
+	}
+	return false
+}
+
+// claimTarget sets the maintenance annotation on the target, which makes its controller scale its workload to zero
+func (r *ChiaMaintenanceReconciler) claimTarget(ctx context.Context, m k8schianetv1.ChiaMaintenance, target client.Object) error {
+	annotations := target.GetAnnotations()
+	if owner, exists := annotations[consts.MaintenanceAnnotation]; exists {
+		if owner == m.Name {
+			return nil
+		}
+		return fmt.Errorf("%s %s is already claimed by ChiaMaintenance %s", m.Spec.Target.Kind, target.GetName(), owner)
+	}
+
+	patch := client.MergeFrom(target.DeepCopyObject().(client.Object))
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[consts.MaintenanceAnnotation] = m.Name
+	target.SetAnnotations(annotations)
+	return r.Patch(ctx, target, patch)
+}
+
+// releaseTarget removes the maintenance annotation from the target if this ChiaMaintenance set it, which restores its workload
+func (r *ChiaMaintenanceReconciler) releaseTarget(ctx context.Context, m k8schianetv1.ChiaMaintenance) error {
+	target, _, notFound, err := r.getTarget(ctx, m)
+	if notFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	annotations := target.GetAnnotations()
+	if annotations[consts.MaintenanceAnnotation] != m.Name {
+		return nil
+	}
+
+	patch := client.MergeFrom(target.DeepCopyObject().(client.Object))
+	delete(annotations, consts.MaintenanceAnnotation)
+	target.SetAnnotations(annotations)
+	return r.Patch(ctx, target, patch)
+}
+
+// isTargetScaledDown returns true once the target's workload has no pods left
+func (r *ChiaMaintenanceReconciler) isTargetScaledDown(ctx context.Context, m k8schianetv1.ChiaMaintenance, podConfig kube.ChiaPodConfig) (bool, error) {
+	key := types.NamespacedName{
+		Namespace: m.Namespace,
+		Name:      podConfig.WorkloadName,
+	}
+
+	var replicas int32
+	if podConfig.StatefulSet {
+		var stateful appsv1.StatefulSet
+		err := r.Get(ctx, key, &stateful)
+		if err != nil {
+			return errors.IsNotFound(err), client.IgnoreNotFound(err)
+		}
+		replicas = stateful.Status.Replicas
+	} else {
+		var deploy appsv1.Deployment
+		err := r.Get(ctx, key, &deploy)
+		if err != nil {
+			return errors.IsNotFound(err), client.IgnoreNotFound(err)
+		}
+		replicas = deploy.Status.Replicas
+	}
+
+	return replicas == 0, nil
+}
+
+// stopJob deletes a ChiaMaintenance's Job in the foreground, so the Job is only removed once its pods are.
+// Returns true once the Job and all of its pods are gone, which is when the task can no longer be using the target's volumes.
+func (r *ChiaMaintenanceReconciler) stopJob(ctx context.Context, m k8schianetv1.ChiaMaintenance) (bool, error) {
+	jobName := fmt.Sprintf(chiamaintenanceNamePattern, m.Name)
+	var job batchv1.Job
+	err := r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: jobName}, &job)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil {
+		if job.DeletionTimestamp.IsZero() {
+			err = r.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationForeground))
+			if err != nil && !errors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	var pods corev1.PodList
+	err = r.List(ctx, &pods, client.InNamespace(m.Namespace), client.MatchingLabels{"job-name": jobName})
+	if err != nil {
+		return false, err
+	}
+	return len(pods.Items) == 0, nil
+}
+
+// validateReplica returns an error if a ChiaMaintenance targets a ChiaNode replica that doesn't exist
+func validateReplica(m k8schianetv1.ChiaMaintenance, target client.Object) error {
+	node, ok := target.(*k8schianetv1.ChiaNode)
+	if !ok || m.Spec.Target.Replica == nil {
+		return nil
+	}
+	if *m.Spec.Target.Replica < 0 || *m.Spec.Target.Replica >= node.Spec.Replicas {
+		return fmt.Errorf("replica %d does not exist, ChiaNode %s has %d replicas", *m.Spec.Target.Replica, node.Name, node.Spec.Replicas)
+	}
+	return nil
+}
+
+// getJobOutcome determines whether a Job has finished. Returns finished, succeeded, and deadline exceeded booleans.
+func getJobOutcome(job batchv1.Job) (bool, bool, bool) {
+	if job.Status.Succeeded > 0 {
+		return true, true, false
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true, false, condition.Reason == "DeadlineExceeded"
+		}
+	}
+	return false, false, false
+}
+
+// getJobResult gives the termination message of the task container of a finished Job's pod
+func (r *ChiaMaintenanceReconciler) getJobResult(ctx context.Context, job batchv1.Job) (string, error) {
+	var pods corev1.PodList
+	err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "maintenance" && status.State.Terminated != nil {
+				return status.State.Terminated.Message, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// getOwnerReference gives the common owner reference spec for ChiaMaintenance related objects
+func (r *ChiaMaintenanceReconciler) getOwnerReference(ctx context.Context, m k8schianetv1.ChiaMaintenance) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			APIVersion: m.APIVersion,
+			Kind:       m.Kind,
+			Name:       m.Name,
+			UID:        m.UID,
+			Controller: &consts.ControllerOwner,
+		},
+	}
+}
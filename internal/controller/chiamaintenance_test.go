@@ -0,0 +1,63 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	apiv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+var _ = Describe("ChiaMaintenance controller", func() {
+	var (
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	Context("When creating ChiaMaintenance", func() {
+		It("should fail when its target does not exist", func() {
+			By("By creating a new ChiaMaintenance")
+			ctx := context.Background()
+			testMaintenance := &apiv1.ChiaMaintenance{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "k8s.chia.net/v1",
+					Kind:       "ChiaMaintenance",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-chiamaintenance",
+					Namespace: "default",
+				},
+				Spec: apiv1.ChiaMaintenanceSpec{
+					Target: apiv1.ChiaMaintenanceTarget{
+						Kind: "ChiaNode",
+						Name: "nonexistent-chianode",
+					},
+					Task: apiv1.MaintenanceTaskDBValidate,
+				},
+			}
+
+			// Create ChiaMaintenance
+			Expect(k8sClient.Create(ctx, testMaintenance)).Should(Succeed())
+
+			// Ensure the ChiaMaintenance fails without a target
+			lookupKey := types.NamespacedName{Name: testMaintenance.Name, Namespace: testMaintenance.Namespace}
+			createdChiaMaintenance := &apiv1.ChiaMaintenance{}
+			Eventually(func() apiv1.ChiaMaintenancePhase {
+				err := k8sClient.Get(ctx, lookupKey, createdChiaMaintenance)
+				if err != nil {
+					return ""
+				}
+				return createdChiaMaintenance.Status.Phase
+			}, timeout, interval).Should(Equal(apiv1.MaintenancePhaseFailed))
+		})
+	})
+})
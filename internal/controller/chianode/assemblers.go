@@ -10,7 +10,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -22,7 +24,7 @@ const chianodeNamePattern = "%s-node"
 
 // assembleBaseService assembles the main Service resource for a ChiaNode CR
 func (r *ChiaNodeReconciler) assembleBaseService(ctx context.Context, node k8schianetv1.ChiaNode) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chianodeNamePattern, node.Name),
 			Namespace:       node.Namespace,
@@ -55,12 +57,15 @@ func (r *ChiaNodeReconciler) assembleBaseService(ctx context.Context, node k8sch
 			Selector: kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, node.Spec.Service)
+	return srv
 }
 
 // assembleInternalService assembles the internal Service resource for a ChiaNode CR
 func (r *ChiaNodeReconciler) assembleInternalService(ctx context.Context, node k8schianetv1.ChiaNode) corev1.Service {
 	local := corev1.ServiceInternalTrafficPolicyLocal
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chianodeNamePattern, node.Name) + "-internal",
 			Namespace:       node.Namespace,
@@ -94,11 +99,14 @@ func (r *ChiaNodeReconciler) assembleInternalService(ctx context.Context, node k
 			Selector: kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, node.Spec.InternalService)
+	return srv
 }
 
 // assembleHeadlessService assembles the headless Service resource for a ChiaNode CR
 func (r *ChiaNodeReconciler) assembleHeadlessService(ctx context.Context, node k8schianetv1.ChiaNode) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chianodeNamePattern, node.Name) + "-headless",
 			Namespace:       node.Namespace,
@@ -132,11 +140,57 @@ func (r *ChiaNodeReconciler) assembleHeadlessService(ctx context.Context, node k
 			Selector: kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, node.Spec.HeadlessService)
+	return srv
+}
+
+// assembleSyncedService assembles the synced Service resource for a ChiaNode CR, which only selects replicas the operator has labeled as synced
+func (r *ChiaNodeReconciler) assembleSyncedService(ctx context.Context, node k8schianetv1.ChiaNode) corev1.Service {
+	selector := kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels)
+	selector[syncedLabel] = "true"
+
+	srv := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chianodeNamePattern, node.Name) + "-synced",
+			Namespace:       node.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
+			Annotations:     node.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, node),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceType("ClusterIP"),
+			Ports: []corev1.ServicePort{
+				{
+					Port:       consts.DaemonPort,
+					TargetPort: intstr.FromString("daemon"),
+					Protocol:   "TCP",
+					Name:       "daemon",
+				},
+				{
+					Port:       r.getFullNodePort(ctx, node),
+					TargetPort: intstr.FromString("peers"),
+					Protocol:   "TCP",
+					Name:       "peers",
+				},
+				{
+					Port:       consts.NodeRPCPort,
+					TargetPort: intstr.FromString("rpc"),
+					Protocol:   "TCP",
+					Name:       "rpc",
+				},
+			},
+			Selector: selector,
+		},
+	}
+
+	kube.ApplyServiceConfig(&srv, node.Spec.SyncedService)
+	return srv
 }
 
 // assembleChiaExporterService assembles the chia-exporter Service resource for a ChiaNode CR
 func (r *ChiaNodeReconciler) assembleChiaExporterService(ctx context.Context, node k8schianetv1.ChiaNode) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chianodeNamePattern, node.Name) + "-metrics",
 			Namespace:       node.Namespace,
@@ -157,11 +211,47 @@ func (r *ChiaNodeReconciler) assembleChiaExporterService(ctx context.Context, no
 			Selector: kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, node.Spec.ChiaExporterConfig.Service)
+	return srv
+}
+
+// assembleNetworkPolicy assembles the NetworkPolicy resource for a ChiaNode CR
+func (r *ChiaNodeReconciler) assembleNetworkPolicy(ctx context.Context, node k8schianetv1.ChiaNode) networkingv1.NetworkPolicy {
+	meta := metav1.ObjectMeta{
+		Name:            fmt.Sprintf(chianodeNamePattern, node.Name),
+		Namespace:       node.Namespace,
+		Labels:          kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
+		Annotations:     node.Spec.AdditionalMetadata.Annotations,
+		OwnerReferences: r.getOwnerReference(ctx, node),
+	}
+	return kube.AssembleNetworkPolicy(ctx, meta, kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta), node.Spec.NetworkPolicy, kube.NamedPort("peers", corev1.ProtocolTCP))
+}
+
+// assembleChiaExporterMonitor assembles the Prometheus Operator monitor for a ChiaNode CR's chia-exporter, or gives nil if it doesn't want one
+func (r *ChiaNodeReconciler) assembleChiaExporterMonitor(ctx context.Context, node k8schianetv1.ChiaNode) (*unstructured.Unstructured, error) {
+	return kube.AssembleChiaExporterMonitor(ctx, node.Kind, node.ObjectMeta, fmt.Sprintf(chianodeNamePattern, node.Name), node.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, node), node.Spec.ChiaExporterConfig)
+}
+
+// assemblePrometheusRule assembles the PrometheusRule with a ChiaNode CR's alerts, or gives nil if it doesn't want one.
+// The node alerts when one of its full_nodes isn't synced.
+func (r *ChiaNodeReconciler) assemblePrometheusRule(ctx context.Context, node k8schianetv1.ChiaNode) *unstructured.Unstructured {
+	return kube.AssemblePrometheusRule(ctx, node.Kind, node.ObjectMeta, fmt.Sprintf(chianodeNamePattern, node.Name), node.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, node), node.Spec.Alerting,
+		kube.AlertingRule{
+			Alert:   "ChiaNodeNotSynced",
+			Expr:    fmt.Sprintf("chia_blockchain_sync_status_synced{%s} == 0", kube.GetMetricSelector(ctx, node.Kind, node.ObjectMeta)),
+			Summary: fmt.Sprintf("A full_node of ChiaNode %s/%s is not synced", node.Namespace, node.Name),
+		},
+	)
 }
 
 // assembleStatefulset assembles the node StatefulSet resource for a ChiaNode CR
-func (r *ChiaNodeReconciler) assembleStatefulset(ctx context.Context, node k8schianetv1.ChiaNode) appsv1.StatefulSet {
-	vols, volClaimTemplates := r.getChiaVolumesAndTemplates(ctx, node)
+func (r *ChiaNodeReconciler) assembleStatefulset(ctx context.Context, node k8schianetv1.ChiaNode) (appsv1.StatefulSet, error) {
+	vols, volClaimTemplates, err := r.getChiaVolumesAndTemplates(ctx, node)
+	if err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+	vols = append(vols, getPeeringVolumes(node)...)
 
 	var stateful appsv1.StatefulSet = appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -184,11 +274,13 @@ func (r *ChiaNodeReconciler) assembleStatefulset(ctx context.Context, node k8sch
 				},
 				Spec: corev1.PodSpec{
 					// TODO add: imagePullSecret, serviceAccountName config
+					InitContainers: append(r.getDataSourceInitContainers(ctx, node), r.getPeeringInitContainers(ctx, node)...),
 					Containers: []corev1.Container{
 						{
 							Name:            "chia",
 							Image:           node.Spec.ChiaConfig.Image,
 							ImagePullPolicy: node.Spec.ImagePullPolicy,
+							Lifecycle:       kube.GetChiaLifecycle(ctx),
 							Env:             r.getChiaNodeEnv(ctx, node),
 							Ports: []corev1.ContainerPort{
 								{
@@ -210,8 +302,9 @@ func (r *ChiaNodeReconciler) assembleStatefulset(ctx context.Context, node k8sch
 							VolumeMounts: r.getChiaVolumeMounts(ctx, node),
 						},
 					},
-					NodeSelector: node.Spec.NodeSelector,
-					Volumes:      vols,
+					NodeSelector:                  node.Spec.NodeSelector,
+					TerminationGracePeriodSeconds: kube.GetTerminationGracePeriodSeconds(ctx, node.Spec.TerminationGracePeriodSeconds, consts.NodeTerminationGracePeriodSeconds),
+					Volumes:                       vols,
 				},
 			},
 			VolumeClaimTemplates: volClaimTemplates,
@@ -236,14 +329,12 @@ func (r *ChiaNodeReconciler) assembleStatefulset(ctx context.Context, node k8sch
 		stateful.Spec.Template.Spec.Containers[0].StartupProbe = node.Spec.ChiaConfig.StartupProbe
 	}
 
-	var containerResorces corev1.ResourceRequirements
 	if node.Spec.ChiaConfig.Resources != nil {
-		containerResorces = *node.Spec.ChiaConfig.Resources
 		stateful.Spec.Template.Spec.Containers[0].Resources = *node.Spec.ChiaConfig.Resources
 	}
 
 	if node.Spec.ChiaExporterConfig.Enabled {
-		exporterContainer := kube.GetChiaExporterContainer(ctx, node.Spec.ChiaExporterConfig.Image, containerSecurityContext, node.Spec.ImagePullPolicy, containerResorces)
+		exporterContainer := kube.GetChiaExporterContainer(ctx, node.Spec.ChiaExporterConfig, containerSecurityContext, node.Spec.ImagePullPolicy)
 		stateful.Spec.Template.Spec.Containers = append(stateful.Spec.Template.Spec.Containers, exporterContainer)
 	}
 
@@ -255,7 +346,16 @@ func (r *ChiaNodeReconciler) assembleStatefulset(ctx context.Context, node k8sch
 		stateful.Spec.Template.Spec.Containers = append(stateful.Spec.Template.Spec.Containers, node.Spec.Sidecars.Containers...)
 	}
 
+	// Scale down while a quiesced backup stops a replica, and to zero while a ChiaMaintenance has exclusive access to this StatefulSet's volumes
+	if replicas, quiesced := getBackupQuiescedReplicas(node); quiesced {
+		stateful.Spec.Replicas = &replicas
+	}
+	if kube.IsPausedForMaintenance(node.ObjectMeta) {
+		var zero int32 = 0
+		stateful.Spec.Replicas = &zero
+	}
+
 	// TODO add pod affinity, tolerations
 
-	return stateful
+	return stateful, nil
 }
@@ -0,0 +1,271 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// backupLabel is set on VolumeSnapshots with the name of the ChiaNode they back up
+	backupLabel = "k8s.chia.net/backup-of"
+
+	// backupReplicaLabel is set on VolumeSnapshots with the StatefulSet ordinal of the volume claim they back up
+	backupReplicaLabel = "k8s.chia.net/backup-replica"
+
+	// defaultBackupMaxCount is the number of snapshots kept for each replica when a ChiaNode does not specify one
+	defaultBackupMaxCount int32 = 3
+
+	// backupPollInterval is how often a ChiaNode is requeued while a backup is being taken
+	backupPollInterval = 10 * time.Second
+)
+
+// getBackupQuiescedReplicas gives the number of replicas the node's StatefulSet is scaled down to while a quiesced backup stops one of its replicas.
+// Returns false if no replica is stopped for a backup.
+func getBackupQuiescedReplicas(node k8schianetv1.ChiaNode) (int32, bool) {
+	if node.Spec.Backup == nil || !node.Spec.Backup.Quiesce || node.Status.Backup == nil || !node.Status.Backup.Active || node.Status.Backup.QuiescedReplica == nil {
+		return 0, false
+	}
+	return *node.Status.Backup.QuiescedReplica, true
+}
+
+// reconcileBackups takes scheduled VolumeSnapshots of each replica's database volume claim, prunes old snapshots, and
+// records the most recent successful snapshots in the ChiaNode's status. Returns the time until the node should be reconciled again.
+func (r *ChiaNodeReconciler) reconcileBackups(ctx context.Context, node *k8schianetv1.ChiaNode) (time.Duration, error) {
+	if node.Spec.Backup == nil || !r.VolumeSnapshotsEnabled {
+		node.Status.Backup = nil
+		return 0, nil
+	}
+	if getDatabaseClaimTemplateName(*node) == "" {
+		return 0, fmt.Errorf("backups require the blockchain database to be stored on a persistentVolumeClaim CHIA_ROOT or database volume")
+	}
+
+	schedule, err := cron.ParseStandard(node.Spec.Backup.Schedule)
+	if err != nil {
+		return 0, fmt.Errorf("invalid backup schedule %q: %v", node.Spec.Backup.Schedule, err)
+	}
+
+	if node.Status.Backup == nil {
+		node.Status.Backup = &k8schianetv1.ChiaNodeBackupStatus{}
+	}
+	status := node.Status.Backup
+	now := time.Now()
+
+	// Start a backup if a scheduled time has passed, skipping any runs that were missed while the operator was down
+	if !status.Active {
+		scheduled := schedule.Next(lastScheduleTime(*node))
+		if !scheduled.After(now) {
+			for next := schedule.Next(scheduled); !next.After(now); next = schedule.Next(next) {
+				scheduled = next
+			}
+			status.Active = true
+			status.LastScheduleTime = &metav1.Time{Time: scheduled}
+		}
+	}
+
+	if status.Active {
+		done, err := r.takeSnapshots(ctx, node)
+		if err != nil {
+			return 0, err
+		}
+		if !done {
+			return backupPollInterval, nil
+		}
+		status.Active = false
+		status.QuiescedReplica = nil
+	}
+
+	err = r.pruneSnapshots(ctx, node)
+	if err != nil {
+		return 0, err
+	}
+
+	return schedule.Next(lastScheduleTime(*node)).Sub(now), nil
+}
+
+// lastScheduleTime gives the scheduled time of the node's most recent backup, or its creation time if it hasn't been backed up yet
+func lastScheduleTime(node k8schianetv1.ChiaNode) time.Time {
+	if node.Status.Backup != nil && node.Status.Backup.LastScheduleTime != nil {
+		return node.Status.Backup.LastScheduleTime.Time
+	}
+	return node.CreationTimestamp.Time
+}
+
+// takeSnapshots creates the VolumeSnapshots for the active backup. Returns true once every snapshot has been cut.
+// Quiesced backups stop and snapshot one replica at a time, from the highest ordinal down, since a StatefulSet can only scale down
+// its highest ordinals. Replicas above the one being snapshotted stay stopped until the backup finishes, and the lower ones keep running.
+func (r *ChiaNodeReconciler) takeSnapshots(ctx context.Context, node *k8schianetv1.ChiaNode) (bool, error) {
+	stsName := fmt.Sprintf(chianodeNamePattern, node.Name)
+	quiesce := node.Spec.Backup.Quiesce
+
+	// Without a StatefulSet there are no volume claims to back up yet, so this run is finished without any snapshots
+	var stateful appsv1.StatefulSet
+	err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: stsName}, &stateful)
+	if err != nil && errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	done := true
+	for replica := node.Spec.Replicas - 1; replica >= 0; replica-- {
+		snapshot := r.assembleVolumeSnapshot(ctx, *node, replica)
+		err := r.Get(ctx, client.ObjectKeyFromObject(&snapshot), &snapshot)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil {
+			// The snapshot has been cut from the volume once it has a creation time, it may still be uploading
+			_, cut, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime")
+			if cut {
+				continue
+			}
+			if quiesce {
+				return false, nil
+			}
+			done = false
+			continue
+		}
+
+		// Stop the replica and wait for its pod to be gone before taking a quiesced snapshot
+		if quiesce {
+			if node.Status.Backup.QuiescedReplica == nil || *node.Status.Backup.QuiescedReplica != replica {
+				node.Status.Backup.QuiescedReplica = &replica
+				return false, nil
+			}
+			var pod corev1.Pod
+			err = r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: fmt.Sprintf("%s-%d", stsName, replica)}, &pod)
+			if err == nil {
+				return false, nil
+			}
+			if !errors.IsNotFound(err) {
+				return false, err
+			}
+		}
+
+		err = r.Create(ctx, &snapshot)
+		if err != nil {
+			return false, err
+		}
+		if quiesce {
+			return false, nil
+		}
+		done = false
+	}
+
+	return done, nil
+}
+
+// assembleVolumeSnapshot assembles the VolumeSnapshot of a replica's database volume claim for the active backup, which is its
+// dedicated database volume claim if it has one, or otherwise its CHIA_ROOT volume claim.
+// Snapshots have no owner reference so backups outlive the ChiaNode.
+func (r *ChiaNodeReconciler) assembleVolumeSnapshot(ctx context.Context, node k8schianetv1.ChiaNode, replica int32) unstructured.Unstructured {
+	stsName := fmt.Sprintf(chianodeNamePattern, node.Name)
+
+	var snapshot unstructured.Unstructured
+	snapshot.SetGroupVersionKind(kube.VolumeSnapshotGVK)
+	snapshot.SetName(fmt.Sprintf("%s-%d-%s", stsName, replica, node.Status.Backup.LastScheduleTime.UTC().Format("20060102-150405")))
+	snapshot.SetNamespace(node.Namespace)
+	snapshot.SetLabels(map[string]string{
+		"app.kubernetes.io/managed-by": "chia-operator",
+		backupLabel:                    node.Name,
+		backupReplicaLabel:             strconv.Itoa(int(replica)),
+	})
+
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": fmt.Sprintf("%s-%s-%d", getDatabaseClaimTemplateName(node), stsName, replica),
+		},
+	}
+	if node.Spec.Backup.VolumeSnapshotClassName != nil {
+		spec["volumeSnapshotClassName"] = *node.Spec.Backup.VolumeSnapshotClassName
+	}
+	snapshot.Object["spec"] = spec
+
+	return snapshot
+}
+
+// pruneSnapshots deletes each replica's snapshots beyond the backup's retention, and records the most recent successful snapshots in status
+func (r *ChiaNodeReconciler) pruneSnapshots(ctx context.Context, node *k8schianetv1.ChiaNode) error {
+	var snapshots unstructured.UnstructuredList
+	snapshots.SetGroupVersionKind(kube.VolumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"))
+	err := r.List(ctx, &snapshots, client.InNamespace(node.Namespace), client.MatchingLabels{backupLabel: node.Name})
+	if err != nil {
+		return err
+	}
+
+	maxCount := defaultBackupMaxCount
+	if node.Spec.Backup.MaxCount != nil {
+		maxCount = *node.Spec.Backup.MaxCount
+	}
+
+	// Group snapshots by replica, newest first
+	byReplica := make(map[string][]unstructured.Unstructured)
+	for _, snapshot := range snapshots.Items {
+		replica := snapshot.GetLabels()[backupReplicaLabel]
+		byReplica[replica] = append(byReplica[replica], snapshot)
+	}
+
+	var lastSuccessful []string
+	var lastSuccessfulTime *metav1.Time
+	for _, replicaSnapshots := range byReplica {
+		sort.Slice(replicaSnapshots, func(i, j int) bool {
+			iCreated := replicaSnapshots[i].GetCreationTimestamp()
+			jCreated := replicaSnapshots[j].GetCreationTimestamp()
+			return jCreated.Before(&iCreated)
+		})
+
+		// Only snapshots that are ready to use count toward the retention, so failed or in-progress snapshots don't push out good ones.
+		// Snapshots that aren't ready are deleted once a newer snapshot of the replica is ready.
+		var readyCount int32
+		for i, snapshot := range replicaSnapshots {
+			ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+			if ready {
+				readyCount++
+			}
+			if ready && readyCount == 1 {
+				// Always keep the most recent successful snapshot
+				lastSuccessful = append(lastSuccessful, snapshot.GetName())
+				created := snapshot.GetCreationTimestamp()
+				if lastSuccessfulTime == nil || lastSuccessfulTime.Before(&created) {
+					lastSuccessfulTime = &created
+				}
+				continue
+			}
+
+			expired := node.Spec.Backup.MaxAge != nil && time.Since(snapshot.GetCreationTimestamp().Time) > node.Spec.Backup.MaxAge.Duration
+			superseded := !ready && readyCount > 0
+			if readyCount > maxCount || expired || superseded {
+				err = r.Delete(ctx, &replicaSnapshots[i])
+				if client.IgnoreNotFound(err) != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	sort.Strings(lastSuccessful)
+	node.Status.Backup.LastSuccessfulSnapshots = lastSuccessful
+	node.Status.Backup.LastSuccessfulTime = lastSuccessfulTime
+
+	return nil
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// newBackupScheme gives a scheme with the built-in types and VolumeSnapshots, which the fake client needs to store snapshots
+func newBackupScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	scheme.AddKnownTypeWithName(kube.VolumeSnapshotGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(kube.VolumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"), &unstructured.UnstructuredList{})
+	return scheme
+}
+
+// newBackupNode gives a ChiaNode with a CHIA_ROOT volume claim and the given backup config, in the middle of a backup scheduled at the given time
+func newBackupNode(replicas int32, backup *k8schianetv1.ChiaNodeBackup, scheduled time.Time) k8schianetv1.ChiaNode {
+	node := newSyncNode()
+	node.Spec.Replicas = replicas
+	node.Spec.Storage = &k8schianetv1.DatabaseStorageConfig{
+		StorageConfig: k8schianetv1.StorageConfig{
+			ChiaRoot: &k8schianetv1.ChiaRootConfig{
+				PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "300Gi"},
+			},
+		},
+	}
+	node.Spec.Backup = backup
+	node.Status.Backup = &k8schianetv1.ChiaNodeBackupStatus{
+		Active:           true,
+		LastScheduleTime: &metav1.Time{Time: scheduled},
+	}
+	return node
+}
+
+// newSnapshot gives a VolumeSnapshot of a node's replica, created the given time ago
+func newSnapshot(name string, replica int, age time.Duration, cut, ready bool) *unstructured.Unstructured {
+	var snapshot unstructured.Unstructured
+	snapshot.SetGroupVersionKind(kube.VolumeSnapshotGVK)
+	snapshot.SetName(name)
+	snapshot.SetNamespace("chia")
+	snapshot.SetCreationTimestamp(metav1.Time{Time: time.Now().Add(-age).Truncate(time.Second)})
+	snapshot.SetLabels(map[string]string{
+		backupLabel:        "node",
+		backupReplicaLabel: fmt.Sprint(replica),
+	})
+	status := map[string]interface{}{"readyToUse": ready}
+	if cut {
+		status["creationTime"] = "2023-01-01T00:00:00Z"
+	}
+	snapshot.Object["status"] = status
+	return &snapshot
+}
+
+// listSnapshotNames gives the names of the VolumeSnapshots in the fake client
+func listSnapshotNames(t *testing.T, c client.Client) []string {
+	var snapshots unstructured.UnstructuredList
+	snapshots.SetGroupVersionKind(kube.VolumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"))
+	if err := c.List(context.Background(), &snapshots); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, snapshot := range snapshots.Items {
+		names = append(names, snapshot.GetName())
+	}
+	return names
+}
+
+func TestGetBackupQuiescedReplicas(t *testing.T) {
+	replica := int32(1)
+	tests := []struct {
+		name             string
+		quiesce          bool
+		status           *k8schianetv1.ChiaNodeBackupStatus
+		expectedReplicas int32
+		expectedStopped  bool
+	}{
+		{
+			name:    "no backup running",
+			quiesce: true,
+		},
+		{
+			name:    "backup not quiesced",
+			quiesce: false,
+			status:  &k8schianetv1.ChiaNodeBackupStatus{Active: true, QuiescedReplica: &replica},
+		},
+		{
+			name:    "quiesced backup waiting to stop a replica",
+			quiesce: true,
+			status:  &k8schianetv1.ChiaNodeBackupStatus{Active: true},
+		},
+		{
+			name:             "quiesced backup stopping a replica",
+			quiesce:          true,
+			status:           &k8schianetv1.ChiaNodeBackupStatus{Active: true, QuiescedReplica: &replica},
+			expectedReplicas: 1,
+			expectedStopped:  true,
+		},
+		{
+			name:    "finished backup",
+			quiesce: true,
+			status:  &k8schianetv1.ChiaNodeBackupStatus{QuiescedReplica: &replica},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.Backup = &k8schianetv1.ChiaNodeBackup{Schedule: "@daily", Quiesce: tt.quiesce}
+			node.Status.Backup = tt.status
+
+			replicas, stopped := getBackupQuiescedReplicas(node)
+			if replicas != tt.expectedReplicas || stopped != tt.expectedStopped {
+				t.Errorf("Expected (%d, %t), got (%d, %t)", tt.expectedReplicas, tt.expectedStopped, replicas, stopped)
+			}
+		})
+	}
+}
+
+func TestAssembleVolumeSnapshot(t *testing.T) {
+	scheduled := time.Date(2023, 6, 1, 4, 30, 0, 0, time.UTC)
+	className := "csi-snapclass"
+	tests := []struct {
+		name     string
+		database bool
+		class    *string
+		expected map[string]interface{}
+	}{
+		{
+			name: "chiaroot claim",
+			expected: map[string]interface{}{
+				"source": map[string]interface{}{"persistentVolumeClaimName": "chiaroot-node-node-1"},
+			},
+		},
+		{
+			name:     "database claim with snapshot class",
+			database: true,
+			class:    &className,
+			expected: map[string]interface{}{
+				"source":                  map[string]interface{}{"persistentVolumeClaimName": "chiadb-node-node-1"},
+				"volumeSnapshotClassName": "csi-snapclass",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newBackupNode(2, &k8schianetv1.ChiaNodeBackup{Schedule: "@daily", VolumeSnapshotClassName: tt.class}, scheduled)
+			if tt.database {
+				node.Spec.Storage.Database = &k8schianetv1.DatabaseConfig{
+					PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "300Gi"},
+				}
+			}
+			r := &ChiaNodeReconciler{}
+
+			snapshot := r.assembleVolumeSnapshot(context.Background(), node, 1)
+			if snapshot.GetName() != "node-node-1-20230601-043000" {
+				t.Errorf("Unexpected snapshot name %s", snapshot.GetName())
+			}
+			if diff := cmp.Diff(tt.expected, snapshot.Object["spec"]); diff != "" {
+				t.Errorf("Snapshot spec does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestTakeSnapshots(t *testing.T) {
+	scheduled := time.Date(2023, 6, 1, 4, 30, 0, 0, time.UTC)
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "node-node", Namespace: "chia"}}
+	one := int32(1)
+	tests := []struct {
+		name              string
+		quiesce           bool
+		quiescedReplica   *int32
+		objects           []client.Object
+		expectedDone      bool
+		expectedSnapshots []string
+		expectedQuiesced  *int32
+	}{
+		{
+			name:         "no statefulset",
+			expectedDone: true,
+		},
+		{
+			name:              "snapshots every replica at once",
+			objects:           []client.Object{statefulSet},
+			expectedSnapshots: []string{"node-node-0-20230601-043000", "node-node-1-20230601-043000"},
+		},
+		{
+			name: "waits for snapshots to be cut",
+			objects: []client.Object{
+				statefulSet,
+				newSnapshot("node-node-0-20230601-043000", 0, time.Minute, false, false),
+				newSnapshot("node-node-1-20230601-043000", 1, time.Minute, true, false),
+			},
+			expectedSnapshots: []string{"node-node-0-20230601-043000", "node-node-1-20230601-043000"},
+		},
+		{
+			name: "done once every snapshot is cut",
+			objects: []client.Object{
+				statefulSet,
+				newSnapshot("node-node-0-20230601-043000", 0, time.Minute, true, false),
+				newSnapshot("node-node-1-20230601-043000", 1, time.Minute, true, true),
+			},
+			expectedDone:      true,
+			expectedSnapshots: []string{"node-node-0-20230601-043000", "node-node-1-20230601-043000"},
+		},
+		{
+			name:             "quiesce stops the highest replica first",
+			quiesce:          true,
+			objects:          []client.Object{statefulSet},
+			expectedQuiesced: &one,
+		},
+		{
+			name:              "quiesce snapshots a stopped replica",
+			quiesce:           true,
+			quiescedReplica:   &one,
+			objects:           []client.Object{statefulSet},
+			expectedSnapshots: []string{"node-node-1-20230601-043000"},
+			expectedQuiesced:  &one,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newBackupNode(2, &k8schianetv1.ChiaNodeBackup{Schedule: "@daily", Quiesce: tt.quiesce}, scheduled)
+			node.Status.Backup.QuiescedReplica = tt.quiescedReplica
+			r := &ChiaNodeReconciler{
+				Client: fake.NewClientBuilder().WithScheme(newBackupScheme(t)).WithObjects(tt.objects...).Build(),
+			}
+
+			done, err := r.takeSnapshots(context.Background(), &node)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if done != tt.expectedDone {
+				t.Errorf("Expected done to be %t, got %t", tt.expectedDone, done)
+			}
+			if diff := cmp.Diff(tt.expectedSnapshots, listSnapshotNames(t, r.Client)); diff != "" {
+				t.Errorf("Snapshots do not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedQuiesced, node.Status.Backup.QuiescedReplica); diff != "" {
+				t.Errorf("Quiesced replica does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	maxCount := int32(2)
+	tests := []struct {
+		name                   string
+		backup                 *k8schianetv1.ChiaNodeBackup
+		snapshots              []client.Object
+		expectedSnapshots      []string
+		expectedLastSuccessful []string
+	}{
+		{
+			name:   "no snapshots",
+			backup: &k8schianetv1.ChiaNodeBackup{Schedule: "@daily"},
+		},
+		{
+			name:   "keeps max count ready snapshots per replica",
+			backup: &k8schianetv1.ChiaNodeBackup{Schedule: "@daily", MaxCount: &maxCount},
+			snapshots: []client.Object{
+				newSnapshot("r0-a", 0, 3*time.Hour, true, true),
+				newSnapshot("r0-b", 0, 2*time.Hour, true, true),
+				newSnapshot("r0-c", 0, time.Hour, true, true),
+				newSnapshot("r1-a", 1, 3*time.Hour, true, true),
+			},
+			expectedSnapshots:      []string{"r0-b", "r0-c", "r1-a"},
+			expectedLastSuccessful: []string{"r0-c", "r1-a"},
+		},
+		{
+			name:   "deletes snapshots superseded by a newer ready snapshot",
+			backup: &k8schianetv1.ChiaNodeBackup{Schedule: "@daily"},
+			snapshots: []client.Object{
+				newSnapshot("r0-failed", 0, 2*time.Hour, true, false),
+				newSnapshot("r0-ready", 0, time.Hour, true, true),
+				newSnapshot("r0-uploading", 0, time.Minute, true, false),
+			},
+			expectedSnapshots:      []string{"r0-ready", "r0-uploading"},
+			expectedLastSuccessful: []string{"r0-ready"},
+		},
+		{
+			name:   "expires old snapshots but keeps the last successful",
+			backup: &k8schianetv1.ChiaNodeBackup{Schedule: "@daily", MaxAge: &metav1.Duration{Duration: 24 * time.Hour}},
+			snapshots: []client.Object{
+				newSnapshot("r0-old", 0, 72*time.Hour, true, true),
+				newSnapshot("r0-newest", 0, 48*time.Hour, true, true),
+			},
+			expectedSnapshots:      []string{"r0-newest"},
+			expectedLastSuccessful: []string{"r0-newest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newBackupNode(2, tt.backup, time.Now())
+			r := &ChiaNodeReconciler{
+				Client: fake.NewClientBuilder().WithScheme(newBackupScheme(t)).WithObjects(tt.snapshots...).Build(),
+			}
+
+			err := r.pruneSnapshots(context.Background(), &node)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedSnapshots, listSnapshotNames(t, r.Client)); diff != "" {
+				t.Errorf("Snapshots do not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedLastSuccessful, node.Status.Backup.LastSuccessfulSnapshots); diff != "" {
+				t.Errorf("Last successful snapshots do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
@@ -7,10 +7,15 @@ package chianode
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -18,6 +23,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	"github.com/cisco-open/operator-tools/pkg/reconciler"
@@ -28,20 +35,48 @@ type ChiaNodeReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// VolumeSnapshotsEnabled is set when the cluster serves the snapshot.storage.k8s.io API, which backups require
+	VolumeSnapshotsEnabled bool
+
+	// PrometheusOperator says which Prometheus Operator kinds the cluster serves. chia-exporter monitors and alerting rules require their kind's CRD.
+	PrometheusOperator kube.PrometheusOperatorAPIs
+
+	// RPCOptions are applied to every replica RPC client, like to route the sync checks to a fake RPC server in tests
+	RPCOptions []chiarpc.Option
+
+	// rpcClients reuses replica RPC clients across the periodic sync checks
+	rpcClients chiarpc.ClientCache
 }
 
-var chianodes map[string]bool = make(map[string]bool)
+const (
+	// volumePollInterval is how often a ChiaNode is requeued while its StatefulSet is recreated or its volume claims are resized
+	volumePollInterval = 10 * time.Second
+
+	// syncCheckInterval is how often a ChiaNode is requeued to refresh its replicas' sync state
+	syncCheckInterval = 30 * time.Second
+)
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes/finalizers,verbs=update
-//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *ChiaNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaNode", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s", req.NamespacedName.String()))
@@ -50,99 +85,304 @@ func (r *ChiaNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	var node k8schianetv1.ChiaNode
 	err := r.Get(ctx, req.NamespacedName, &node)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chianodes[req.NamespacedName.String()]
-		if exists {
-			delete(chianodes, req.NamespacedName.String())
-			metrics.ChiaNodes.Sub(1.0)
+		// chia-exporter monitors and PrometheusRules in other namespaces aren't garbage collected with the ChiaNode
+		err = kube.RemoveChiaExporterMonitors(ctx, r.Client, r.PrometheusOperator, kube.GetCommonLabels(ctx, "ChiaNode", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaNode", "Monitor")
+			return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error removing node chia-exporter monitors: %v", req.NamespacedName, err)
+		}
+		if r.PrometheusOperator.PrometheusRules {
+			err = kube.ReconcilePrometheusRule(ctx, r.Client, nil, kube.GetCommonLabels(ctx, "ChiaNode", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+			if err != nil {
+				metrics.RecordReconcileError("ChiaNode", "PrometheusRule")
+				return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error removing node PrometheusRules: %v", req.NamespacedName, err)
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaNode", "ChiaNode")
 		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to fetch ChiaNode resource", req.NamespacedName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	previousStatus := node.Status.DeepCopy()
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chianodes[req.NamespacedName.String()]
-	if !exists {
-		chianodes[req.NamespacedName.String()] = true
-		metrics.ChiaNodes.Add(1.0)
+	// Volume claim templates are assembled from the storage config, so reject invalid ones up front
+	_, _, err = r.getChiaVolumesAndTemplates(ctx, node)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaNode", "ChiaNode")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "InvalidStorageConfig", fmt.Sprintf("Invalid storage configuration: %v", err))
+		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s has an invalid storage configuration: %v", req.NamespacedName, err)
 	}
 
 	// Reconcile ChiaNode owned objects
 	srv := r.assembleBaseService(ctx, node)
-	res, err := kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err := kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, node.Spec.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaNode", "Service")
 		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node Service: %v", req.NamespacedName, err)
 	}
 
 	srv = r.assembleInternalService(ctx, node)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, node.Spec.InternalService)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaNode", "Service")
 		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node internal Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node Local Service: %v", req.NamespacedName, err)
 	}
 
 	srv = r.assembleHeadlessService(ctx, node)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, node.Spec.HeadlessService)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaNode", "Service")
 		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node headless Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node headless Service: %v", req.NamespacedName, err)
 	}
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.HeadlessServiceDisabledCondition, !kube.ShouldMakeService(node.Spec.HeadlessService),
+		"The node headless Service is disabled, so replicas don't get DNS names and in-cluster peering between replicas won't resolve.")
+
+	srv = r.assembleSyncedService(ctx, node)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, node.Spec.SyncedService)
+	if err != nil {
+		if res == nil {
+			res = &reconcile.Result{}
+		}
+		metrics.RecordReconcileError("ChiaNode", "Service")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node synced Service -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node synced Service: %v", req.NamespacedName, err)
+	}
 
 	srv = r.assembleChiaExporterService(ctx, node)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, node.Spec.ChiaExporterConfig.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaNode", "Service")
 		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node metrics Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node chia-exporter Service: %v", req.NamespacedName, err)
 	}
 
-	stateful := r.assembleStatefulset(ctx, node)
-	res, err = kube.ReconcileStatefulset(ctx, resourceReconciler, stateful)
+	policy := r.assembleNetworkPolicy(ctx, node)
+	if node.Spec.NetworkPolicy.Enabled {
+		res, err = kube.ReconcileNetworkPolicy(ctx, resourceReconciler, policy)
+	} else {
+		res, err = kube.RemoveNetworkPolicy(ctx, resourceReconciler, policy)
+	}
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
-		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node Statefulset -- Check operator logs.")
-		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node StatefulSet: %v", req.NamespacedName, err)
+		metrics.RecordReconcileError("ChiaNode", "NetworkPolicy")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node NetworkPolicy -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node NetworkPolicy: %v", req.NamespacedName, err)
 	}
 
-	// Update CR status
-	r.Recorder.Event(&node, corev1.EventTypeNormal, "Created", "Successfully created ChiaNode resources.")
-	node.Status.Ready = true
-	err = r.Status().Update(ctx, &node)
+	// Scrape chia-exporter with a Prometheus Operator monitor if its CRD is installed
+	if len(r.PrometheusOperator.MonitorKinds()) > 0 {
+		monitor, err := r.assembleChiaExporterMonitor(ctx, node)
+		if err == nil {
+			err = kube.ReconcileChiaExporterMonitor(ctx, r.Client, r.PrometheusOperator, monitor, kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta))
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaNode", "Monitor")
+			r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node chia-exporter monitor -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node chia-exporter monitor: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.MonitorsDisabledCondition, !r.PrometheusOperator.ServesMonitor(node.Spec.ChiaExporterConfig.Monitor),
+		"A chia-exporter monitor is configured but its Prometheus Operator CRD was not found when the operator started.")
+
+	// Create the PrometheusRule with the node's alerts if the CRD is installed
+	if r.PrometheusOperator.PrometheusRules {
+		err = kube.ReconcilePrometheusRule(ctx, r.Client, r.assemblePrometheusRule(ctx, node), kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaNode", "PrometheusRule")
+			r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node PrometheusRule -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node PrometheusRule: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.AlertingDisabledCondition, node.Spec.Alerting != nil && !r.PrometheusOperator.PrometheusRules,
+		"Alerting is configured but the PrometheusRule CRD was not found when the operator started.")
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.AlertingWithoutMonitorCondition, node.Spec.Alerting != nil && (node.Spec.ChiaExporterConfig.Monitor == nil || !node.Spec.ChiaExporterConfig.Enabled),
+		"Alerting is configured without a chia-exporter monitor. Alerts select metrics by their k8s_chia_net_provenance label, which your own scrape config must add.")
+
+	// Write the in-cluster peer list to a ConfigMap the replicas read when they start, or remove it if peering isn't enabled
+	peering := r.assemblePeeringConfigMap(ctx, node)
+	if node.Spec.IntraClusterPeering != nil {
+		res, err = kube.ReconcileConfigMap(ctx, resourceReconciler, peering)
+	} else {
+		res, err = kube.RemoveConfigMap(ctx, resourceReconciler, peering)
+	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
-		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to update ChiaNode status", req.NamespacedName))
-		return ctrl.Result{}, err
+		if res == nil {
+			res = &reconcile.Result{}
+		}
+		metrics.RecordReconcileError("ChiaNode", "ConfigMap")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node peering ConfigMap -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node peering ConfigMap: %v", req.NamespacedName, err)
 	}
 
-	return ctrl.Result{}, nil
+	stateful, err := r.assembleStatefulset(ctx, node)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaNode", "StatefulSet")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to assemble node StatefulSet -- Check operator logs.")
+		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling node StatefulSet: %v", req.NamespacedName, err)
+	}
+	recreating, err := r.reconcileVolumeClaimTemplates(ctx, &node, &stateful)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaNode", "PersistentVolumeClaim")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to resize node volumes -- Check operator logs.")
+		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node volume claims: %v", req.NamespacedName, err)
+	}
+	if recreating {
+		// The StatefulSet is recreated with its new volume claim templates once the orphaning delete finishes
+		r.Recorder.Event(&node, corev1.EventTypeNormal, "Recreating", "Recreating node StatefulSet with updated volume claim templates, its pods are kept running.")
+	} else {
+		err = r.reconcileDataSourceClaims(ctx, node, stateful)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaNode", "PersistentVolumeClaim")
+			r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node volume claims from data source -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node data source volume claims: %v", req.NamespacedName, err)
+		}
+
+		res, err = kube.ReconcileStatefulset(ctx, resourceReconciler, stateful)
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaNode", "StatefulSet")
+			r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node Statefulset -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node StatefulSet: %v", req.NamespacedName, err)
+		}
+	}
+	// Expose each replica's peer port on its own Service, and remove peer Services for replicas that no longer exist
+	desiredPeerServices := make(map[string]bool)
+	node.Status.PeerServices = nil
+	if node.Spec.PeerServices != nil {
+		for ordinal := int32(0); ordinal < node.Spec.Replicas; ordinal++ {
+			srv = r.assemblePeerService(ctx, node, ordinal)
+			res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+			if err != nil {
+				if res == nil {
+					res = &reconcile.Result{}
+				}
+				metrics.RecordReconcileError("ChiaNode", "Service")
+				r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to create node peer Service -- Check operator logs.")
+				return *res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node peer Service %s: %v", req.NamespacedName, srv.Name, err)
+			}
+			desiredPeerServices[srv.Name] = true
+
+			var current corev1.Service
+			err = r.Get(ctx, types.NamespacedName{Namespace: srv.Namespace, Name: srv.Name}, &current)
+			if err != nil {
+				metrics.RecordReconcileError("ChiaNode", "Service")
+				return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error getting node peer Service %s: %v", req.NamespacedName, srv.Name, err)
+			}
+			node.Status.PeerServices = append(node.Status.PeerServices, getPeerServiceStatus(node, ordinal, current))
+		}
+	}
+	err = r.removeStalePeerServices(ctx, node, desiredPeerServices)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaNode", "Service")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to remove stale node peer Services -- Check operator logs.")
+		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error removing stale node peer Services: %v", req.NamespacedName, err)
+	}
+
+	// Warn about peer ChiaNodes that don't exist or have their synced Service disabled, since their synced Service won't resolve
+	var missingPeers, unreachablePeers []string
+	if node.Spec.IntraClusterPeering != nil {
+		for _, peer := range node.Spec.IntraClusterPeering.ChiaNodes {
+			peerName := fmt.Sprintf("%s/%s", getPeerNodeNamespace(node, peer), peer.Name)
+			var peerNode k8schianetv1.ChiaNode
+			err = r.Get(ctx, types.NamespacedName{Namespace: getPeerNodeNamespace(node, peer), Name: peer.Name}, &peerNode)
+			if errors.IsNotFound(err) {
+				missingPeers = append(missingPeers, peerName)
+			} else if err == nil && !kube.ShouldMakeService(peerNode.Spec.SyncedService) {
+				unreachablePeers = append(unreachablePeers, peerName)
+			} else if err != nil {
+				metrics.RecordReconcileError("ChiaNode", "ChiaNode")
+				return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error getting peer ChiaNode %s: %v", req.NamespacedName, peerName, err)
+			}
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.PeerNotFoundCondition, len(missingPeers) > 0,
+		fmt.Sprintf("Peer ChiaNodes %s were not found.", strings.Join(missingPeers, ", ")))
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.PeerSyncedServiceDisabledCondition, len(unreachablePeers) > 0,
+		fmt.Sprintf("Peer ChiaNodes %s have syncedService.enabled set to false, so they can't be reached as peers.", strings.Join(unreachablePeers, ", ")))
+
+	// Label replicas with their sync state so the synced Service only routes to synced replicas
+	syncedReplicas, err := r.reconcileSyncedLabels(ctx, node)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaNode", "Pod")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to label node replicas with their sync state -- Check operator logs.")
+		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error labeling node replicas with their sync state: %v", req.NamespacedName, err)
+	}
+	node.Status.SyncedReplicas = syncedReplicas
+
+	var unsupportedClaims []string
+	if node.Status.VolumeResize != nil {
+		for _, claim := range node.Status.VolumeResize.Claims {
+			if claim.State == k8schianetv1.VolumeResizeStateUnsupported {
+				unsupportedClaims = append(unsupportedClaims, claim.Name)
+			}
+		}
+	}
+	if kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.ResizeUnsupportedCondition, len(unsupportedClaims) > 0,
+		fmt.Sprintf("PersistentVolumeClaims %s can not be expanded because their StorageClass does not allow volume expansion. The StatefulSet keeps its current volume claim templates.", strings.Join(unsupportedClaims, ", "))) {
+		metrics.RecordReconcileError("ChiaNode", "PersistentVolumeClaim")
+		log.Error(fmt.Errorf("PersistentVolumeClaims %s can not be expanded", strings.Join(unsupportedClaims, ", ")), fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to resize node volume claims, their StorageClass does not allow volume expansion", req.NamespacedName))
+	}
+
+	kube.SetWarningCondition(r.Recorder, &node, &node.Status.Conditions, consts.BackupsDisabledCondition, node.Spec.Backup != nil && !r.VolumeSnapshotsEnabled,
+		"Backups are configured but the VolumeSnapshot CRDs were not found when the operator started.")
+	backupRequeue, err := r.reconcileBackups(ctx, &node)
+	if err != nil {
+		metrics.RecordReconcileError("ChiaNode", "VolumeSnapshot")
+		r.Recorder.Event(&node, corev1.EventTypeWarning, "Failed", "Failed to back up node volumes -- Check operator logs.")
+		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error reconciling node VolumeSnapshots: %v", req.NamespacedName, err)
+	}
+
+	// Update CR status, only writing it and recording the event when something changed since the sync check requeues every 30 seconds
+	if !node.Status.Ready {
+		r.Recorder.Event(&node, corev1.EventTypeNormal, "Created", "Successfully created ChiaNode resources.")
+	}
+	node.Status.Ready = true
+	if !equality.Semantic.DeepEqual(*previousStatus, node.Status) {
+		err = r.Status().Update(ctx, &node)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaNode", "ChiaNode")
+			log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to update ChiaNode status", req.NamespacedName))
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Requeue for the next scheduled backup, or to check on an active backup, StatefulSet recreation, or volume resize,
+	// and at least as often as the replicas' sync state is refreshed
+	requeue := backupRequeue
+	if (recreating || node.Status.VolumeResize != nil) && (requeue == 0 || requeue > volumePollInterval) {
+		requeue = volumePollInterval
+	}
+	if requeue == 0 || requeue > syncCheckInterval {
+		requeue = syncCheckInterval
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ChiaNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaNode{}).
+		Owns(&corev1.Service{}).
 		Complete(r)
 }
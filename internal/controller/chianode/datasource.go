@@ -0,0 +1,218 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// downloadDatabaseScript downloads a blockchain database to DB_PATH if the replica doesn't have one yet.
+// Partial downloads are resumed, and the file is only moved into place once it is complete. When VERIFY_DB is set,
+// an .unverified marker is placed next to the database first so the verify-db init container validates it.
+const downloadDatabaseScript = `set -o errexit
+if [ -f "${DB_PATH}" ]; then
+  echo "Blockchain database ${DB_PATH} already exists, skipping download"
+  exit 0
+fi
+mkdir -p "$(dirname "${DB_PATH}")"
+curl --fail --location --retry 5 --continue-at - --output "${DB_PATH}.download" "${DB_URL}"
+if [ -n "${DB_SHA256}" ]; then
+  echo "${DB_SHA256}  ${DB_PATH}.download" | sha256sum --check -
+fi
+case "${DB_URL}" in
+  *.gz) gunzip --stdout "${DB_PATH}.download" > "${DB_PATH}.part" && rm "${DB_PATH}.download" ;;
+  *) mv "${DB_PATH}.download" "${DB_PATH}.part" ;;
+esac
+if [ "${VERIFY_DB}" = "true" ]; then
+  touch "${DB_PATH}.unverified"
+fi
+mv "${DB_PATH}.part" "${DB_PATH}"`
+
+// verifyDatabaseScript validates a blockchain database that was downloaded, or restored from another replica's volume, once.
+// Each replica records its pod in a marker file on the database volume. A database on a volume whose marker names a different
+// pod was restored from that pod's volume claim or a snapshot of it. Databases a replica created itself are never validated.
+const verifyDatabaseScript = `set -o errexit
+replica_file="$(dirname "${DB_PATH}")/.chia-operator-replica"
+replica="${POD_NAMESPACE}/${POD_NAME}"
+if [ -f "${DB_PATH}" ]; then
+  if [ -f "${DB_PATH}.unverified" ] || { [ -f "${replica_file}" ] && [ "$(cat "${replica_file}")" != "${replica}" ]; }; then
+    chia db validate --db "${DB_PATH}"
+  fi
+fi
+rm -f "${DB_PATH}.unverified"
+mkdir -p "$(dirname "${replica_file}")"
+echo "${replica}" > "${replica_file}"`
+
+// getDataSourceVolumeClaimSource gives the data source for new replicas' database volume claims, if one was specified.
+// This is restored to the dedicated database volume claim if the node has one, or otherwise the CHIA_ROOT volume claim.
+func (r *ChiaNodeReconciler) getDataSourceVolumeClaimSource(ctx context.Context, node k8schianetv1.ChiaNode) *corev1.TypedLocalObjectReference {
+	if node.Spec.DataSource == nil {
+		return nil
+	}
+
+	if node.Spec.DataSource.VolumeSnapshotName != "" {
+		apiGroup := "snapshot.storage.k8s.io"
+		return &corev1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     node.Spec.DataSource.VolumeSnapshotName,
+		}
+	}
+
+	if node.Spec.DataSource.PersistentVolumeClaimName != "" {
+		return &corev1.TypedLocalObjectReference{
+			Kind: "PersistentVolumeClaim",
+			Name: node.Spec.DataSource.PersistentVolumeClaimName,
+		}
+	}
+
+	return nil
+}
+
+// getDataSourceInitContainers assembles the init containers that download and verify a ChiaNode's blockchain database before the chia container starts
+func (r *ChiaNodeReconciler) getDataSourceInitContainers(ctx context.Context, node k8schianetv1.ChiaNode) []corev1.Container {
+	var containers []corev1.Container
+	if node.Spec.DataSource == nil {
+		return containers
+	}
+
+	verify := node.Spec.DataSource.Verify == nil || *node.Spec.DataSource.Verify
+	dbEnv := append(r.getChiaNodeEnv(ctx, node),
+		corev1.EnvVar{
+			Name:  "DB_PATH",
+			Value: r.getDatabasePath(ctx, node),
+		},
+		corev1.EnvVar{
+			Name:  "VERIFY_DB",
+			Value: strconv.FormatBool(verify),
+		},
+	)
+
+	if node.Spec.DataSource.DatabaseURL != "" {
+		containers = append(containers, corev1.Container{
+			Name:            "download-db",
+			Image:           node.Spec.ChiaConfig.Image,
+			ImagePullPolicy: node.Spec.ImagePullPolicy,
+			Args:            []string{"/bin/bash", "-c", downloadDatabaseScript},
+			Env: append(slices.Clone(dbEnv),
+				corev1.EnvVar{
+					Name:  "DB_URL",
+					Value: node.Spec.DataSource.DatabaseURL,
+				},
+				corev1.EnvVar{
+					Name:  "DB_SHA256",
+					Value: node.Spec.DataSource.DatabaseSHA256,
+				},
+			),
+			VolumeMounts:    r.getChiaVolumeMounts(ctx, node),
+			SecurityContext: node.Spec.ChiaConfig.SecurityContext,
+		})
+	}
+
+	if verify {
+		containers = append(containers, corev1.Container{
+			Name:            "verify-db",
+			Image:           node.Spec.ChiaConfig.Image,
+			ImagePullPolicy: node.Spec.ImagePullPolicy,
+			Args:            []string{"/bin/bash", "-c", verifyDatabaseScript},
+			Env: append(slices.Clone(dbEnv),
+				corev1.EnvVar{
+					Name: "POD_NAME",
+					ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.name",
+						},
+					},
+				},
+				corev1.EnvVar{
+					Name: "POD_NAMESPACE",
+					ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.namespace",
+						},
+					},
+				},
+			),
+			VolumeMounts:    r.getChiaVolumeMounts(ctx, node),
+			SecurityContext: node.Spec.ChiaConfig.SecurityContext,
+		})
+	}
+
+	return containers
+}
+
+// reconcileDataSourceClaims creates the database volume claims of replicas that don't have one yet, restored from the data source.
+// Volume claim templates can't be changed, so rather than setting the data source on the StatefulSet's template, which would mean recreating
+// the StatefulSet whenever the data source changes, new replicas' claims are created ahead of the StatefulSet and it adopts them.
+func (r *ChiaNodeReconciler) reconcileDataSourceClaims(ctx context.Context, node k8schianetv1.ChiaNode, stateful appsv1.StatefulSet) error {
+	dataSource := r.getDataSourceVolumeClaimSource(ctx, node)
+	if dataSource == nil {
+		return nil
+	}
+	template := kube.FindVolumeClaimTemplate(stateful, getDatabaseClaimTemplateName(node))
+	if template == nil {
+		return nil
+	}
+
+	// Claims created by the StatefulSet have the template's labels and the StatefulSet's selector labels
+	labels := make(map[string]string)
+	for k, v := range template.Labels {
+		labels[k] = v
+	}
+	for k, v := range stateful.Spec.Selector.MatchLabels {
+		labels[k] = v
+	}
+
+	for replica := int32(0); replica < node.Spec.Replicas; replica++ {
+		name := fmt.Sprintf("%s-%s-%d", template.Name, stateful.Name, replica)
+		var pvc corev1.PersistentVolumeClaim
+		err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: name}, &pvc)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		pvc = corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: node.Namespace,
+				Labels:    labels,
+			},
+			Spec: *template.Spec.DeepCopy(),
+		}
+		pvc.Spec.DataSource = dataSource
+		err = r.Create(ctx, &pvc)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getDatabasePath gives the path to the blockchain database for the ChiaNode's network in the chia container
+func (r *ChiaNodeReconciler) getDatabasePath(ctx context.Context, node k8schianetv1.ChiaNode) string {
+	network := "mainnet"
+	if node.Spec.ChiaConfig.Network != nil && *node.Spec.ChiaConfig.Network != "" {
+		network = *node.Spec.ChiaConfig.Network
+	} else if node.Spec.ChiaConfig.Testnet != nil && *node.Spec.ChiaConfig.Testnet {
+		network = "testnet11"
+	}
+	return fmt.Sprintf("/chia-data/db/blockchain_v2_%s.sqlite", network)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// envValues gives a map of a container's environment variable names to their values, with field references given by their field path
+func envValues(container corev1.Container) map[string]string {
+	values := make(map[string]string)
+	for _, env := range container.Env {
+		if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil {
+			values[env.Name] = env.ValueFrom.FieldRef.FieldPath
+			continue
+		}
+		values[env.Name] = env.Value
+	}
+	return values
+}
+
+func TestGetDataSourceInitContainers(t *testing.T) {
+	noVerify := false
+	tests := []struct {
+		name       string
+		dataSource *k8schianetv1.ChiaNodeDataSource
+		expected   map[string]map[string]string
+	}{
+		{
+			name:     "no data source",
+			expected: map[string]map[string]string{},
+		},
+		{
+			name: "download and verify",
+			dataSource: &k8schianetv1.ChiaNodeDataSource{
+				DatabaseURL:    "https://example.com/blockchain_v2_mainnet.sqlite.gz",
+				DatabaseSHA256: "abc123",
+			},
+			expected: map[string]map[string]string{
+				"download-db": {
+					"DB_PATH":   "/chia-data/db/blockchain_v2_mainnet.sqlite",
+					"VERIFY_DB": "true",
+					"DB_URL":    "https://example.com/blockchain_v2_mainnet.sqlite.gz",
+					"DB_SHA256": "abc123",
+					"service":   "node",
+					"CHIA_ROOT": "/chia-data",
+					"keys":      "none",
+					"ca":        "/chia-ca",
+				},
+				"verify-db": {
+					"DB_PATH":       "/chia-data/db/blockchain_v2_mainnet.sqlite",
+					"VERIFY_DB":     "true",
+					"POD_NAME":      "metadata.name",
+					"POD_NAMESPACE": "metadata.namespace",
+					"service":       "node",
+					"CHIA_ROOT":     "/chia-data",
+					"keys":          "none",
+					"ca":            "/chia-ca",
+				},
+			},
+		},
+		{
+			name: "download without verifying",
+			dataSource: &k8schianetv1.ChiaNodeDataSource{
+				DatabaseURL: "https://example.com/blockchain_v2_mainnet.sqlite",
+				Verify:      &noVerify,
+			},
+			expected: map[string]map[string]string{
+				"download-db": {
+					"DB_PATH":   "/chia-data/db/blockchain_v2_mainnet.sqlite",
+					"VERIFY_DB": "false",
+					"DB_URL":    "https://example.com/blockchain_v2_mainnet.sqlite",
+					"DB_SHA256": "",
+					"service":   "node",
+					"CHIA_ROOT": "/chia-data",
+					"keys":      "none",
+					"ca":        "/chia-ca",
+				},
+			},
+		},
+		{
+			name: "verify a restored volume",
+			dataSource: &k8schianetv1.ChiaNodeDataSource{
+				PersistentVolumeClaimName: "node-db",
+			},
+			expected: map[string]map[string]string{
+				"verify-db": {
+					"DB_PATH":       "/chia-data/db/blockchain_v2_mainnet.sqlite",
+					"VERIFY_DB":     "true",
+					"POD_NAME":      "metadata.name",
+					"POD_NAMESPACE": "metadata.namespace",
+					"service":       "node",
+					"CHIA_ROOT":     "/chia-data",
+					"keys":          "none",
+					"ca":            "/chia-ca",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.DataSource = tt.dataSource
+			r := &ChiaNodeReconciler{}
+
+			actual := make(map[string]map[string]string)
+			for _, container := range r.getDataSourceInitContainers(context.Background(), node) {
+				actual[container.Name] = envValues(container)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("Init container environments do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package chianode
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
@@ -16,8 +17,8 @@ import (
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 )
 
-// getChiaVolumes retrieves the requisite volumes from the Chia config struct
-func (r *ChiaNodeReconciler) getChiaVolumesAndTemplates(ctx context.Context, node k8schianetv1.ChiaNode) ([]corev1.Volume, []corev1.PersistentVolumeClaim) {
+// getChiaVolumesAndTemplates retrieves the requisite volumes and volume claim templates from the Chia config struct
+func (r *ChiaNodeReconciler) getChiaVolumesAndTemplates(ctx context.Context, node k8schianetv1.ChiaNode) ([]corev1.Volume, []corev1.PersistentVolumeClaim, error) {
 	var v []corev1.Volume
 	var vcts []corev1.PersistentVolumeClaim
 
@@ -36,20 +37,11 @@ func (r *ChiaNodeReconciler) getChiaVolumesAndTemplates(ctx context.Context, nod
 	var chiaRootAdded bool = false
 	if node.Spec.Storage != nil && node.Spec.Storage.ChiaRoot != nil {
 		if node.Spec.Storage.ChiaRoot.PersistentVolumeClaim != nil {
-			vcts = append(vcts, corev1.PersistentVolumeClaim{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "chiaroot",
-				},
-				Spec: corev1.PersistentVolumeClaimSpec{
-					AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
-					StorageClassName: &node.Spec.Storage.ChiaRoot.PersistentVolumeClaim.StorageClass,
-					Resources: corev1.VolumeResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceStorage: resource.MustParse(node.Spec.Storage.ChiaRoot.PersistentVolumeClaim.ResourceRequest),
-						},
-					},
-				},
-			})
+			vct, err := getClaimTemplate("chiaroot", *node.Spec.Storage.ChiaRoot.PersistentVolumeClaim)
+			if err != nil {
+				return nil, nil, err
+			}
+			vcts = append(vcts, vct)
 			chiaRootAdded = true
 		} else if node.Spec.Storage.ChiaRoot.HostPathVolume != nil {
 			v = append(v, corev1.Volume{
@@ -72,12 +64,81 @@ func (r *ChiaNodeReconciler) getChiaVolumesAndTemplates(ctx context.Context, nod
 		})
 	}
 
+	// Dedicated database volume -- PVC is respected first if both it and hostpath are specified, falls back to hostPath if specified
+	// If both are empty, the database stays in the CHIA_ROOT volume
+	if node.Spec.Storage != nil && node.Spec.Storage.Database != nil {
+		if node.Spec.Storage.Database.PersistentVolumeClaim != nil {
+			vct, err := getClaimTemplate("chiadb", *node.Spec.Storage.Database.PersistentVolumeClaim)
+			if err != nil {
+				return nil, nil, err
+			}
+			vcts = append(vcts, vct)
+		} else if node.Spec.Storage.Database.HostPathVolume != nil {
+			v = append(v, corev1.Volume{
+				Name: "chiadb",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: node.Spec.Storage.Database.HostPathVolume.Path,
+					},
+				},
+			})
+		}
+	}
+
 	// Add sidecar volumes if any exist
 	if len(node.Spec.Sidecars.Volumes) > 0 {
 		v = append(v, node.Spec.Sidecars.Volumes...)
 	}
 
-	return v, vcts
+	return v, vcts, nil
+}
+
+// getClaimTemplate assembles a volume claim template from a ChiaNode storage claim config. Each replica gets its own claim from the
+// template, so an existing claimName can't be used and a resourceRequest is required.
+func getClaimTemplate(name string, config k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	if config.ResourceRequest == "" {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("%s persistentVolumeClaim requires a resourceRequest, ChiaNodes create a volume claim for each replica and don't support claimName", name)
+	}
+	request, err := resource.ParseQuantity(config.ResourceRequest)
+	if err != nil {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("invalid %s resourceRequest %q: %v", name, config.ResourceRequest, err)
+	}
+
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+			StorageClassName: &config.StorageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: request,
+				},
+			},
+		},
+	}, nil
+}
+
+// hasDatabaseVolume returns true if the node stores its database directory on a dedicated volume
+func hasDatabaseVolume(node k8schianetv1.ChiaNode) bool {
+	return node.Spec.Storage != nil && node.Spec.Storage.Database != nil &&
+		(node.Spec.Storage.Database.PersistentVolumeClaim != nil || node.Spec.Storage.Database.HostPathVolume != nil)
+}
+
+// getDatabaseClaimTemplateName gives the name of the volume claim template that holds the node's blockchain database, which backups
+// snapshot and data sources are restored to. Returns an empty string if the database isn't stored on a volume claim.
+func getDatabaseClaimTemplateName(node k8schianetv1.ChiaNode) string {
+	if hasDatabaseVolume(node) {
+		if node.Spec.Storage.Database.PersistentVolumeClaim != nil {
+			return "chiadb"
+		}
+		return ""
+	}
+	if node.Spec.Storage != nil && node.Spec.Storage.ChiaRoot != nil && node.Spec.Storage.ChiaRoot.PersistentVolumeClaim != nil {
+		return "chiaroot"
+	}
+	return ""
 }
 
 // getChiaVolumeMounts retrieves the requisite volume mounts from the Chia config struct
@@ -96,6 +157,14 @@ func (r *ChiaNodeReconciler) getChiaVolumeMounts(ctx context.Context, node k8sch
 		MountPath: "/chia-data",
 	})
 
+	// Dedicated database volume
+	if hasDatabaseVolume(node) {
+		v = append(v, corev1.VolumeMount{
+			Name:      "chiadb",
+			MountPath: "/chia-data/db",
+		})
+	}
+
 	return v
 }
 
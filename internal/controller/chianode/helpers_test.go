@@ -0,0 +1,102 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestGetChiaVolumesAndTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		storage   *k8schianetv1.DatabaseStorageConfig
+		templates map[string]string
+		expectErr bool
+	}{
+		{
+			name:      "no storage",
+			templates: map[string]string{},
+		},
+		{
+			name: "chiaroot and database claims",
+			storage: &k8schianetv1.DatabaseStorageConfig{
+				StorageConfig: k8schianetv1.StorageConfig{
+					ChiaRoot: &k8schianetv1.ChiaRootConfig{
+						PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"},
+					},
+				},
+				Database: &k8schianetv1.DatabaseConfig{
+					PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{StorageClass: "local-nvme", ResourceRequest: "300Gi"},
+				},
+			},
+			templates: map[string]string{
+				"chiaroot": "10Gi",
+				"chiadb":   "300Gi",
+			},
+		},
+		{
+			name: "database claim name only",
+			storage: &k8schianetv1.DatabaseStorageConfig{
+				Database: &k8schianetv1.DatabaseConfig{
+					PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "node-db"},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "chiaroot claim name only",
+			storage: &k8schianetv1.DatabaseStorageConfig{
+				StorageConfig: k8schianetv1.StorageConfig{
+					ChiaRoot: &k8schianetv1.ChiaRootConfig{
+						PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "node-root"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid resource request",
+			storage: &k8schianetv1.DatabaseStorageConfig{
+				Database: &k8schianetv1.DatabaseConfig{
+					PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "lots"},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.Storage = tt.storage
+			r := &ChiaNodeReconciler{}
+
+			_, vcts, err := r.getChiaVolumesAndTemplates(context.Background(), node)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			actual := make(map[string]string)
+			for _, vct := range vcts {
+				request := vct.Spec.Resources.Requests.Storage()
+				actual[vct.Name] = request.String()
+			}
+			if diff := cmp.Diff(tt.templates, actual); diff != "" {
+				t.Errorf("Volume claim templates do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
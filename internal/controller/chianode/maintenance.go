@@ -0,0 +1,47 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// GetMaintenancePodConfig gives the pod configuration of a ChiaNode replica for ChiaMaintenance tasks.
+// Volume claim templates are resolved to the claims the StatefulSet created for the given replica ordinal.
+func GetMaintenancePodConfig(ctx context.Context, node k8schianetv1.ChiaNode, replica int32) (kube.ChiaPodConfig, error) {
+	r := &ChiaNodeReconciler{}
+	vols, volClaimTemplates, err := r.getChiaVolumesAndTemplates(ctx, node)
+	if err != nil {
+		return kube.ChiaPodConfig{}, err
+	}
+	for _, vct := range volClaimTemplates {
+		vols = append(vols, corev1.Volume{
+			Name: vct.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: fmt.Sprintf("%s-%s-%d", vct.Name, fmt.Sprintf(chianodeNamePattern, node.Name), replica),
+				},
+			},
+		})
+	}
+
+	return kube.ChiaPodConfig{
+		WorkloadName:    fmt.Sprintf(chianodeNamePattern, node.Name),
+		StatefulSet:     true,
+		Image:           node.Spec.ChiaConfig.Image,
+		ImagePullPolicy: node.Spec.ImagePullPolicy,
+		Env:             r.getChiaNodeEnv(ctx, node),
+		Volumes:         vols,
+		VolumeMounts:    r.getChiaVolumeMounts(ctx, node),
+		NodeSelector:    node.Spec.NodeSelector,
+		SecurityContext: node.Spec.PodSecurityContext,
+	}, nil
+}
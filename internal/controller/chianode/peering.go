@@ -0,0 +1,163 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// chianodePeeringNamePattern is the name of the ConfigMap holding a ChiaNode's in-cluster peer list
+	chianodePeeringNamePattern = "%s-node-peering"
+
+	// peeringPath is where the peering ConfigMap is mounted in the configure-peers init container
+	peeringPath = "/peering"
+
+	// fullNodePeersKey is the peering ConfigMap key holding the full_node_peers as JSON
+	fullNodePeersKey = "full_node_peers.json"
+)
+
+// configurePeersScript renders the in-cluster peers into the full_node's chia config. The image entrypoint creates the chia config
+// before running this script. Every replica is given the same peer list, so each replica removes its own DNS name from it.
+// The peer list is read from the peering ConfigMap rather than the pod template, so scaling the ChiaNode doesn't restart existing replicas.
+const configurePeersScript = `set -o errexit
+config="${CHIA_ROOT}/config/config.yaml"
+export SELF_HOST="$(hostname).${HEADLESS_SERVICE_DOMAIN}"
+export FULL_NODE_PEERS="$(cat ` + peeringPath + `/` + fullNodePeersKey + `)"
+yq -i '.full_node.full_node_peers = (env(FULL_NODE_PEERS) | map(select(.host != strenv(SELF_HOST))))' "${config}"
+if [ -n "${TRUSTED_CIDRS}" ]; then
+  yq -i '.full_node.trusted_cidrs = env(TRUSTED_CIDRS)' "${config}"
+fi`
+
+// fullNodePeer is an entry in the full_node_peers of the chia config
+type fullNodePeer struct {
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+}
+
+// getHeadlessServiceDomain gives the DNS domain of the ChiaNode's headless Service, which each replica's DNS name is a subdomain of
+func getHeadlessServiceDomain(node k8schianetv1.ChiaNode) string {
+	return fmt.Sprintf("%s-headless.%s.svc", fmt.Sprintf(chianodeNamePattern, node.Name), node.Namespace)
+}
+
+// getPeerNodeNamespace gives the namespace of a ChiaNode peer reference
+func getPeerNodeNamespace(node k8schianetv1.ChiaNode, peer k8schianetv1.ChiaNodePeerReference) string {
+	if peer.Namespace != "" {
+		return peer.Namespace
+	}
+	return node.Namespace
+}
+
+// getIntraClusterPeers gives the in-cluster full_node peers of the ChiaNode's replicas. Peers use the node's own peer port,
+// since full_nodes only peer with others on the same network.
+func (r *ChiaNodeReconciler) getIntraClusterPeers(ctx context.Context, node k8schianetv1.ChiaNode) []fullNodePeer {
+	peers := []fullNodePeer{}
+	port := r.getFullNodePort(ctx, node)
+
+	if node.Spec.IntraClusterPeering.Replicas == nil || *node.Spec.IntraClusterPeering.Replicas {
+		for ordinal := int32(0); ordinal < node.Spec.Replicas; ordinal++ {
+			peers = append(peers, fullNodePeer{
+				Host: fmt.Sprintf("%s-%d.%s", fmt.Sprintf(chianodeNamePattern, node.Name), ordinal, getHeadlessServiceDomain(node)),
+				Port: port,
+			})
+		}
+	}
+
+	for _, peer := range node.Spec.IntraClusterPeering.ChiaNodes {
+		peers = append(peers, fullNodePeer{
+			Host: fmt.Sprintf("%s-synced.%s.svc", fmt.Sprintf(chianodeNamePattern, peer.Name), getPeerNodeNamespace(node, peer)),
+			Port: port,
+		})
+	}
+
+	return peers
+}
+
+// getPeeringInitContainers assembles the init container that configures a ChiaNode's in-cluster peers before the chia container starts
+func (r *ChiaNodeReconciler) getPeeringInitContainers(ctx context.Context, node k8schianetv1.ChiaNode) []corev1.Container {
+	var containers []corev1.Container
+	if node.Spec.IntraClusterPeering == nil {
+		return containers
+	}
+
+	// Marshaling a slice of strings can't fail
+	var trustedCIDRs []byte
+	if len(node.Spec.IntraClusterPeering.TrustedCIDRs) > 0 {
+		trustedCIDRs, _ = json.Marshal(node.Spec.IntraClusterPeering.TrustedCIDRs)
+	}
+
+	containers = append(containers, corev1.Container{
+		Name:            "configure-peers",
+		Image:           node.Spec.ChiaConfig.Image,
+		ImagePullPolicy: node.Spec.ImagePullPolicy,
+		Args:            []string{"/bin/bash", "-c", configurePeersScript},
+		Env: append(r.getChiaNodeEnv(ctx, node),
+			corev1.EnvVar{
+				Name:  "HEADLESS_SERVICE_DOMAIN",
+				Value: getHeadlessServiceDomain(node),
+			},
+			corev1.EnvVar{
+				Name:  "TRUSTED_CIDRS",
+				Value: string(trustedCIDRs),
+			},
+		),
+		VolumeMounts: append(r.getChiaVolumeMounts(ctx, node),
+			corev1.VolumeMount{
+				Name:      "peering",
+				ReadOnly:  true,
+				MountPath: peeringPath,
+			},
+		),
+		SecurityContext: node.Spec.ChiaConfig.SecurityContext,
+	})
+
+	return containers
+}
+
+// getPeeringVolumes gives the volume for the peering ConfigMap, which the configure-peers init container reads the peer list from
+func getPeeringVolumes(node k8schianetv1.ChiaNode) []corev1.Volume {
+	var volumes []corev1.Volume
+	if node.Spec.IntraClusterPeering == nil {
+		return volumes
+	}
+
+	return append(volumes, corev1.Volume{
+		Name: "peering",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: fmt.Sprintf(chianodePeeringNamePattern, node.Name),
+				},
+			},
+		},
+	})
+}
+
+// assemblePeeringConfigMap assembles the ConfigMap holding the ChiaNode's in-cluster peer list
+func (r *ChiaNodeReconciler) assemblePeeringConfigMap(ctx context.Context, node k8schianetv1.ChiaNode) corev1.ConfigMap {
+	// Marshaling a slice of structs with only string and integer fields can't fail
+	peers, _ := json.Marshal(r.getIntraClusterPeers(ctx, node))
+
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chianodePeeringNamePattern, node.Name),
+			Namespace:       node.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels),
+			Annotations:     node.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, node),
+		},
+		Data: map[string]string{
+			fullNodePeersKey: string(peers),
+		},
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestGetIntraClusterPeers(t *testing.T) {
+	noReplicas := false
+	testnet := true
+	tests := []struct {
+		name     string
+		peering  k8schianetv1.ChiaNodeIntraClusterPeering
+		testnet  *bool
+		expected []fullNodePeer
+	}{
+		{
+			name: "replicas by default",
+			expected: []fullNodePeer{
+				{Host: "node-node-0.node-node-headless.chia.svc", Port: 8444},
+				{Host: "node-node-1.node-node-headless.chia.svc", Port: 8444},
+			},
+		},
+		{
+			name:     "replicas disabled",
+			peering:  k8schianetv1.ChiaNodeIntraClusterPeering{Replicas: &noReplicas},
+			expected: []fullNodePeer{},
+		},
+		{
+			name: "other ChiaNodes",
+			peering: k8schianetv1.ChiaNodeIntraClusterPeering{
+				Replicas: &noReplicas,
+				ChiaNodes: []k8schianetv1.ChiaNodePeerReference{
+					{Name: "other"},
+					{Name: "remote", Namespace: "chia-mainnet"},
+				},
+			},
+			expected: []fullNodePeer{
+				{Host: "other-node-synced.chia.svc", Port: 8444},
+				{Host: "remote-node-synced.chia-mainnet.svc", Port: 8444},
+			},
+		},
+		{
+			name: "testnet port",
+			peering: k8schianetv1.ChiaNodeIntraClusterPeering{
+				ChiaNodes: []k8schianetv1.ChiaNodePeerReference{{Name: "other"}},
+			},
+			testnet: &testnet,
+			expected: []fullNodePeer{
+				{Host: "node-node-0.node-node-headless.chia.svc", Port: 58444},
+				{Host: "node-node-1.node-node-headless.chia.svc", Port: 58444},
+				{Host: "other-node-synced.chia.svc", Port: 58444},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.Replicas = 2
+			node.Spec.IntraClusterPeering = &tt.peering
+			node.Spec.ChiaConfig.Testnet = tt.testnet
+			r := &ChiaNodeReconciler{}
+
+			if diff := cmp.Diff(tt.expected, r.getIntraClusterPeers(context.Background(), node)); diff != "" {
+				t.Errorf("Peers do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAssemblePeeringConfigMap(t *testing.T) {
+	noReplicas := false
+	tests := []struct {
+		name     string
+		peering  k8schianetv1.ChiaNodeIntraClusterPeering
+		expected string
+	}{
+		{
+			name:     "replicas",
+			expected: `[{"host":"node-node-0.node-node-headless.chia.svc","port":8444}]`,
+		},
+		{
+			name:     "no peers",
+			peering:  k8schianetv1.ChiaNodeIntraClusterPeering{Replicas: &noReplicas},
+			expected: `[]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.Replicas = 1
+			node.Spec.IntraClusterPeering = &tt.peering
+			r := &ChiaNodeReconciler{}
+
+			cm := r.assemblePeeringConfigMap(context.Background(), node)
+			if cm.Name != "node-node-peering" {
+				t.Errorf("Expected ConfigMap name node-node-peering, got %s", cm.Name)
+			}
+			if diff := cmp.Diff(map[string]string{fullNodePeersKey: tt.expected}, cm.Data); diff != "" {
+				t.Errorf("ConfigMap data does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGetPeeringInitContainers(t *testing.T) {
+	tests := []struct {
+		name            string
+		peering         *k8schianetv1.ChiaNodeIntraClusterPeering
+		expectedEnv     map[string]string
+		expectedVolumes int
+	}{
+		{
+			name: "peering disabled",
+		},
+		{
+			name:    "peering without trusted CIDRs",
+			peering: &k8schianetv1.ChiaNodeIntraClusterPeering{},
+			expectedEnv: map[string]string{
+				"HEADLESS_SERVICE_DOMAIN": "node-node-headless.chia.svc",
+				"TRUSTED_CIDRS":           "",
+			},
+			expectedVolumes: 1,
+		},
+		{
+			name: "peering with trusted CIDRs",
+			peering: &k8schianetv1.ChiaNodeIntraClusterPeering{
+				TrustedCIDRs: []string{"10.0.0.0/8", "fd00::/8"},
+			},
+			expectedEnv: map[string]string{
+				"HEADLESS_SERVICE_DOMAIN": "node-node-headless.chia.svc",
+				"TRUSTED_CIDRS":           `["10.0.0.0/8","fd00::/8"]`,
+			},
+			expectedVolumes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.IntraClusterPeering = tt.peering
+			r := &ChiaNodeReconciler{}
+
+			containers := r.getPeeringInitContainers(context.Background(), node)
+			if tt.expectedEnv == nil {
+				if len(containers) != 0 {
+					t.Errorf("Expected no init containers, got %d", len(containers))
+				}
+			} else {
+				if len(containers) != 1 {
+					t.Fatalf("Expected one init container, got %d", len(containers))
+				}
+				env := envValues(containers[0])
+				for name, expected := range tt.expectedEnv {
+					if env[name] != expected {
+						t.Errorf("Expected %s to be %q, got %q", name, expected, env[name])
+					}
+				}
+			}
+
+			if volumes := getPeeringVolumes(node); len(volumes) != tt.expectedVolumes {
+				t.Errorf("Expected %d peering volumes, got %d", tt.expectedVolumes, len(volumes))
+			}
+		})
+	}
+}
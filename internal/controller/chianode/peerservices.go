@@ -0,0 +1,136 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// chianodePeerServiceNamePattern is the name of each replica's peer Service, given the ChiaNode name and replica ordinal
+	chianodePeerServiceNamePattern = "%s-node-peers-%d"
+
+	// peerOrdinalLabel is set on replica peer Services with the ordinal of the replica they select
+	peerOrdinalLabel = "k8s.chia.net/peer-ordinal"
+
+	// externalDNSHostnameAnnotation is read by external-dns to create DNS records for a Service's external address
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+)
+
+// getReplicaPeer gives the peer Service config of a replica, which is empty if the ChiaNode doesn't list one for its ordinal
+func getReplicaPeer(node k8schianetv1.ChiaNode, ordinal int32) k8schianetv1.ChiaNodeReplicaPeer {
+	if int(ordinal) < len(node.Spec.PeerServices.Replicas) {
+		return node.Spec.PeerServices.Replicas[ordinal]
+	}
+	return k8schianetv1.ChiaNodeReplicaPeer{}
+}
+
+// assemblePeerService assembles the peer Service for one of a ChiaNode's replicas, which selects the replica's pod by name
+func (r *ChiaNodeReconciler) assemblePeerService(ctx context.Context, node k8schianetv1.ChiaNode, ordinal int32) corev1.Service {
+	peer := getReplicaPeer(node, ordinal)
+
+	annotations := make(map[string]string)
+	for k, v := range node.Spec.AdditionalMetadata.Annotations {
+		annotations[k] = v
+	}
+	for k, v := range peer.Annotations {
+		annotations[k] = v
+	}
+	if peer.ExternalHostname != "" {
+		annotations[externalDNSHostnameAnnotation] = peer.ExternalHostname
+	}
+
+	labels := kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta, node.Spec.AdditionalMetadata.Labels)
+	labels[peerOrdinalLabel] = strconv.Itoa(int(ordinal))
+
+	// The Service exposes the replica's own peer port, which is the port the replica advertises to its peers
+	port := corev1.ServicePort{
+		Port:       r.getFullNodePort(ctx, node),
+		TargetPort: intstr.FromString("peers"),
+		Protocol:   "TCP",
+		Name:       "peers",
+	}
+
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chianodePeerServiceNamePattern, node.Name, ordinal),
+			Namespace:       node.Namespace,
+			Labels:          labels,
+			Annotations:     annotations,
+			OwnerReferences: r.getOwnerReference(ctx, node),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{port},
+			Selector: map[string]string{
+				"statefulset.kubernetes.io/pod-name": fmt.Sprintf("%s-%d", fmt.Sprintf(chianodeNamePattern, node.Name), ordinal),
+			},
+		},
+	}
+}
+
+// getPeerServiceStatus gives a replica's peer Service status from the Service. The external address is the replica's external hostname
+// if one was given, or otherwise the LoadBalancer's ingress address, and is empty until it's known.
+func getPeerServiceStatus(node k8schianetv1.ChiaNode, ordinal int32, srv corev1.Service) k8schianetv1.ChiaNodePeerServiceStatus {
+	status := k8schianetv1.ChiaNodePeerServiceStatus{
+		Ordinal:     ordinal,
+		ServiceName: srv.Name,
+	}
+	if len(srv.Spec.Ports) == 0 {
+		return status
+	}
+
+	host := getReplicaPeer(node, ordinal).ExternalHostname
+	port := srv.Spec.Ports[0].Port
+	if host == "" {
+		for _, ingress := range srv.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				host = ingress.Hostname
+				break
+			}
+			if ingress.IP != "" {
+				host = ingress.IP
+				break
+			}
+		}
+	}
+
+	if host != "" && port != 0 {
+		status.ExternalAddress = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	return status
+}
+
+// removeStalePeerServices deletes the ChiaNode's replica peer Services that aren't in the desired set, left over from scaling down or disabling peer Services
+func (r *ChiaNodeReconciler) removeStalePeerServices(ctx context.Context, node k8schianetv1.ChiaNode, desired map[string]bool) error {
+	var services corev1.ServiceList
+	err := r.List(ctx, &services, client.InNamespace(node.Namespace), client.MatchingLabels(kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta)), client.HasLabels{peerOrdinalLabel})
+	if err != nil {
+		return err
+	}
+
+	for i, srv := range services.Items {
+		if desired[srv.Name] || !metav1.IsControlledBy(&srv, &node) {
+			continue
+		}
+		err = r.Delete(ctx, &services.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// newPeerServicesNode gives a ChiaNode with peer Services configured for the given replicas
+func newPeerServicesNode(peers ...k8schianetv1.ChiaNodeReplicaPeer) k8schianetv1.ChiaNode {
+	node := newSyncNode()
+	node.UID = "node-uid"
+	node.Spec.Replicas = 2
+	node.Spec.PeerServices = &k8schianetv1.ChiaNodePeerServices{Replicas: peers}
+	return node
+}
+
+func TestAssemblePeerService(t *testing.T) {
+	testnet := true
+	tests := []struct {
+		name                string
+		node                k8schianetv1.ChiaNode
+		ordinal             int32
+		expectedAnnotations map[string]string
+		expectedPort        int32
+	}{
+		{
+			name:                "replica without an entry",
+			node:                newPeerServicesNode(),
+			ordinal:             1,
+			expectedAnnotations: map[string]string{},
+			expectedPort:        8444,
+		},
+		{
+			name: "replica with an external hostname and annotations",
+			node: newPeerServicesNode(
+				k8schianetv1.ChiaNodeReplicaPeer{},
+				k8schianetv1.ChiaNodeReplicaPeer{
+					ExternalHostname: "node-1.example.com",
+					Annotations:      map[string]string{"service.beta.kubernetes.io/load-balancer-ip": "203.0.113.10"},
+				},
+			),
+			ordinal: 1,
+			expectedAnnotations: map[string]string{
+				"service.beta.kubernetes.io/load-balancer-ip": "203.0.113.10",
+				externalDNSHostnameAnnotation:                 "node-1.example.com",
+			},
+			expectedPort: 8444,
+		},
+		{
+			name: "replica annotations override the node's additional annotations",
+			node: func() k8schianetv1.ChiaNode {
+				node := newPeerServicesNode(k8schianetv1.ChiaNodeReplicaPeer{Annotations: map[string]string{"team": "replica"}})
+				node.Spec.AdditionalMetadata.Annotations = map[string]string{"team": "node", "owner": "chia"}
+				node.Spec.ChiaConfig.Testnet = &testnet
+				return node
+			}(),
+			ordinal:             0,
+			expectedAnnotations: map[string]string{"team": "replica", "owner": "chia"},
+			expectedPort:        58444,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ChiaNodeReconciler{}
+
+			srv := r.assemblePeerService(context.Background(), tt.node, tt.ordinal)
+			if expected := fmt.Sprintf("node-node-peers-%d", tt.ordinal); srv.Name != expected {
+				t.Errorf("Expected Service name %s, got %s", expected, srv.Name)
+			}
+			if diff := cmp.Diff(tt.expectedAnnotations, srv.Annotations); diff != "" {
+				t.Errorf("Annotations do not match. Diff: %s", diff)
+			}
+			if actual := srv.Labels[peerOrdinalLabel]; actual != fmt.Sprint(tt.ordinal) {
+				t.Errorf("Expected peer ordinal label %d, got %s", tt.ordinal, actual)
+			}
+			expectedSelector := map[string]string{"statefulset.kubernetes.io/pod-name": fmt.Sprintf("node-node-%d", tt.ordinal)}
+			if diff := cmp.Diff(expectedSelector, srv.Spec.Selector); diff != "" {
+				t.Errorf("Selector does not match. Diff: %s", diff)
+			}
+			if len(srv.Spec.Ports) != 1 || srv.Spec.Ports[0].Port != tt.expectedPort {
+				t.Errorf("Expected a single peers port %d, got %v", tt.expectedPort, srv.Spec.Ports)
+			}
+		})
+	}
+}
+
+func TestGetPeerServiceStatus(t *testing.T) {
+	newService := func(ingress ...corev1.LoadBalancerIngress) corev1.Service {
+		return corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-node-peers-0"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "peers", Port: 8444}}},
+			Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+		}
+	}
+	tests := []struct {
+		name            string
+		node            k8schianetv1.ChiaNode
+		srv             corev1.Service
+		expectedAddress string
+	}{
+		{
+			name: "load balancer not provisioned yet",
+			node: newPeerServicesNode(),
+			srv:  newService(),
+		},
+		{
+			name:            "load balancer IP",
+			node:            newPeerServicesNode(),
+			srv:             newService(corev1.LoadBalancerIngress{IP: "203.0.113.10"}),
+			expectedAddress: "203.0.113.10:8444",
+		},
+		{
+			name:            "load balancer hostname",
+			node:            newPeerServicesNode(),
+			srv:             newService(corev1.LoadBalancerIngress{Hostname: "lb.example.com", IP: "203.0.113.10"}),
+			expectedAddress: "lb.example.com:8444",
+		},
+		{
+			name:            "external hostname takes precedence",
+			node:            newPeerServicesNode(k8schianetv1.ChiaNodeReplicaPeer{ExternalHostname: "node-0.example.com"}),
+			srv:             newService(corev1.LoadBalancerIngress{IP: "203.0.113.10"}),
+			expectedAddress: "node-0.example.com:8444",
+		},
+		{
+			name:            "IPv6 load balancer",
+			node:            newPeerServicesNode(),
+			srv:             newService(corev1.LoadBalancerIngress{IP: "2001:db8::10"}),
+			expectedAddress: "[2001:db8::10]:8444",
+		},
+		{
+			name: "service without ports",
+			node: newPeerServicesNode(k8schianetv1.ChiaNodeReplicaPeer{ExternalHostname: "node-0.example.com"}),
+			srv:  corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "node-node-peers-0"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := k8schianetv1.ChiaNodePeerServiceStatus{
+				Ordinal:         0,
+				ServiceName:     "node-node-peers-0",
+				ExternalAddress: tt.expectedAddress,
+			}
+			if diff := cmp.Diff(expected, getPeerServiceStatus(tt.node, 0, tt.srv)); diff != "" {
+				t.Errorf("Peer Service status does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRemoveStalePeerServices(t *testing.T) {
+	node := newPeerServicesNode()
+	r := &ChiaNodeReconciler{}
+	newPeerService := func(ordinal int32, owned bool) *corev1.Service {
+		srv := r.assemblePeerService(context.Background(), node, ordinal)
+		if !owned {
+			srv.OwnerReferences = nil
+		}
+		return &srv
+	}
+	otherService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "node-node",
+			Namespace:       "chia",
+			Labels:          kube.GetCommonLabels(context.Background(), node.Kind, node.ObjectMeta),
+			OwnerReferences: r.getOwnerReference(context.Background(), node),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		services []client.Object
+		desired  map[string]bool
+		expected []string
+	}{
+		{
+			name:     "all peer services desired",
+			services: []client.Object{newPeerService(0, true), newPeerService(1, true), otherService},
+			desired:  map[string]bool{"node-node-peers-0": true, "node-node-peers-1": true},
+			expected: []string{"node-node", "node-node-peers-0", "node-node-peers-1"},
+		},
+		{
+			name:     "scaled down",
+			services: []client.Object{newPeerService(0, true), newPeerService(1, true), otherService},
+			desired:  map[string]bool{"node-node-peers-0": true},
+			expected: []string{"node-node", "node-node-peers-0"},
+		},
+		{
+			name:     "peer services disabled",
+			services: []client.Object{newPeerService(0, true), newPeerService(1, true), otherService},
+			expected: []string{"node-node"},
+		},
+		{
+			name:     "services the node doesn't own are kept",
+			services: []client.Object{newPeerService(0, false), newPeerService(1, true)},
+			expected: []string{"node-node-peers-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ChiaNodeReconciler{
+				Client: fake.NewClientBuilder().WithObjects(tt.services...).Build(),
+			}
+
+			err := r.removeStalePeerServices(context.Background(), node, tt.desired)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var services corev1.ServiceList
+			err = r.List(context.Background(), &services)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var actual []string
+			for _, srv := range services.Items {
+				actual = append(actual, srv.Name)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("Remaining Services do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// reconcileVolumeClaimTemplates brings a StatefulSet's existing volume claims in line with its desired volume claim templates.
+// Larger storage requests are applied to the existing claims of every template, and because volume claim templates are immutable, the StatefulSet is
+// deleted with orphaned pods so it can be recreated with the new templates. Returns true if the StatefulSet is being recreated.
+// If a claim can't be expanded, the desired StatefulSet keeps its current volume claim templates so it never claims a size its claims don't have.
+func (r *ChiaNodeReconciler) reconcileVolumeClaimTemplates(ctx context.Context, node *k8schianetv1.ChiaNode, desired *appsv1.StatefulSet) (bool, error) {
+	var current appsv1.StatefulSet
+	err := r.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &current)
+	if err != nil && errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// An orphaning delete is still in progress
+	if !current.DeletionTimestamp.IsZero() {
+		return true, nil
+	}
+
+	unsupported, err := r.resizeClaims(ctx, node, desired.Name, desired.Spec.VolumeClaimTemplates)
+	if err != nil {
+		return false, err
+	}
+	if unsupported {
+		desired.Spec.VolumeClaimTemplates = current.Spec.VolumeClaimTemplates
+		return false, nil
+	}
+
+	if kube.VolumeClaimTemplatesMatch(current, *desired) {
+		return false, nil
+	}
+
+	orphan := client.PropagationPolicy("Orphan")
+	err = r.Delete(ctx, &current, orphan)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return true, nil
+}
+
+// resizeClaims expands each replica's claims to their templates' storage requests, and records resize progress in the ChiaNode's status.
+// Returns true if a claim needs expanding but its StorageClass doesn't allow it.
+func (r *ChiaNodeReconciler) resizeClaims(ctx context.Context, node *k8schianetv1.ChiaNode, stsName string, templates []corev1.PersistentVolumeClaim) (bool, error) {
+	var claimStatuses []k8schianetv1.ChiaNodeVolumeClaimResizeStatus
+	resizing := false
+	unsupported := false
+	for _, template := range templates {
+		requested := template.Spec.Resources.Requests[corev1.ResourceStorage]
+		for replica := int32(0); replica < node.Spec.Replicas; replica++ {
+			var pvc corev1.PersistentVolumeClaim
+			err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: fmt.Sprintf("%s-%s-%d", template.Name, stsName, replica)}, &pvc)
+			if err != nil && errors.IsNotFound(err) {
+				// The StatefulSet will create this claim from the template
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+
+			claimStatus := k8schianetv1.ChiaNodeVolumeClaimResizeStatus{
+				Name:          pvc.Name,
+				RequestedSize: requested.String(),
+				Capacity:      pvc.Status.Capacity.Storage().String(),
+				State:         k8schianetv1.VolumeResizeStateComplete,
+			}
+
+			current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			if current.Cmp(requested) < 0 {
+				expandable, err := r.isExpandable(ctx, pvc)
+				if err != nil {
+					return false, err
+				}
+				if !expandable {
+					claimStatus.State = k8schianetv1.VolumeResizeStateUnsupported
+					claimStatuses = append(claimStatuses, claimStatus)
+					resizing = true
+					unsupported = true
+					continue
+				}
+
+				patch := client.MergeFrom(pvc.DeepCopy())
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+				err = r.Patch(ctx, &pvc, patch)
+				if err != nil {
+					return false, err
+				}
+			}
+
+			if pvc.Status.Capacity.Storage().Cmp(requested) < 0 {
+				claimStatus.State = k8schianetv1.VolumeResizeStateResizing
+				for _, condition := range pvc.Status.Conditions {
+					if condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending && condition.Status == corev1.ConditionTrue {
+						claimStatus.State = k8schianetv1.VolumeResizeStateFileSystemResizePending
+					}
+				}
+			}
+			if claimStatus.State != k8schianetv1.VolumeResizeStateComplete {
+				resizing = true
+			}
+			claimStatuses = append(claimStatuses, claimStatus)
+		}
+	}
+
+	if !resizing {
+		node.Status.VolumeResize = nil
+		return false, nil
+	}
+	node.Status.VolumeResize = &k8schianetv1.ChiaNodeVolumeResizeStatus{
+		Claims: claimStatuses,
+	}
+
+	return unsupported, nil
+}
+
+// isExpandable returns true if a claim's StorageClass allows volume expansion
+func (r *ChiaNodeReconciler) isExpandable(ctx context.Context, pvc corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+
+	var sc storagev1.StorageClass
+	err := r.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, &sc)
+	if err != nil && errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// newClaimTemplate gives a volume claim template with the given storage request
+func newClaimTemplate(name, request string) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(request)},
+			},
+		},
+	}
+}
+
+// newReplicaClaim gives a replica's claim with the given StorageClass, storage request and capacity, and optionally a pending file system resize
+func newReplicaClaim(name, storageClass, request, capacity string, fsResizePending bool) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "chia"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(request)},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+		},
+	}
+	if fsResizePending {
+		pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+			{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+		}
+	}
+	return pvc
+}
+
+// newStorageClass gives a StorageClass that does or doesn't allow volume expansion
+func newStorageClass(name string, allowExpansion bool) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: name},
+		Provisioner:          "example.com/csi",
+		AllowVolumeExpansion: &allowExpansion,
+	}
+}
+
+func TestResizeClaims(t *testing.T) {
+	storageClasses := []client.Object{newStorageClass("expandable", true), newStorageClass("fixed", false)}
+	tests := []struct {
+		name                string
+		claims              []client.Object
+		expectedUnsupported bool
+		expectedStatus      *k8schianetv1.ChiaNodeVolumeResizeStatus
+		expectedRequests    map[string]string
+	}{
+		{
+			name: "claims not created yet",
+		},
+		{
+			name: "claims already at the requested size",
+			claims: []client.Object{
+				newReplicaClaim("chiaroot-node-node-0", "expandable", "300Gi", "300Gi", false),
+				newReplicaClaim("chiaroot-node-node-1", "expandable", "300Gi", "300Gi", false),
+			},
+			expectedRequests: map[string]string{"chiaroot-node-node-0": "300Gi", "chiaroot-node-node-1": "300Gi"},
+		},
+		{
+			name: "expands claims",
+			claims: []client.Object{
+				newReplicaClaim("chiaroot-node-node-0", "expandable", "200Gi", "200Gi", false),
+				newReplicaClaim("chiaroot-node-node-1", "expandable", "300Gi", "200Gi", true),
+			},
+			expectedStatus: &k8schianetv1.ChiaNodeVolumeResizeStatus{
+				Claims: []k8schianetv1.ChiaNodeVolumeClaimResizeStatus{
+					{Name: "chiaroot-node-node-0", RequestedSize: "300Gi", Capacity: "200Gi", State: k8schianetv1.VolumeResizeStateResizing},
+					{Name: "chiaroot-node-node-1", RequestedSize: "300Gi", Capacity: "200Gi", State: k8schianetv1.VolumeResizeStateFileSystemResizePending},
+				},
+			},
+			expectedRequests: map[string]string{"chiaroot-node-node-0": "300Gi", "chiaroot-node-node-1": "300Gi"},
+		},
+		{
+			name: "storage class doesn't allow expansion",
+			claims: []client.Object{
+				newReplicaClaim("chiaroot-node-node-0", "fixed", "200Gi", "200Gi", false),
+				newReplicaClaim("chiaroot-node-node-1", "fixed", "200Gi", "200Gi", false),
+			},
+			expectedUnsupported: true,
+			expectedStatus: &k8schianetv1.ChiaNodeVolumeResizeStatus{
+				Claims: []k8schianetv1.ChiaNodeVolumeClaimResizeStatus{
+					{Name: "chiaroot-node-node-0", RequestedSize: "300Gi", Capacity: "200Gi", State: k8schianetv1.VolumeResizeStateUnsupported},
+					{Name: "chiaroot-node-node-1", RequestedSize: "300Gi", Capacity: "200Gi", State: k8schianetv1.VolumeResizeStateUnsupported},
+				},
+			},
+			expectedRequests: map[string]string{"chiaroot-node-node-0": "200Gi", "chiaroot-node-node-1": "200Gi"},
+		},
+		{
+			name: "storage class not found",
+			claims: []client.Object{
+				newReplicaClaim("chiaroot-node-node-0", "missing", "200Gi", "200Gi", false),
+			},
+			expectedUnsupported: true,
+			expectedStatus: &k8schianetv1.ChiaNodeVolumeResizeStatus{
+				Claims: []k8schianetv1.ChiaNodeVolumeClaimResizeStatus{
+					{Name: "chiaroot-node-node-0", RequestedSize: "300Gi", Capacity: "200Gi", State: k8schianetv1.VolumeResizeStateUnsupported},
+				},
+			},
+			expectedRequests: map[string]string{"chiaroot-node-node-0": "200Gi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.Replicas = 2
+			node.Status.VolumeResize = &k8schianetv1.ChiaNodeVolumeResizeStatus{}
+			r := &ChiaNodeReconciler{
+				Client: fake.NewClientBuilder().WithObjects(storageClasses...).WithObjects(tt.claims...).Build(),
+			}
+
+			unsupported, err := r.resizeClaims(context.Background(), &node, "node-node", []corev1.PersistentVolumeClaim{newClaimTemplate("chiaroot", "300Gi")})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if unsupported != tt.expectedUnsupported {
+				t.Errorf("Expected unsupported to be %t, got %t", tt.expectedUnsupported, unsupported)
+			}
+			if diff := cmp.Diff(tt.expectedStatus, node.Status.VolumeResize); diff != "" {
+				t.Errorf("Volume resize status does not match. Diff: %s", diff)
+			}
+
+			for name, expected := range tt.expectedRequests {
+				var pvc corev1.PersistentVolumeClaim
+				err = r.Get(context.Background(), types.NamespacedName{Namespace: "chia", Name: name}, &pvc)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				actual := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+				if actual.String() != expected {
+					t.Errorf("Expected claim %s to request %s, got %s", name, expected, actual.String())
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileVolumeClaimTemplates(t *testing.T) {
+	newStatefulSet := func(request string) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-node", Namespace: "chia"},
+			Spec: appsv1.StatefulSetSpec{
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{newClaimTemplate("chiaroot", request)},
+			},
+		}
+	}
+	tests := []struct {
+		name             string
+		objects          []client.Object
+		expectedRecreate bool
+		expectedTemplate string
+		expectedExists   bool
+	}{
+		{
+			name:             "no statefulset",
+			expectedTemplate: "300Gi",
+		},
+		{
+			name: "templates match",
+			objects: []client.Object{
+				newStatefulSet("300Gi"),
+				newReplicaClaim("chiaroot-node-node-0", "expandable", "300Gi", "300Gi", false),
+			},
+			expectedTemplate: "300Gi",
+			expectedExists:   true,
+		},
+		{
+			name: "larger request recreates the statefulset",
+			objects: []client.Object{
+				newStatefulSet("200Gi"),
+				newReplicaClaim("chiaroot-node-node-0", "expandable", "200Gi", "200Gi", false),
+				newStorageClass("expandable", true),
+			},
+			expectedRecreate: true,
+			expectedTemplate: "300Gi",
+		},
+		{
+			name: "unsupported resize keeps the current templates",
+			objects: []client.Object{
+				newStatefulSet("200Gi"),
+				newReplicaClaim("chiaroot-node-node-0", "fixed", "200Gi", "200Gi", false),
+				newStorageClass("fixed", false),
+			},
+			expectedTemplate: "200Gi",
+			expectedExists:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newSyncNode()
+			node.Spec.Replicas = 1
+			desired := newStatefulSet("300Gi")
+			r := &ChiaNodeReconciler{
+				Client: fake.NewClientBuilder().WithObjects(tt.objects...).Build(),
+			}
+
+			recreate, err := r.reconcileVolumeClaimTemplates(context.Background(), &node, desired)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if recreate != tt.expectedRecreate {
+				t.Errorf("Expected recreate to be %t, got %t", tt.expectedRecreate, recreate)
+			}
+			request := desired.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+			if request.String() != tt.expectedTemplate {
+				t.Errorf("Expected desired template to request %s, got %s", tt.expectedTemplate, request.String())
+			}
+
+			var current appsv1.StatefulSet
+			err = r.Get(context.Background(), types.NamespacedName{Namespace: "chia", Name: "node-node"}, &current)
+			if exists := !errors.IsNotFound(err); exists != tt.expectedExists {
+				t.Errorf("Expected StatefulSet to exist to be %t, got %t", tt.expectedExists, exists)
+			}
+		})
+	}
+}
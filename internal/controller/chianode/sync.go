@@ -0,0 +1,150 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// syncedLabel is set by the operator on each ChiaNode replica pod to "true" or "false" from its full_node's sync state.
+// The synced Service selects the pods where it's "true".
+const syncedLabel = "k8s.chia.net/synced"
+
+// isPodReady says whether a pod is running, not terminating, and passing its readiness checks
+func isPodReady(pod corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// syncQueryTimeout bounds how long the sync state queries of all of a ChiaNode's replicas may take, so an unresponsive replica
+// doesn't hold up the reconcile worker. Replicas are queried in parallel, and ones that don't answer in time are considered not synced.
+const syncQueryTimeout = 5 * time.Second
+
+// getReplicaRPCURL gives the base URL of a replica pod's full_node RPC server
+func getReplicaRPCURL(pod corev1.Pod) string {
+	return fmt.Sprintf("https://%s", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(consts.NodeRPCPort)))
+}
+
+// isReplicaPod says whether a pod belongs to the ChiaNode's StatefulSet
+func isReplicaPod(node k8schianetv1.ChiaNode, pod corev1.Pod) bool {
+	owner := metav1.GetControllerOf(&pod)
+	return owner != nil && owner.Kind == "StatefulSet" && owner.Name == fmt.Sprintf(chianodeNamePattern, node.Name)
+}
+
+// getReplicaSyncStates queries the full_node RPC server of each ready replica pod in parallel, and gives the names of the pods
+// that report a synced blockchain. Replicas that can't be queried are left out.
+func (r *ChiaNodeReconciler) getReplicaSyncStates(ctx context.Context, node k8schianetv1.ChiaNode, pods []corev1.Pod) map[string]bool {
+	ctx, cancel := context.WithTimeout(ctx, syncQueryTimeout)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		synced = make(map[string]bool)
+	)
+	opts := append([]chiarpc.Option{chiarpc.WithTimeout(syncQueryTimeout), chiarpc.WithRetries(0)}, r.RPCOptions...)
+	for _, pod := range pods {
+		if !isReplicaPod(node, pod) || !isPodReady(pod) {
+			continue
+		}
+
+		rpc, err := r.rpcClients.Get(ctx, r.Client, node.Namespace, node.Spec.ChiaConfig.CASecretName, getReplicaRPCURL(pod), opts...)
+		if err != nil {
+			log.FromContext(ctx).Info(fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s/%s unable to query sync state of replica %s: %v", node.Namespace, node.Name, pod.Name, err))
+			continue
+		}
+
+		wg.Add(1)
+		go func(podName string, rpc *chiarpc.Client) {
+			defer wg.Done()
+			state, err := rpc.GetBlockchainState(ctx)
+			if err != nil {
+				log.FromContext(ctx).Info(fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s/%s unable to query sync state of replica %s: %v", node.Namespace, node.Name, podName, err))
+				return
+			}
+			if state.BlockchainState.Sync.Synced {
+				mu.Lock()
+				synced[podName] = true
+				mu.Unlock()
+			}
+		}(pod.Name, rpc)
+	}
+	wg.Wait()
+
+	return synced
+}
+
+// reconcileSyncedLabels queries each of the ChiaNode's replicas for its sync state and labels its pod with the result,
+// so the synced Service only routes to healthy replicas that are synced. It gives the number of synced replicas.
+// Replicas that aren't ready or can't be queried are labeled as not synced.
+func (r *ChiaNodeReconciler) reconcileSyncedLabels(ctx context.Context, node k8schianetv1.ChiaNode) (int32, error) {
+	var pods corev1.PodList
+	err := r.List(ctx, &pods, client.InNamespace(node.Namespace), client.MatchingLabels(kube.GetCommonLabels(ctx, node.Kind, node.ObjectMeta)))
+	if err != nil {
+		return 0, err
+	}
+
+	return r.labelReplicaSyncStates(ctx, node, pods.Items, r.getReplicaSyncStates(ctx, node, pods.Items))
+}
+
+// labelReplicaSyncStates sets the synced label on each of the ChiaNode's replica pods from their sync states, recording an event when
+// a replica is added to or removed from the synced Service. It gives the number of synced replicas.
+func (r *ChiaNodeReconciler) labelReplicaSyncStates(ctx context.Context, node k8schianetv1.ChiaNode, pods []corev1.Pod, syncStates map[string]bool) (int32, error) {
+	var synced int32
+	for i, pod := range pods {
+		if !isReplicaPod(node, pod) {
+			continue
+		}
+
+		replicaSynced := syncStates[pod.Name]
+		if replicaSynced {
+			synced++
+		}
+
+		previous := pod.Labels[syncedLabel]
+		value := strconv.FormatBool(replicaSynced)
+		if previous == value {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pods[i].Labels == nil {
+			pods[i].Labels = make(map[string]string)
+		}
+		pods[i].Labels[syncedLabel] = value
+		err := r.Patch(ctx, &pods[i], patch)
+		if client.IgnoreNotFound(err) != nil {
+			return synced, err
+		}
+		if replicaSynced {
+			r.Recorder.Event(&node, corev1.EventTypeNormal, "ReplicaSynced", fmt.Sprintf("Replica %s is synced and was added to the synced Service.", pod.Name))
+		} else if previous == "true" {
+			r.Recorder.Event(&node, corev1.EventTypeWarning, "ReplicaNotSynced", fmt.Sprintf("Replica %s is no longer synced and was removed from the synced Service.", pod.Name))
+		}
+	}
+
+	return synced, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chianode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/chiarpc/chiarpctest"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// newSyncNode gives a ChiaNode for the sync label tests
+func newSyncNode() k8schianetv1.ChiaNode {
+	return k8schianetv1.ChiaNode{
+		TypeMeta:   metav1.TypeMeta{Kind: "ChiaNode", APIVersion: "k8s.chia.net/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "node", Namespace: "chia"},
+	}
+}
+
+// newReplicaPod gives a pod with the ChiaNode's labels, owned by the given StatefulSet, with the given synced label and readiness
+func newReplicaPod(node k8schianetv1.ChiaNode, name, owner, synced string, ready bool) *corev1.Pod {
+	labels := kube.GetCommonLabels(context.Background(), node.Kind, node.ObjectMeta)
+	if synced != "" {
+		labels[syncedLabel] = synced
+	}
+	controller := true
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: node.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "StatefulSet", Name: owner, UID: "sts", Controller: &controller},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      "10.0.0.1",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus}},
+		},
+	}
+}
+
+func TestIsPodReady(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name     string
+		pod      corev1.Pod
+		expected bool
+	}{
+		{
+			name: "ready",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      "10.0.0.1",
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			}},
+			expected: true,
+		},
+		{
+			name: "not ready",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      "10.0.0.1",
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			}},
+			expected: false,
+		},
+		{
+			name: "no ready condition",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				PodIP: "10.0.0.1",
+			}},
+			expected: false,
+		},
+		{
+			name: "pending",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase:      corev1.PodPending,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			}},
+			expected: false,
+		},
+		{
+			name: "terminating",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					PodIP:      "10.0.0.1",
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := isPodReady(tt.pod); actual != tt.expected {
+				t.Errorf("Expected isPodReady to be %t, got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReconcileSyncedLabels(t *testing.T) {
+	node := newSyncNode()
+	// None of the replicas are ready, so they're labeled as not synced without querying their RPC servers
+	r := &ChiaNodeReconciler{
+		Client: fake.NewClientBuilder().WithObjects(
+			newReplicaPod(node, "node-node-0", "node-node", "true", false),
+			newReplicaPod(node, "node-node-1", "node-node", "", false),
+			newReplicaPod(node, "other-0", "other", "true", false),
+		).Build(),
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	synced, err := r.reconcileSyncedLabels(context.Background(), node)
+	if err != nil {
+		t.Fatalf("Error reconciling synced labels: %v", err)
+	}
+	if synced != 0 {
+		t.Errorf("Expected 0 synced replicas, got %d", synced)
+	}
+
+	expected := map[string]string{
+		"node-node-0": "false",
+		"node-node-1": "false",
+		"other-0":     "true",
+	}
+	actual := make(map[string]string)
+	for name := range expected {
+		var pod corev1.Pod
+		err = r.Get(context.Background(), types.NamespacedName{Namespace: node.Namespace, Name: name}, &pod)
+		if err != nil {
+			t.Fatalf("Error getting pod %s: %v", name, err)
+		}
+		actual[name] = pod.Labels[syncedLabel]
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Synced labels do not match. Diff: %s", diff)
+	}
+
+	events := r.Recorder.(*record.FakeRecorder).Events
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if event := <-events; event != "Warning ReplicaNotSynced Replica node-node-0 is no longer synced and was removed from the synced Service." {
+		t.Errorf("Unexpected event: %s", event)
+	}
+}
+
+func TestLabelReplicaSyncStates(t *testing.T) {
+	node := newSyncNode()
+	pods := []*corev1.Pod{
+		newReplicaPod(node, "node-node-0", "node-node", "false", true),
+		newReplicaPod(node, "node-node-1", "node-node", "true", true),
+		newReplicaPod(node, "node-node-2", "node-node", "", true),
+	}
+	builder := fake.NewClientBuilder()
+	var items []corev1.Pod
+	for _, pod := range pods {
+		builder = builder.WithObjects(pod)
+		items = append(items, *pod)
+	}
+	r := &ChiaNodeReconciler{
+		Client:   builder.Build(),
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	// Fetch the pods so they have the resource versions the fake client expects in patches
+	for i := range items {
+		err := r.Get(context.Background(), types.NamespacedName{Namespace: node.Namespace, Name: items[i].Name}, &items[i])
+		if err != nil {
+			t.Fatalf("Error getting pod %s: %v", items[i].Name, err)
+		}
+	}
+
+	synced, err := r.labelReplicaSyncStates(context.Background(), node, items, map[string]bool{
+		"node-node-0": true,
+		"node-node-1": true,
+	})
+	if err != nil {
+		t.Fatalf("Error labeling replica sync states: %v", err)
+	}
+	if synced != 2 {
+		t.Errorf("Expected 2 synced replicas, got %d", synced)
+	}
+
+	expected := map[string]string{
+		"node-node-0": "true",
+		"node-node-1": "true",
+		"node-node-2": "false",
+	}
+	actual := make(map[string]string)
+	for name := range expected {
+		var pod corev1.Pod
+		err = r.Get(context.Background(), types.NamespacedName{Namespace: node.Namespace, Name: name}, &pod)
+		if err != nil {
+			t.Fatalf("Error getting pod %s: %v", name, err)
+		}
+		actual[name] = pod.Labels[syncedLabel]
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Synced labels do not match. Diff: %s", diff)
+	}
+
+	events := r.Recorder.(*record.FakeRecorder).Events
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if event := <-events; event != "Normal ReplicaSynced Replica node-node-0 is synced and was added to the synced Service." {
+		t.Errorf("Unexpected event: %s", event)
+	}
+}
+
+func TestGetReplicaSyncStates(t *testing.T) {
+	caData, err := chiarpctest.NewCASecretData()
+	if err != nil {
+		t.Fatalf("Error generating CA: %v", err)
+	}
+	server, err := chiarpctest.NewServer(caData)
+	if err != nil {
+		t.Fatalf("Error starting fake RPC server: %v", err)
+	}
+	defer server.Close()
+	server.SetResponse("get_blockchain_state", map[string]interface{}{
+		"success": true,
+		"blockchain_state": map[string]interface{}{
+			"sync": map[string]interface{}{
+				"synced": true,
+			},
+		},
+	})
+
+	node := newSyncNode()
+	node.Spec.ChiaConfig.CASecretName = "chiaca-secret"
+	r := &ChiaNodeReconciler{
+		Client: fake.NewClientBuilder().WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "chiaca-secret", Namespace: node.Namespace},
+			Data:       caData,
+		}).Build(),
+		Recorder:   record.NewFakeRecorder(10),
+		RPCOptions: []chiarpc.Option{server.DialOption()},
+	}
+
+	// Only ready replicas of this ChiaNode are queried
+	pods := []corev1.Pod{
+		*newReplicaPod(node, "node-node-0", "node-node", "", true),
+		*newReplicaPod(node, "node-node-1", "node-node", "", false),
+		*newReplicaPod(node, "other-0", "other", "", true),
+	}
+	actual := r.getReplicaSyncStates(context.Background(), node, pods)
+
+	expected := map[string]bool{
+		"node-node-0": true,
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Replica sync states do not match. Diff: %s", diff)
+	}
+	if requests := server.Requests("get_blockchain_state"); requests != 1 {
+		t.Errorf("Expected 1 get_blockchain_state request, got %d", requests)
+	}
+}
@@ -10,7 +10,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -22,7 +24,7 @@ const chiaseederNamePattern = "%s-seeder"
 
 // assembleBaseService assembles the main Service resource for a ChiaSeeder CR
 func (r *ChiaSeederReconciler) assembleBaseService(ctx context.Context, seeder k8schianetv1.ChiaSeeder) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiaseederNamePattern, seeder.Name),
 			Namespace:       seeder.Namespace,
@@ -67,11 +69,14 @@ func (r *ChiaSeederReconciler) assembleBaseService(ctx context.Context, seeder k
 			Selector: kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta, seeder.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, seeder.Spec.Service)
+	return srv
 }
 
 // assembleChiaExporterService assembles the chia-exporter Service resource for a ChiaSeeder CR
 func (r *ChiaSeederReconciler) assembleChiaExporterService(ctx context.Context, seeder k8schianetv1.ChiaSeeder) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiaseederNamePattern, seeder.Name) + "-metrics",
 			Namespace:       seeder.Namespace,
@@ -92,6 +97,35 @@ func (r *ChiaSeederReconciler) assembleChiaExporterService(ctx context.Context,
 			Selector: kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta, seeder.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, seeder.Spec.ChiaExporterConfig.Service)
+	return srv
+}
+
+// assembleNetworkPolicy assembles the NetworkPolicy resource for a ChiaSeeder CR
+func (r *ChiaSeederReconciler) assembleNetworkPolicy(ctx context.Context, seeder k8schianetv1.ChiaSeeder) networkingv1.NetworkPolicy {
+	meta := metav1.ObjectMeta{
+		Name:            fmt.Sprintf(chiaseederNamePattern, seeder.Name),
+		Namespace:       seeder.Namespace,
+		Labels:          kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta, seeder.Spec.AdditionalMetadata.Labels),
+		Annotations:     seeder.Spec.AdditionalMetadata.Annotations,
+		OwnerReferences: r.getOwnerReference(ctx, seeder),
+	}
+	return kube.AssembleNetworkPolicy(ctx, meta, kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta), seeder.Spec.NetworkPolicy,
+		kube.NamedPort("peers", corev1.ProtocolTCP),
+		kube.NamedPort("dns", corev1.ProtocolUDP),
+		kube.NamedPort("dns-tcp", corev1.ProtocolTCP),
+	)
+}
+
+// assembleChiaExporterMonitor assembles the Prometheus Operator monitor for a ChiaSeeder CR's chia-exporter, or gives nil if it doesn't want one
+func (r *ChiaSeederReconciler) assembleChiaExporterMonitor(ctx context.Context, seeder k8schianetv1.ChiaSeeder) (*unstructured.Unstructured, error) {
+	return kube.AssembleChiaExporterMonitor(ctx, seeder.Kind, seeder.ObjectMeta, fmt.Sprintf(chiaseederNamePattern, seeder.Name), seeder.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, seeder), seeder.Spec.ChiaExporterConfig)
+}
+
+// assemblePrometheusRule assembles the PrometheusRule with a ChiaSeeder CR's alerts, or gives nil if it doesn't want one
+func (r *ChiaSeederReconciler) assemblePrometheusRule(ctx context.Context, seeder k8schianetv1.ChiaSeeder) *unstructured.Unstructured {
+	return kube.AssemblePrometheusRule(ctx, seeder.Kind, seeder.ObjectMeta, fmt.Sprintf(chiaseederNamePattern, seeder.Name), seeder.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, seeder), seeder.Spec.Alerting)
 }
 
 // assembleDeployment assembles the Deployment resource for a ChiaSeeder CR
@@ -115,11 +149,13 @@ func (r *ChiaSeederReconciler) assembleDeployment(ctx context.Context, seeder k8
 				},
 				Spec: corev1.PodSpec{
 					// TODO add: imagePullSecret, serviceAccountName config
+					InitContainers: r.getInitContainers(ctx, seeder),
 					Containers: []corev1.Container{
 						{
 							Name:            "chia",
 							Image:           seeder.Spec.ChiaConfig.Image,
 							ImagePullPolicy: seeder.Spec.ImagePullPolicy,
+							Lifecycle:       kube.GetChiaLifecycle(ctx),
 							Env:             r.getChiaEnv(ctx, seeder),
 							Ports: []corev1.ContainerPort{
 								{
@@ -158,8 +194,9 @@ func (r *ChiaSeederReconciler) assembleDeployment(ctx context.Context, seeder k8
 							},
 						},
 					},
-					NodeSelector: seeder.Spec.NodeSelector,
-					Volumes:      r.getChiaVolumes(ctx, seeder),
+					NodeSelector:                  seeder.Spec.NodeSelector,
+					TerminationGracePeriodSeconds: kube.GetTerminationGracePeriodSeconds(ctx, seeder.Spec.TerminationGracePeriodSeconds, consts.SeederTerminationGracePeriodSeconds),
+					Volumes:                       r.getChiaVolumes(ctx, seeder),
 				},
 			},
 		},
@@ -183,14 +220,12 @@ func (r *ChiaSeederReconciler) assembleDeployment(ctx context.Context, seeder k8
 		deploy.Spec.Template.Spec.Containers[0].StartupProbe = seeder.Spec.ChiaConfig.StartupProbe
 	}
 
-	var containerResorces corev1.ResourceRequirements
 	if seeder.Spec.ChiaConfig.Resources != nil {
-		containerResorces = *seeder.Spec.ChiaConfig.Resources
 		deploy.Spec.Template.Spec.Containers[0].Resources = *seeder.Spec.ChiaConfig.Resources
 	}
 
 	if seeder.Spec.ChiaExporterConfig.Enabled {
-		exporterContainer := kube.GetChiaExporterContainer(ctx, seeder.Spec.ChiaExporterConfig.Image, containerSecurityContext, seeder.Spec.ImagePullPolicy, containerResorces)
+		exporterContainer := kube.GetChiaExporterContainer(ctx, seeder.Spec.ChiaExporterConfig, containerSecurityContext, seeder.Spec.ImagePullPolicy)
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, exporterContainer)
 	}
 
@@ -202,7 +237,19 @@ func (r *ChiaSeederReconciler) assembleDeployment(ctx context.Context, seeder k8
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, seeder.Spec.Sidecars.Containers...)
 	}
 
+	// A rolling update would briefly run two seeders against the same ReadWriteOnce claim
+	if kube.GetGeneratedChiaRootClaimConfig(getStorageConfig(seeder)) != nil {
+		deploy.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+
 	// TODO add pod affinity, tolerations
 
 	return deploy
 }
+
+// assembleChiaRootClaim assembles the CHIA_ROOT PersistentVolumeClaim for a ChiaSeeder CR that gives a storage class and size instead of an existing claim
+func (r *ChiaSeederReconciler) assembleChiaRootClaim(ctx context.Context, seeder k8schianetv1.ChiaSeeder, config k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	return kube.AssembleChiaRootClaim(ctx, fmt.Sprintf(chiaseederNamePattern, seeder.Name), seeder.Namespace, kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta, seeder.Spec.AdditionalMetadata.Labels), r.getOwnerReference(ctx, seeder), config)
+}
@@ -23,9 +23,11 @@ package chiaseeder
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -34,6 +36,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	"github.com/cisco-open/operator-tools/pkg/reconciler"
@@ -44,20 +47,26 @@ type ChiaSeederReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
-}
 
-var chiaseeders map[string]bool = make(map[string]bool)
+	// PrometheusOperator says which Prometheus Operator kinds the cluster serves. chia-exporter monitors and alerting rules require their kind's CRD.
+	PrometheusOperator kube.PrometheusOperatorAPIs
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaseeders,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaseeders/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaseeders/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *ChiaSeederReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaSeeder", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaSeederReconciler ChiaSeeder=%s", req.NamespacedName.String()))
@@ -66,56 +75,113 @@ func (r *ChiaSeederReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	var seeder k8schianetv1.ChiaSeeder
 	err := r.Get(ctx, req.NamespacedName, &seeder)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiaseeders[req.NamespacedName.String()]
-		if exists {
-			delete(chiaseeders, req.NamespacedName.String())
-			metrics.ChiaSeeders.Sub(1.0)
+		// chia-exporter monitors and PrometheusRules in other namespaces aren't garbage collected with the ChiaSeeder
+		err = kube.RemoveChiaExporterMonitors(ctx, r.Client, r.PrometheusOperator, kube.GetCommonLabels(ctx, "ChiaSeeder", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaSeeder", "Monitor")
+			return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error removing seeder chia-exporter monitors: %v", req.NamespacedName, err)
+		}
+		if r.PrometheusOperator.PrometheusRules {
+			err = kube.ReconcilePrometheusRule(ctx, r.Client, nil, kube.GetCommonLabels(ctx, "ChiaSeeder", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+			if err != nil {
+				metrics.RecordReconcileError("ChiaSeeder", "PrometheusRule")
+				return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error removing seeder PrometheusRules: %v", req.NamespacedName, err)
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaSeeder", "ChiaSeeder")
 		log.Error(err, fmt.Sprintf("ChiaSeederReconciler ChiaSeeder=%s unable to fetch ChiaSeeder resource", req.NamespacedName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiaseeders[req.NamespacedName.String()]
-	if !exists {
-		chiaseeders[req.NamespacedName.String()] = true
-		metrics.ChiaSeeders.Add(1.0)
-	}
-
 	srv := r.assembleBaseService(ctx, seeder)
-	res, err := kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err := kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, seeder.Spec.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaSeeder", "Service")
 		r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling Service: %v", req.NamespacedName, err)
 	}
 
 	srv = r.assembleChiaExporterService(ctx, seeder)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, seeder.Spec.ChiaExporterConfig.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaSeeder", "Service")
 		r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder metrics Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling chia-exporter Service: %v", req.NamespacedName, err)
 	}
 
+	policy := r.assembleNetworkPolicy(ctx, seeder)
+	if seeder.Spec.NetworkPolicy.Enabled {
+		res, err = kube.ReconcileNetworkPolicy(ctx, resourceReconciler, policy)
+	} else {
+		res, err = kube.RemoveNetworkPolicy(ctx, resourceReconciler, policy)
+	}
+	if err != nil {
+		if res == nil {
+			res = &reconcile.Result{}
+		}
+		metrics.RecordReconcileError("ChiaSeeder", "NetworkPolicy")
+		r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder NetworkPolicy -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling seeder NetworkPolicy: %v", req.NamespacedName, err)
+	}
+
+	// Scrape chia-exporter with a Prometheus Operator monitor if its CRD is installed
+	if len(r.PrometheusOperator.MonitorKinds()) > 0 {
+		monitor, err := r.assembleChiaExporterMonitor(ctx, seeder)
+		if err == nil {
+			err = kube.ReconcileChiaExporterMonitor(ctx, r.Client, r.PrometheusOperator, monitor, kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta))
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaSeeder", "Monitor")
+			r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder chia-exporter monitor -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling seeder chia-exporter monitor: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &seeder, &seeder.Status.Conditions, consts.MonitorsDisabledCondition, !r.PrometheusOperator.ServesMonitor(seeder.Spec.ChiaExporterConfig.Monitor),
+		"A chia-exporter monitor is configured but its Prometheus Operator CRD was not found when the operator started.")
+
+	// Create the PrometheusRule with the seeder's alerts if the CRD is installed
+	if r.PrometheusOperator.PrometheusRules {
+		err = kube.ReconcilePrometheusRule(ctx, r.Client, r.assemblePrometheusRule(ctx, seeder), kube.GetCommonLabels(ctx, seeder.Kind, seeder.ObjectMeta))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaSeeder", "PrometheusRule")
+			r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder PrometheusRule -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling seeder PrometheusRule: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &seeder, &seeder.Status.Conditions, consts.AlertingDisabledCondition, seeder.Spec.Alerting != nil && !r.PrometheusOperator.PrometheusRules,
+		"Alerting is configured but the PrometheusRule CRD was not found when the operator started.")
+	kube.SetWarningCondition(r.Recorder, &seeder, &seeder.Status.Conditions, consts.AlertingWithoutMonitorCondition, seeder.Spec.Alerting != nil && (seeder.Spec.ChiaExporterConfig.Monitor == nil || !seeder.Spec.ChiaExporterConfig.Enabled),
+		"Alerting is configured without a chia-exporter monitor. Alerts select metrics by their k8s_chia_net_provenance label, which your own scrape config must add.")
+
+	// Create the CHIA_ROOT PersistentVolumeClaim if a storage class and size were given instead of an existing claim
+	if pvcConfig := kube.GetGeneratedChiaRootClaimConfig(getStorageConfig(seeder)); pvcConfig != nil {
+		pvc, err := r.assembleChiaRootClaim(ctx, seeder, *pvcConfig)
+		if err == nil {
+			err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, pvc)
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaSeeder", "PersistentVolumeClaim")
+			r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder CHIA_ROOT PersistentVolumeClaim -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling seeder CHIA_ROOT PersistentVolumeClaim: %v", req.NamespacedName, err)
+		}
+	}
+
 	deploy := r.assembleDeployment(ctx, seeder)
 	res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaSeeder", "Deployment")
 		r.Recorder.Event(&seeder, corev1.EventTypeWarning, "Failed", "Failed to create seeder Deployment -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error reconciling Deployment: %v", req.NamespacedName, err)
 	}
@@ -125,7 +191,7 @@ func (r *ChiaSeederReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	seeder.Status.Ready = true
 	err = r.Status().Update(ctx, &seeder)
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaSeeder", "ChiaSeeder")
 		log.Error(err, fmt.Sprintf("ChiaSeederReconciler ChiaSeeder=%s unable to update ChiaSeeder status", req.NamespacedName))
 		return ctrl.Result{}, err
 	}
@@ -14,8 +14,17 @@ import (
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
+// configureDatabaseScript points the crawler database at the dedicated database volume. The image entrypoint creates
+// the chia config before running this script, and moves an existing crawler database from CHIA_ROOT on first run.
+const configureDatabaseScript = `set -o errexit
+if [ -f "${CHIA_ROOT}/crawler.db" ] && [ ! -f "${CHIA_ROOT}/db/crawler.db" ]; then
+  mv "${CHIA_ROOT}"/crawler.db* "${CHIA_ROOT}/db/"
+fi
+yq -i '.seeder.crawler_db_path = "db/crawler.db"' "${CHIA_ROOT}/config/config.yaml"`
+
 // getChiaVolumes retrieves the requisite volumes from the Chia config struct
 func (r *ChiaSeederReconciler) getChiaVolumes(ctx context.Context, seeder k8schianetv1.ChiaSeeder) []corev1.Volume {
 	var v []corev1.Volume
@@ -39,7 +48,7 @@ func (r *ChiaSeederReconciler) getChiaVolumes(ctx context.Context, seeder k8schi
 				Name: "chiaroot",
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: seeder.Spec.Storage.ChiaRoot.PersistentVolumeClaim.ClaimName,
+						ClaimName: kube.GetChiaRootClaimName(fmt.Sprintf(chiaseederNamePattern, seeder.Name), getStorageConfig(seeder)),
 					},
 				},
 			})
@@ -65,6 +74,30 @@ func (r *ChiaSeederReconciler) getChiaVolumes(ctx context.Context, seeder k8schi
 		})
 	}
 
+	// Dedicated database volume -- PVC is respected first if both it and hostpath are specified, falls back to hostPath if specified
+	// If both are empty, the database stays in the CHIA_ROOT volume
+	if seeder.Spec.Storage != nil && seeder.Spec.Storage.Database != nil {
+		if seeder.Spec.Storage.Database.PersistentVolumeClaim != nil {
+			v = append(v, corev1.Volume{
+				Name: "chiadb",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: seeder.Spec.Storage.Database.PersistentVolumeClaim.ClaimName,
+					},
+				},
+			})
+		} else if seeder.Spec.Storage.Database.HostPathVolume != nil {
+			v = append(v, corev1.Volume{
+				Name: "chiadb",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: seeder.Spec.Storage.Database.HostPathVolume.Path,
+					},
+				},
+			})
+		}
+	}
+
 	// Add sidecar volumes if any exist
 	if len(seeder.Spec.Sidecars.Volumes) > 0 {
 		v = append(v, seeder.Spec.Sidecars.Volumes...)
@@ -89,9 +122,52 @@ func (r *ChiaSeederReconciler) getChiaVolumeMounts(ctx context.Context, seeder k
 		MountPath: "/chia-data",
 	})
 
+	// Dedicated database volume
+	if hasDatabaseVolume(seeder) {
+		v = append(v, corev1.VolumeMount{
+			Name:      "chiadb",
+			MountPath: "/chia-data/db",
+		})
+	}
+
 	return v
 }
 
+// getStorageConfig gives the seeder's CHIA_ROOT storage config, or nil if it doesn't have one
+func getStorageConfig(seeder k8schianetv1.ChiaSeeder) *k8schianetv1.StorageConfig {
+	if seeder.Spec.Storage == nil {
+		return nil
+	}
+	return &seeder.Spec.Storage.StorageConfig
+}
+
+// hasDatabaseVolume returns true if the seeder stores its database directory on a dedicated volume
+func hasDatabaseVolume(seeder k8schianetv1.ChiaSeeder) bool {
+	return seeder.Spec.Storage != nil && seeder.Spec.Storage.Database != nil &&
+		(seeder.Spec.Storage.Database.PersistentVolumeClaim != nil || seeder.Spec.Storage.Database.HostPathVolume != nil)
+}
+
+// getInitContainers assembles the init containers that prepare CHIA_ROOT before the chia container starts.
+// The crawler keeps its database at the top of CHIA_ROOT by default, so with a dedicated database volume it is moved into the db directory.
+func (r *ChiaSeederReconciler) getInitContainers(ctx context.Context, seeder k8schianetv1.ChiaSeeder) []corev1.Container {
+	var containers []corev1.Container
+	if !hasDatabaseVolume(seeder) {
+		return containers
+	}
+
+	containers = append(containers, corev1.Container{
+		Name:            "configure-db",
+		Image:           seeder.Spec.ChiaConfig.Image,
+		ImagePullPolicy: seeder.Spec.ImagePullPolicy,
+		Args:            []string{"/bin/bash", "-c", configureDatabaseScript},
+		Env:             r.getChiaEnv(ctx, seeder),
+		VolumeMounts:    r.getChiaVolumeMounts(ctx, seeder),
+		SecurityContext: seeder.Spec.ChiaConfig.SecurityContext,
+	})
+
+	return containers
+}
+
 // getChiaEnv retrieves the environment variables from the Chia config struct
 func (r *ChiaSeederReconciler) getChiaEnv(ctx context.Context, seeder k8schianetv1.ChiaSeeder) []corev1.EnvVar {
 	var env []corev1.EnvVar
@@ -10,7 +10,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -22,7 +24,7 @@ const chiatimelordNamePattern = "%s-timelord"
 
 // assembleBaseService assembles the main Service resource for a Chiatl CR
 func (r *ChiaTimelordReconciler) assembleBaseService(ctx context.Context, tl k8schianetv1.ChiaTimelord) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiatimelordNamePattern, tl.Name),
 			Namespace:       tl.Namespace,
@@ -55,11 +57,14 @@ func (r *ChiaTimelordReconciler) assembleBaseService(ctx context.Context, tl k8s
 			Selector: kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta, tl.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, tl.Spec.Service)
+	return srv
 }
 
 // assembleChiaExporterService assembles the chia-exporter Service resource for a ChiaTimelord CR
 func (r *ChiaTimelordReconciler) assembleChiaExporterService(ctx context.Context, tl k8schianetv1.ChiaTimelord) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiatimelordNamePattern, tl.Name) + "metrics",
 			Namespace:       tl.Namespace,
@@ -80,6 +85,31 @@ func (r *ChiaTimelordReconciler) assembleChiaExporterService(ctx context.Context
 			Selector: kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta, tl.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, tl.Spec.ChiaExporterConfig.Service)
+	return srv
+}
+
+// assembleNetworkPolicy assembles the NetworkPolicy resource for a ChiaTimelord CR
+func (r *ChiaTimelordReconciler) assembleNetworkPolicy(ctx context.Context, tl k8schianetv1.ChiaTimelord) networkingv1.NetworkPolicy {
+	meta := metav1.ObjectMeta{
+		Name:            fmt.Sprintf(chiatimelordNamePattern, tl.Name),
+		Namespace:       tl.Namespace,
+		Labels:          kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta, tl.Spec.AdditionalMetadata.Labels),
+		Annotations:     tl.Spec.AdditionalMetadata.Annotations,
+		OwnerReferences: r.getOwnerReference(ctx, tl),
+	}
+	return kube.AssembleNetworkPolicy(ctx, meta, kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta), tl.Spec.NetworkPolicy, kube.NamedPort("peers", corev1.ProtocolTCP))
+}
+
+// assembleChiaExporterMonitor assembles the Prometheus Operator monitor for a ChiaTimelord CR's chia-exporter, or gives nil if it doesn't want one
+func (r *ChiaTimelordReconciler) assembleChiaExporterMonitor(ctx context.Context, tl k8schianetv1.ChiaTimelord) (*unstructured.Unstructured, error) {
+	return kube.AssembleChiaExporterMonitor(ctx, tl.Kind, tl.ObjectMeta, fmt.Sprintf(chiatimelordNamePattern, tl.Name), tl.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, tl), tl.Spec.ChiaExporterConfig)
+}
+
+// assemblePrometheusRule assembles the PrometheusRule with a ChiaTimelord CR's alerts, or gives nil if it doesn't want one
+func (r *ChiaTimelordReconciler) assemblePrometheusRule(ctx context.Context, tl k8schianetv1.ChiaTimelord) *unstructured.Unstructured {
+	return kube.AssemblePrometheusRule(ctx, tl.Kind, tl.ObjectMeta, fmt.Sprintf(chiatimelordNamePattern, tl.Name), tl.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, tl), tl.Spec.Alerting)
 }
 
 // assembleDeployment assembles the tl Deployment resource for a ChiaTimelord CR
@@ -108,6 +138,7 @@ func (r *ChiaTimelordReconciler) assembleDeployment(ctx context.Context, tl k8sc
 							Name:            "chia",
 							Image:           tl.Spec.ChiaConfig.Image,
 							ImagePullPolicy: tl.Spec.ImagePullPolicy,
+							Lifecycle:       kube.GetChiaLifecycle(ctx),
 							Env:             r.getChiaEnv(ctx, tl),
 							Ports: []corev1.ContainerPort{
 								{
@@ -138,8 +169,9 @@ func (r *ChiaTimelordReconciler) assembleDeployment(ctx context.Context, tl k8sc
 							},
 						},
 					},
-					NodeSelector: tl.Spec.NodeSelector,
-					Volumes:      r.getChiaVolumes(ctx, tl),
+					NodeSelector:                  tl.Spec.NodeSelector,
+					TerminationGracePeriodSeconds: kube.GetTerminationGracePeriodSeconds(ctx, tl.Spec.TerminationGracePeriodSeconds, consts.TimelordTerminationGracePeriodSeconds),
+					Volumes:                       r.getChiaVolumes(ctx, tl),
 				},
 			},
 		},
@@ -163,14 +195,12 @@ func (r *ChiaTimelordReconciler) assembleDeployment(ctx context.Context, tl k8sc
 		deploy.Spec.Template.Spec.Containers[0].StartupProbe = tl.Spec.ChiaConfig.StartupProbe
 	}
 
-	var containerResorces corev1.ResourceRequirements
 	if tl.Spec.ChiaConfig.Resources != nil {
-		containerResorces = *tl.Spec.ChiaConfig.Resources
 		deploy.Spec.Template.Spec.Containers[0].Resources = *tl.Spec.ChiaConfig.Resources
 	}
 
 	if tl.Spec.ChiaExporterConfig.Enabled {
-		exporterContainer := kube.GetChiaExporterContainer(ctx, tl.Spec.ChiaExporterConfig.Image, containerSecurityContext, tl.Spec.ImagePullPolicy, containerResorces)
+		exporterContainer := kube.GetChiaExporterContainer(ctx, tl.Spec.ChiaExporterConfig, containerSecurityContext, tl.Spec.ImagePullPolicy)
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, exporterContainer)
 	}
 
@@ -182,7 +212,19 @@ func (r *ChiaTimelordReconciler) assembleDeployment(ctx context.Context, tl k8sc
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, tl.Spec.Sidecars.Containers...)
 	}
 
+	// A rolling update would briefly run two timelords against the same ReadWriteOnce claim
+	if kube.GetGeneratedChiaRootClaimConfig(tl.Spec.Storage) != nil {
+		deploy.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+
 	// TODO add pod affinity, tolerations
 
 	return deploy
 }
+
+// assembleChiaRootClaim assembles the CHIA_ROOT PersistentVolumeClaim for a ChiaTimelord CR that gives a storage class and size instead of an existing claim
+func (r *ChiaTimelordReconciler) assembleChiaRootClaim(ctx context.Context, tl k8schianetv1.ChiaTimelord, config k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	return kube.AssembleChiaRootClaim(ctx, fmt.Sprintf(chiatimelordNamePattern, tl.Name), tl.Namespace, kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta, tl.Spec.AdditionalMetadata.Labels), r.getOwnerReference(ctx, tl), config)
+}
@@ -7,9 +7,12 @@ package chiatimelord
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -18,6 +21,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	"github.com/cisco-open/operator-tools/pkg/reconciler"
@@ -28,18 +33,39 @@ type ChiaTimelordReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// PrometheusOperator says which Prometheus Operator kinds the cluster serves. chia-exporter monitors and alerting rules require their kind's CRD.
+	PrometheusOperator kube.PrometheusOperatorAPIs
+
+	// RPCOptions are applied to every timelord RPC client, like to route the health checks to a fake RPC server in tests
+	RPCOptions []chiarpc.Option
+
+	// rpcClients reuses timelord RPC clients across the periodic health checks
+	rpcClients chiarpc.ClientCache
 }
 
-var chiatimelords map[string]bool = make(map[string]bool)
+const (
+	// defaultIdleThreshold is the idle threshold used when a ChiaTimelord does not specify one
+	defaultIdleThreshold = 10 * time.Minute
+
+	// healthCheckInterval is how often a ChiaTimelord is requeued to refresh its RPC health status
+	healthCheckInterval = time.Minute
+)
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiatimelords,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiatimelords/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiatimelords/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *ChiaTimelordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaTimelord", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaTimelordController ChiaTimelord=%s", req.NamespacedName.String()))
@@ -48,72 +74,137 @@ func (r *ChiaTimelordReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	var tl k8schianetv1.ChiaTimelord
 	err := r.Get(ctx, req.NamespacedName, &tl)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiatimelords[req.NamespacedName.String()]
-		if exists {
-			delete(chiatimelords, req.NamespacedName.String())
-			metrics.ChiaTimelords.Sub(1.0)
+		// chia-exporter monitors and PrometheusRules in other namespaces aren't garbage collected with the ChiaTimelord
+		err = kube.RemoveChiaExporterMonitors(ctx, r.Client, r.PrometheusOperator, kube.GetCommonLabels(ctx, "ChiaTimelord", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaTimelord", "Monitor")
+			return ctrl.Result{}, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error removing timelord chia-exporter monitors: %v", req.NamespacedName, err)
+		}
+		if r.PrometheusOperator.PrometheusRules {
+			err = kube.ReconcilePrometheusRule(ctx, r.Client, nil, kube.GetCommonLabels(ctx, "ChiaTimelord", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+			if err != nil {
+				metrics.RecordReconcileError("ChiaTimelord", "PrometheusRule")
+				return ctrl.Result{}, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error removing timelord PrometheusRules: %v", req.NamespacedName, err)
+			}
 		}
+		r.rpcClients.Remove(chiarpc.ServiceURL(fmt.Sprintf(chiatimelordNamePattern, req.Name), req.Namespace, consts.TimelordRPCPort))
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaTimelord", "ChiaTimelord")
 		log.Error(err, fmt.Sprintf("ChiaTimelordController ChiaTimelord=%s unable to fetch ChiaTimelord resource", req.NamespacedName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiatimelords[req.NamespacedName.String()]
-	if !exists {
-		chiatimelords[req.NamespacedName.String()] = true
-		metrics.ChiaTimelords.Add(1.0)
-	}
+	previousStatus := tl.Status.DeepCopy()
 
 	// Reconcile ChiaTimelord owned objects
 	srv := r.assembleBaseService(ctx, tl)
-	res, err := kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err := kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, tl.Spec.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaTimelord", "Service")
 		r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling node Service: %v", req.NamespacedName, err)
 	}
 
 	srv = r.assembleChiaExporterService(ctx, tl)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, srv)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, srv, tl.Spec.ChiaExporterConfig.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaTimelord", "Service")
 		r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord metrics Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling node chia-exporter Service: %v", req.NamespacedName, err)
 	}
 
+	policy := r.assembleNetworkPolicy(ctx, tl)
+	if tl.Spec.NetworkPolicy.Enabled {
+		res, err = kube.ReconcileNetworkPolicy(ctx, resourceReconciler, policy)
+	} else {
+		res, err = kube.RemoveNetworkPolicy(ctx, resourceReconciler, policy)
+	}
+	if err != nil {
+		if res == nil {
+			res = &reconcile.Result{}
+		}
+		metrics.RecordReconcileError("ChiaTimelord", "NetworkPolicy")
+		r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord NetworkPolicy -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling timelord NetworkPolicy: %v", req.NamespacedName, err)
+	}
+
+	// Scrape chia-exporter with a Prometheus Operator monitor if its CRD is installed
+	if len(r.PrometheusOperator.MonitorKinds()) > 0 {
+		monitor, err := r.assembleChiaExporterMonitor(ctx, tl)
+		if err == nil {
+			err = kube.ReconcileChiaExporterMonitor(ctx, r.Client, r.PrometheusOperator, monitor, kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta))
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaTimelord", "Monitor")
+			r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord chia-exporter monitor -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling timelord chia-exporter monitor: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &tl, &tl.Status.Conditions, consts.MonitorsDisabledCondition, !r.PrometheusOperator.ServesMonitor(tl.Spec.ChiaExporterConfig.Monitor),
+		"A chia-exporter monitor is configured but its Prometheus Operator CRD was not found when the operator started.")
+
+	// Create the PrometheusRule with the timelord's alerts if the CRD is installed
+	if r.PrometheusOperator.PrometheusRules {
+		err = kube.ReconcilePrometheusRule(ctx, r.Client, r.assemblePrometheusRule(ctx, tl), kube.GetCommonLabels(ctx, tl.Kind, tl.ObjectMeta))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaTimelord", "PrometheusRule")
+			r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord PrometheusRule -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling timelord PrometheusRule: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &tl, &tl.Status.Conditions, consts.AlertingDisabledCondition, tl.Spec.Alerting != nil && !r.PrometheusOperator.PrometheusRules,
+		"Alerting is configured but the PrometheusRule CRD was not found when the operator started.")
+	kube.SetWarningCondition(r.Recorder, &tl, &tl.Status.Conditions, consts.AlertingWithoutMonitorCondition, tl.Spec.Alerting != nil && (tl.Spec.ChiaExporterConfig.Monitor == nil || !tl.Spec.ChiaExporterConfig.Enabled),
+		"Alerting is configured without a chia-exporter monitor. Alerts select metrics by their k8s_chia_net_provenance label, which your own scrape config must add.")
+
+	// Create the CHIA_ROOT PersistentVolumeClaim if a storage class and size were given instead of an existing claim
+	if pvcConfig := kube.GetGeneratedChiaRootClaimConfig(tl.Spec.Storage); pvcConfig != nil {
+		pvc, err := r.assembleChiaRootClaim(ctx, tl, *pvcConfig)
+		if err == nil {
+			err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, pvc)
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaTimelord", "PersistentVolumeClaim")
+			r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord CHIA_ROOT PersistentVolumeClaim -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling timelord CHIA_ROOT PersistentVolumeClaim: %v", req.NamespacedName, err)
+		}
+	}
+
 	deploy := r.assembleDeployment(ctx, tl)
 	res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaTimelord", "Deployment")
 		r.Recorder.Event(&tl, corev1.EventTypeWarning, "Failed", "Failed to create timelord Deployment -- Check operator logs.")
-		return *res, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling node StatefulSet: %v", req.NamespacedName, err)
+		return *res, fmt.Errorf("ChiaTimelordController ChiaTimelord=%s encountered error reconciling timelord Deployment: %v", req.NamespacedName, err)
 	}
 
-	// Update CR status
-	r.Recorder.Event(&tl, corev1.EventTypeNormal, "Created", "Successfully created ChiaTimelord resources.")
+	// Update CR status, only writing it and recording the event when something changed since the health check requeues every minute
+	if !tl.Status.Ready {
+		r.Recorder.Event(&tl, corev1.EventTypeNormal, "Created", "Successfully created ChiaTimelord resources.")
+	}
 	tl.Status.Ready = true
-	err = r.Status().Update(ctx, &tl)
-	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
-		log.Error(err, fmt.Sprintf("ChiaTimelordController ChiaTimelord=%s unable to update ChiaNode status", req.NamespacedName))
-		return ctrl.Result{}, err
+	r.updateHealthStatus(ctx, &tl)
+	if !equality.Semantic.DeepEqual(*previousStatus, tl.Status) {
+		err = r.Status().Update(ctx, &tl)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaTimelord", "ChiaTimelord")
+			log.Error(err, fmt.Sprintf("ChiaTimelordController ChiaTimelord=%s unable to update ChiaTimelord status", req.NamespacedName))
+			return ctrl.Result{}, err
+		}
 	}
 
-	return ctrl.Result{}, nil
+	// Requeue to keep the RPC health status fresh
+	return ctrl.Result{RequeueAfter: healthCheckInterval}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
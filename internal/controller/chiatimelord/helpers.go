@@ -6,13 +6,17 @@ package chiatimelord
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
 // getChiaVolumes retrieves the requisite volumes from the Chia config struct
@@ -38,7 +42,7 @@ func (r *ChiaTimelordReconciler) getChiaVolumes(ctx context.Context, tl k8schian
 				Name: "chiaroot",
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: tl.Spec.Storage.ChiaRoot.PersistentVolumeClaim.ClaimName,
+						ClaimName: kube.GetChiaRootClaimName(fmt.Sprintf(chiatimelordNamePattern, tl.Name), tl.Spec.Storage),
 					},
 				},
 			})
@@ -171,3 +175,63 @@ func (r *ChiaTimelordReconciler) getOwnerReference(ctx context.Context, tl k8sch
 		},
 	}
 }
+
+// updateHealthStatus queries the timelord RPC and records its full_node connection and the peak height it sees in the CR status.
+// The Idle condition is raised when the peak hasn't advanced within the CR's idle threshold, since a timelord without new peaks has nothing to infuse.
+func (r *ChiaTimelordReconciler) updateHealthStatus(ctx context.Context, tl *k8schianetv1.ChiaTimelord) {
+	now := metav1.Now()
+
+	state, err := r.getTimelordState(ctx, *tl)
+	if err != nil {
+		log.FromContext(ctx).Info(fmt.Sprintf("ChiaTimelordController ChiaTimelord=%s/%s unable to query timelord RPC: %v", tl.Namespace, tl.Name, err))
+		meta.SetStatusCondition(&tl.Status.Conditions, metav1.Condition{
+			Type:               consts.TimelordRPCAvailableCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: tl.Generation,
+			Reason:             "QueryFailed",
+			Message:            err.Error(),
+		})
+		tl.Status.FullNodeConnected = false
+	} else {
+		meta.SetStatusCondition(&tl.Status.Conditions, metav1.Condition{
+			Type:               consts.TimelordRPCAvailableCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: tl.Generation,
+			Reason:             "QuerySucceeded",
+			Message:            "Timelord RPC responded",
+		})
+		tl.Status.FullNodeConnected = state.FullNodeConnected
+		if state.PeakHeight != nil && (tl.Status.PeakHeight == nil || *state.PeakHeight != *tl.Status.PeakHeight) {
+			tl.Status.PeakHeight = state.PeakHeight
+			tl.Status.LastPeakTime = &now
+		}
+	}
+
+	// Start the idle clock the first time this timelord is observed
+	if tl.Status.LastPeakTime == nil {
+		tl.Status.LastPeakTime = &now
+	}
+
+	idleThreshold := defaultIdleThreshold
+	if tl.Spec.IdleThreshold != nil {
+		idleThreshold = tl.Spec.IdleThreshold.Duration
+	}
+	idleFor := now.Sub(tl.Status.LastPeakTime.Time)
+	if idleFor > idleThreshold {
+		meta.SetStatusCondition(&tl.Status.Conditions, metav1.Condition{
+			Type:               consts.TimelordIdleCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: tl.Generation,
+			Reason:             "NoNewPeaks",
+			Message:            "No new peak received from a full_node within the idle threshold, see lastPeakTime",
+		})
+	} else {
+		meta.SetStatusCondition(&tl.Status.Conditions, metav1.Condition{
+			Type:               consts.TimelordIdleCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: tl.Generation,
+			Reason:             "ReceivingPeaks",
+			Message:            "Timelord has received a new peak within its idle threshold",
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiatimelord
+
+import (
+	"context"
+	"fmt"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// timelordState holds the state reported by a timelord's RPC server
+type timelordState struct {
+	FullNodeConnected bool
+	PeakHeight        *uint32
+}
+
+// getTimelordRPCURL gives the base URL of a ChiaTimelord's RPC server
+func getTimelordRPCURL(tl k8schianetv1.ChiaTimelord) string {
+	return chiarpc.ServiceURL(fmt.Sprintf(chiatimelordNamePattern, tl.Name), tl.Namespace, consts.TimelordRPCPort)
+}
+
+// getTimelordState queries the timelord's RPC server for its full_node connections and the highest peak they report.
+// The timelord RPC server only serves the endpoints common to every chia service, so get_connections is all there is to query.
+func (r *ChiaTimelordReconciler) getTimelordState(ctx context.Context, tl k8schianetv1.ChiaTimelord) (timelordState, error) {
+	var state timelordState
+
+	rpc, err := r.rpcClients.Get(ctx, r.Client, tl.Namespace, tl.Spec.ChiaConfig.CASecretName, getTimelordRPCURL(tl), r.RPCOptions...)
+	if err != nil {
+		return state, err
+	}
+
+	conns, err := rpc.GetConnections(ctx)
+	if err != nil {
+		return state, err
+	}
+	for _, conn := range conns.Connections {
+		if conn.Type != chiarpc.NodeTypeFullNode {
+			continue
+		}
+		state.FullNodeConnected = true
+		if conn.PeakHeight != nil && (state.PeakHeight == nil || *conn.PeakHeight > *state.PeakHeight) {
+			height := *conn.PeakHeight
+			state.PeakHeight = &height
+		}
+	}
+
+	return state, nil
+}
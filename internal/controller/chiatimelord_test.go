@@ -12,10 +12,14 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	apiv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 )
 
 var _ = Describe("ChiaTimelord controller", func() {
@@ -80,5 +84,62 @@ var _ = Describe("ChiaTimelord controller", func() {
 			// Ensure the ChiaTimelord's spec equals the expected spec
 			Expect(createdChiaTimelord.Spec).Should(Equal(expect.Spec))
 		})
+
+		It("should record the timelord's RPC health in its status", func() {
+			By("By creating a ChiaTimelord whose RPC server reports a full_node peer")
+			ctx := context.Background()
+			rpcServer.SetResponse("get_connections", map[string]interface{}{
+				"success": true,
+				"connections": []map[string]interface{}{
+					{
+						"node_id":     "0xabc",
+						"type":        chiarpc.NodeTypeFullNode,
+						"peer_host":   "10.0.0.1",
+						"peer_port":   8444,
+						"peak_height": 4500000,
+					},
+				},
+			})
+			caSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-timelord-rpc-ca",
+					Namespace: "default",
+				},
+				Data: rpcCASecretData,
+			}
+			Expect(k8sClient.Create(ctx, caSecret)).Should(Succeed())
+			testTimelord := &apiv1.ChiaTimelord{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "k8s.chia.net/v1",
+					Kind:       "ChiaTimelord",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-chiatimelord-rpc",
+					Namespace: "default",
+				},
+				Spec: apiv1.ChiaTimelordSpec{
+					ChiaConfig: apiv1.ChiaTimelordSpecChia{
+						CommonSpecChia: apiv1.CommonSpecChia{
+							CASecretName: caSecret.Name,
+						},
+						FullNodePeer: "node.default.svc.cluster.local:58444",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, testTimelord)).Should(Succeed())
+
+			// The health check queries the fake RPC server and records the full_node connection and its peak
+			lookupKey := types.NamespacedName{Name: testTimelord.Name, Namespace: testTimelord.Namespace}
+			createdChiaTimelord := &apiv1.ChiaTimelord{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, lookupKey, createdChiaTimelord)
+				if err != nil {
+					return false
+				}
+				return createdChiaTimelord.Status.FullNodeConnected &&
+					createdChiaTimelord.Status.PeakHeight != nil && *createdChiaTimelord.Status.PeakHeight == 4500000
+			}, timeout, interval).Should(BeTrue())
+			Expect(meta.IsStatusConditionTrue(createdChiaTimelord.Status.Conditions, consts.TimelordRPCAvailableCondition)).Should(BeTrue())
+		})
 	})
 })
@@ -10,7 +10,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -22,7 +24,7 @@ const chiawalletNamePattern = "%s-wallet"
 
 // assembleBaseService reconciles the main Service resource for a ChiaWallet CR
 func (r *ChiaWalletReconciler) assembleBaseService(ctx context.Context, wallet k8schianetv1.ChiaWallet) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiawalletNamePattern, wallet.Name),
 			Namespace:       wallet.Namespace,
@@ -55,11 +57,14 @@ func (r *ChiaWalletReconciler) assembleBaseService(ctx context.Context, wallet k
 			Selector: kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, wallet.Spec.Service)
+	return srv
 }
 
 // assembleChiaExporterService assembles the chia-exporter Service resource for a ChiaWallet CR
 func (r *ChiaWalletReconciler) assembleChiaExporterService(ctx context.Context, wallet k8schianetv1.ChiaWallet) corev1.Service {
-	return corev1.Service{
+	srv := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf(chiawalletNamePattern, wallet.Name) + "-metrics",
 			Namespace:       wallet.Namespace,
@@ -80,6 +85,38 @@ func (r *ChiaWalletReconciler) assembleChiaExporterService(ctx context.Context,
 			Selector: kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels),
 		},
 	}
+
+	kube.ApplyServiceConfig(&srv, wallet.Spec.ChiaExporterConfig.Service)
+	return srv
+}
+
+// assembleNetworkPolicy assembles the NetworkPolicy resource for a ChiaWallet CR
+func (r *ChiaWalletReconciler) assembleNetworkPolicy(ctx context.Context, wallet k8schianetv1.ChiaWallet) networkingv1.NetworkPolicy {
+	meta := metav1.ObjectMeta{
+		Name:            fmt.Sprintf(chiawalletNamePattern, wallet.Name),
+		Namespace:       wallet.Namespace,
+		Labels:          kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels),
+		Annotations:     wallet.Spec.AdditionalMetadata.Annotations,
+		OwnerReferences: r.getOwnerReference(ctx, wallet),
+	}
+	return kube.AssembleNetworkPolicy(ctx, meta, kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta), wallet.Spec.NetworkPolicy, kube.NamedPort("peers", corev1.ProtocolTCP))
+}
+
+// assembleChiaExporterMonitor assembles the Prometheus Operator monitor for a ChiaWallet CR's chia-exporter, or gives nil if it doesn't want one
+func (r *ChiaWalletReconciler) assembleChiaExporterMonitor(ctx context.Context, wallet k8schianetv1.ChiaWallet) (*unstructured.Unstructured, error) {
+	return kube.AssembleChiaExporterMonitor(ctx, wallet.Kind, wallet.ObjectMeta, fmt.Sprintf(chiawalletNamePattern, wallet.Name), wallet.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, wallet), wallet.Spec.ChiaExporterConfig)
+}
+
+// assemblePrometheusRule assembles the PrometheusRule with a ChiaWallet CR's alerts, or gives nil if it doesn't want one.
+// The wallet alerts when it isn't synced.
+func (r *ChiaWalletReconciler) assemblePrometheusRule(ctx context.Context, wallet k8schianetv1.ChiaWallet) *unstructured.Unstructured {
+	return kube.AssemblePrometheusRule(ctx, wallet.Kind, wallet.ObjectMeta, fmt.Sprintf(chiawalletNamePattern, wallet.Name), wallet.Spec.AdditionalMetadata.Labels, r.getOwnerReference(ctx, wallet), wallet.Spec.Alerting,
+		kube.AlertingRule{
+			Alert:   "ChiaWalletNotSynced",
+			Expr:    fmt.Sprintf("chia_wallet_synced{%s} == 0", kube.GetMetricSelector(ctx, wallet.Kind, wallet.ObjectMeta)),
+			Summary: fmt.Sprintf("ChiaWallet %s/%s is not synced", wallet.Namespace, wallet.Name),
+		},
+	)
 }
 
 // assembleDeployment reconciles the wallet Deployment resource for a ChiaWallet CR
@@ -96,97 +133,151 @@ func (r *ChiaWalletReconciler) assembleDeployment(ctx context.Context, wallet k8
 			Selector: &metav1.LabelSelector{
 				MatchLabels: kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta),
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels:      kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels),
-					Annotations: wallet.Spec.AdditionalMetadata.Annotations,
-				},
-				Spec: corev1.PodSpec{
-					// TODO add: imagePullSecret, serviceAccountName config
-					Containers: []corev1.Container{
+			Template: r.assemblePodTemplate(ctx, wallet),
+		},
+	}
+
+	// A rolling update would briefly run two wallets against the same ReadWriteOnce claim
+	if wallet.Spec.Storage != nil && wallet.Spec.Storage.ChiaRoot != nil && wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim != nil {
+		deploy.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+
+	// Scale to zero while a ChiaMaintenance has exclusive access to this Deployment's volumes
+	if kube.IsPausedForMaintenance(wallet.ObjectMeta) {
+		var zero int32 = 0
+		deploy.Spec.Replicas = &zero
+	}
+
+	return deploy
+}
+
+// assembleStatefulSet assembles the wallet StatefulSet resource for a ChiaWallet CR running in StatefulSet mode.
+// StatefulSets replace their pod before starting a new one, so only one wallet ever runs against its CHIA_ROOT volume.
+func (r *ChiaWalletReconciler) assembleStatefulSet(ctx context.Context, wallet k8schianetv1.ChiaWallet) appsv1.StatefulSet {
+	var replicas int32 = 1
+	// Scale to zero while a ChiaMaintenance has exclusive access to this StatefulSet's volumes
+	if kube.IsPausedForMaintenance(wallet.ObjectMeta) {
+		replicas = 0
+	}
+
+	var volClaimTemplates []corev1.PersistentVolumeClaim
+	retention := appsv1.RetainPersistentVolumeClaimRetentionPolicyType
+	if vct := getChiaRootClaimTemplate(wallet); vct != nil {
+		volClaimTemplates = append(volClaimTemplates, *vct)
+		if wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim.RetentionPolicy == appsv1.DeletePersistentVolumeClaimRetentionPolicyType {
+			retention = appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+		}
+	}
+
+	return appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf(chiawalletNamePattern, wallet.Name),
+			Namespace:       wallet.Namespace,
+			Labels:          kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels),
+			Annotations:     wallet.Spec.AdditionalMetadata.Annotations,
+			OwnerReferences: r.getOwnerReference(ctx, wallet),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta),
+			},
+			ServiceName:          fmt.Sprintf(chiawalletNamePattern, wallet.Name),
+			Template:             r.assemblePodTemplate(ctx, wallet),
+			VolumeClaimTemplates: volClaimTemplates,
+			PersistentVolumeClaimRetentionPolicy: &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+				WhenDeleted: retention,
+				WhenScaled:  appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+			},
+		},
+	}
+}
+
+// assemblePodTemplate assembles the wallet pod template shared by the Deployment and StatefulSet
+func (r *ChiaWalletReconciler) assemblePodTemplate(ctx context.Context, wallet k8schianetv1.ChiaWallet) corev1.PodTemplateSpec {
+	var template corev1.PodTemplateSpec = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels),
+			Annotations: wallet.Spec.AdditionalMetadata.Annotations,
+		},
+		Spec: corev1.PodSpec{
+			// TODO add: imagePullSecret, serviceAccountName config
+			Containers: []corev1.Container{
+				{
+					Name:            "chia",
+					Image:           wallet.Spec.ChiaConfig.Image,
+					ImagePullPolicy: wallet.Spec.ImagePullPolicy,
+					Lifecycle:       kube.GetChiaLifecycle(ctx),
+					Env:             r.getChiaEnv(ctx, wallet),
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "daemon",
+							ContainerPort: consts.DaemonPort,
+							Protocol:      "TCP",
+						},
+						{
+							Name:          "peers",
+							ContainerPort: consts.WalletPort,
+							Protocol:      "TCP",
+						},
 						{
-							Name:            "chia",
-							Image:           wallet.Spec.ChiaConfig.Image,
-							ImagePullPolicy: wallet.Spec.ImagePullPolicy,
-							Env:             r.getChiaEnv(ctx, wallet),
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "daemon",
-									ContainerPort: consts.DaemonPort,
-									Protocol:      "TCP",
-								},
-								{
-									Name:          "peers",
-									ContainerPort: consts.WalletPort,
-									Protocol:      "TCP",
-								},
-								{
-									Name:          "rpc",
-									ContainerPort: consts.WalletRPCPort,
-									Protocol:      "TCP",
-								},
-							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "secret-ca",
-									MountPath: "/chia-ca",
-								},
-								{
-									Name:      "key",
-									MountPath: "/key",
-								},
-								{
-									Name:      "chiaroot",
-									MountPath: "/chia-data",
-								},
-							},
+							Name:          "rpc",
+							ContainerPort: consts.WalletRPCPort,
+							Protocol:      "TCP",
 						},
 					},
-					NodeSelector: wallet.Spec.NodeSelector,
-					Volumes:      r.getChiaVolumes(ctx, wallet),
+					VolumeMounts: r.getChiaVolumeMounts(ctx, wallet),
 				},
 			},
+			NodeSelector:                  wallet.Spec.NodeSelector,
+			TerminationGracePeriodSeconds: kube.GetTerminationGracePeriodSeconds(ctx, wallet.Spec.TerminationGracePeriodSeconds, consts.WalletTerminationGracePeriodSeconds),
+			Volumes:                       r.getChiaVolumes(ctx, wallet),
 		},
 	}
 
 	var containerSecurityContext *corev1.SecurityContext
 	if wallet.Spec.ChiaConfig.SecurityContext != nil {
 		containerSecurityContext = wallet.Spec.ChiaConfig.SecurityContext
-		deploy.Spec.Template.Spec.Containers[0].SecurityContext = wallet.Spec.ChiaConfig.SecurityContext
+		template.Spec.Containers[0].SecurityContext = wallet.Spec.ChiaConfig.SecurityContext
 	}
 
 	if wallet.Spec.ChiaConfig.LivenessProbe != nil {
-		deploy.Spec.Template.Spec.Containers[0].LivenessProbe = wallet.Spec.ChiaConfig.LivenessProbe
+		template.Spec.Containers[0].LivenessProbe = wallet.Spec.ChiaConfig.LivenessProbe
 	}
 
 	if wallet.Spec.ChiaConfig.ReadinessProbe != nil {
-		deploy.Spec.Template.Spec.Containers[0].ReadinessProbe = wallet.Spec.ChiaConfig.ReadinessProbe
+		template.Spec.Containers[0].ReadinessProbe = wallet.Spec.ChiaConfig.ReadinessProbe
 	}
 
 	if wallet.Spec.ChiaConfig.StartupProbe != nil {
-		deploy.Spec.Template.Spec.Containers[0].StartupProbe = wallet.Spec.ChiaConfig.StartupProbe
+		template.Spec.Containers[0].StartupProbe = wallet.Spec.ChiaConfig.StartupProbe
 	}
 
-	var containerResorces corev1.ResourceRequirements
 	if wallet.Spec.ChiaConfig.Resources != nil {
-		containerResorces = *wallet.Spec.ChiaConfig.Resources
-		deploy.Spec.Template.Spec.Containers[0].Resources = *wallet.Spec.ChiaConfig.Resources
+		template.Spec.Containers[0].Resources = *wallet.Spec.ChiaConfig.Resources
 	}
 
 	if wallet.Spec.ChiaExporterConfig.Enabled {
-		exporterContainer := kube.GetChiaExporterContainer(ctx, wallet.Spec.ChiaExporterConfig.Image, containerSecurityContext, wallet.Spec.ImagePullPolicy, containerResorces)
-		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, exporterContainer)
+		exporterContainer := kube.GetChiaExporterContainer(ctx, wallet.Spec.ChiaExporterConfig, containerSecurityContext, wallet.Spec.ImagePullPolicy)
+		template.Spec.Containers = append(template.Spec.Containers, exporterContainer)
 	}
 
 	if wallet.Spec.PodSecurityContext != nil {
-		deploy.Spec.Template.Spec.SecurityContext = wallet.Spec.PodSecurityContext
+		template.Spec.SecurityContext = wallet.Spec.PodSecurityContext
 	}
 
 	if len(wallet.Spec.Sidecars.Containers) > 0 {
-		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, wallet.Spec.Sidecars.Containers...)
+		template.Spec.Containers = append(template.Spec.Containers, wallet.Spec.Sidecars.Containers...)
 	}
 
 	// TODO add pod affinity, tolerations
 
-	return deploy
+	return template
+}
+
+// assembleChiaRootClaim assembles the CHIA_ROOT PersistentVolumeClaim for a ChiaWallet CR that gives a storage class and size instead of an existing claim
+func (r *ChiaWalletReconciler) assembleChiaRootClaim(ctx context.Context, wallet k8schianetv1.ChiaWallet, config k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	return kube.AssembleChiaRootClaim(ctx, fmt.Sprintf(chiawalletNamePattern, wallet.Name), wallet.Namespace, kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta, wallet.Spec.AdditionalMetadata.Labels), r.getOwnerReference(ctx, wallet), config)
 }
@@ -7,9 +7,12 @@ package chiawallet
 import (
 	"context"
 	"fmt"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -18,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	"github.com/cisco-open/operator-tools/pkg/reconciler"
@@ -28,20 +32,30 @@ type ChiaWalletReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// PrometheusOperator says which Prometheus Operator kinds the cluster serves. chia-exporter monitors and alerting rules require their kind's CRD.
+	PrometheusOperator kube.PrometheusOperatorAPIs
 }
 
-var chiawallets map[string]bool = make(map[string]bool)
+// volumePollInterval is how often a ChiaWallet is requeued while its StatefulSet is recreated
+const volumePollInterval = 10 * time.Second
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiawallets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiawallets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiawallets/finalizers,verbs=update
-//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.15.0/pkg/reconcile
 func (r *ChiaWalletReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer metrics.ObserveReconcileDuration("ChiaWallet", time.Now())
 	log := log.FromContext(ctx)
 	resourceReconciler := reconciler.NewReconcilerWith(r.Client, reconciler.WithLog(log))
 	log.Info(fmt.Sprintf("ChiaWalletReconciler ChiaWallet=%s", req.NamespacedName.String()))
@@ -50,59 +64,176 @@ func (r *ChiaWalletReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	var wallet k8schianetv1.ChiaWallet
 	err := r.Get(ctx, req.NamespacedName, &wallet)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiawallets[req.NamespacedName.String()]
-		if exists {
-			delete(chiawallets, req.NamespacedName.String())
-			metrics.ChiaWallets.Sub(1.0)
+		// chia-exporter monitors and PrometheusRules in other namespaces aren't garbage collected with the ChiaWallet
+		err = kube.RemoveChiaExporterMonitors(ctx, r.Client, r.PrometheusOperator, kube.GetCommonLabels(ctx, "ChiaWallet", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaWallet", "Monitor")
+			return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error removing wallet chia-exporter monitors: %v", req.NamespacedName, err)
+		}
+		if r.PrometheusOperator.PrometheusRules {
+			err = kube.ReconcilePrometheusRule(ctx, r.Client, nil, kube.GetCommonLabels(ctx, "ChiaWallet", metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}))
+			if err != nil {
+				metrics.RecordReconcileError("ChiaWallet", "PrometheusRule")
+				return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error removing wallet PrometheusRules: %v", req.NamespacedName, err)
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaWallet", "ChiaWallet")
 		log.Error(err, fmt.Sprintf("ChiaWalletReconciler ChiaWallet=%s unable to fetch ChiaWallet resource", req.NamespacedName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiawallets[req.NamespacedName.String()]
-	if !exists {
-		chiawallets[req.NamespacedName.String()] = true
-		metrics.ChiaWallets.Add(1.0)
+	// The CHIA_ROOT claim template is assembled from its resourceRequest, so reject an invalid one up front
+	if wallet.Spec.Storage != nil && wallet.Spec.Storage.ChiaRoot != nil {
+		err = kube.ValidateResourceRequests(wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaWallet", "ChiaWallet")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "InvalidStorageConfig", fmt.Sprintf("Invalid storage configuration: %v", err))
+			return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s has an invalid storage configuration: %v", req.NamespacedName, err)
+		}
 	}
 
 	// Reconcile ChiaWallet owned objects
 	service := r.assembleBaseService(ctx, wallet)
-	res, err := kube.ReconcileService(ctx, resourceReconciler, service)
+	res, err := kube.ReconcileConfiguredService(ctx, resourceReconciler, service, wallet.Spec.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaWallet", "Service")
 		r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create harvester Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet Service: %v", req.NamespacedName, err)
 	}
 
 	service = r.assembleChiaExporterService(ctx, wallet)
-	res, err = kube.ReconcileService(ctx, resourceReconciler, service)
+	res, err = kube.ReconcileConfiguredService(ctx, resourceReconciler, service, wallet.Spec.ChiaExporterConfig.Service)
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaWallet", "Service")
 		r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create harvester metrics Service -- Check operator logs.")
 		return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet chia-exporter Service: %v", req.NamespacedName, err)
 	}
 
-	deploy := r.assembleDeployment(ctx, wallet)
-	res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
+	policy := r.assembleNetworkPolicy(ctx, wallet)
+	if wallet.Spec.NetworkPolicy.Enabled {
+		res, err = kube.ReconcileNetworkPolicy(ctx, resourceReconciler, policy)
+	} else {
+		res, err = kube.RemoveNetworkPolicy(ctx, resourceReconciler, policy)
+	}
 	if err != nil {
 		if res == nil {
 			res = &reconcile.Result{}
 		}
-		metrics.OperatorErrors.Add(1.0)
-		r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create harvester Deployment -- Check operator logs.")
-		return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet Deployment: %v", req.NamespacedName, err)
+		metrics.RecordReconcileError("ChiaWallet", "NetworkPolicy")
+		r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create wallet NetworkPolicy -- Check operator logs.")
+		return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet NetworkPolicy: %v", req.NamespacedName, err)
+	}
+
+	// Scrape chia-exporter with a Prometheus Operator monitor if its CRD is installed
+	if len(r.PrometheusOperator.MonitorKinds()) > 0 {
+		monitor, err := r.assembleChiaExporterMonitor(ctx, wallet)
+		if err == nil {
+			err = kube.ReconcileChiaExporterMonitor(ctx, r.Client, r.PrometheusOperator, monitor, kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta))
+		}
+		if err != nil {
+			metrics.RecordReconcileError("ChiaWallet", "Monitor")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create wallet chia-exporter monitor -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet chia-exporter monitor: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &wallet, &wallet.Status.Conditions, consts.MonitorsDisabledCondition, !r.PrometheusOperator.ServesMonitor(wallet.Spec.ChiaExporterConfig.Monitor),
+		"A chia-exporter monitor is configured but its Prometheus Operator CRD was not found when the operator started.")
+
+	// Create the PrometheusRule with the wallet's alerts if the CRD is installed
+	if r.PrometheusOperator.PrometheusRules {
+		err = kube.ReconcilePrometheusRule(ctx, r.Client, r.assemblePrometheusRule(ctx, wallet), kube.GetCommonLabels(ctx, wallet.Kind, wallet.ObjectMeta))
+		if err != nil {
+			metrics.RecordReconcileError("ChiaWallet", "PrometheusRule")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create wallet PrometheusRule -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet PrometheusRule: %v", req.NamespacedName, err)
+		}
+	}
+	kube.SetWarningCondition(r.Recorder, &wallet, &wallet.Status.Conditions, consts.AlertingDisabledCondition, wallet.Spec.Alerting != nil && !r.PrometheusOperator.PrometheusRules,
+		"Alerting is configured but the PrometheusRule CRD was not found when the operator started.")
+	kube.SetWarningCondition(r.Recorder, &wallet, &wallet.Status.Conditions, consts.AlertingWithoutMonitorCondition, wallet.Spec.Alerting != nil && (wallet.Spec.ChiaExporterConfig.Monitor == nil || !wallet.Spec.ChiaExporterConfig.Enabled),
+		"Alerting is configured without a chia-exporter monitor. Alerts select metrics by their k8s_chia_net_provenance label, which your own scrape config must add.")
+
+	// Run the wallet in a StatefulSet or a Deployment, and remove the other workload left over from switching between them
+	var recreating bool
+	workloadMeta := metav1.ObjectMeta{
+		Name:      fmt.Sprintf(chiawalletNamePattern, wallet.Name),
+		Namespace: wallet.Namespace,
+	}
+	if runsStatefulSet(wallet) {
+		res, err = kube.RemoveDeployment(ctx, resourceReconciler, appsv1.Deployment{ObjectMeta: workloadMeta})
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaWallet", "Deployment")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to remove wallet Deployment -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error removing wallet Deployment: %v", req.NamespacedName, err)
+		}
+
+		stateful := r.assembleStatefulSet(ctx, wallet)
+		recreating, err = r.reconcileVolumeClaimTemplates(ctx, stateful)
+		if err != nil {
+			metrics.RecordReconcileError("ChiaWallet", "PersistentVolumeClaim")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to update wallet volume claim templates -- Check operator logs.")
+			return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet volume claim templates: %v", req.NamespacedName, err)
+		}
+		if recreating {
+			// The StatefulSet is recreated with its new volume claim templates once the orphaning delete finishes
+			r.Recorder.Event(&wallet, corev1.EventTypeNormal, "Recreating", "Recreating wallet StatefulSet with updated volume claim templates, its pod is kept running.")
+		} else {
+			res, err = kube.ReconcileStatefulset(ctx, resourceReconciler, stateful)
+			if err != nil {
+				if res == nil {
+					res = &reconcile.Result{}
+				}
+				metrics.RecordReconcileError("ChiaWallet", "StatefulSet")
+				r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create wallet StatefulSet -- Check operator logs.")
+				return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet StatefulSet: %v", req.NamespacedName, err)
+			}
+		}
+	} else {
+		res, err = kube.RemoveStatefulset(ctx, resourceReconciler, appsv1.StatefulSet{ObjectMeta: workloadMeta})
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaWallet", "StatefulSet")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to remove wallet StatefulSet -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error removing wallet StatefulSet: %v", req.NamespacedName, err)
+		}
+
+		// Create the CHIA_ROOT PersistentVolumeClaim if a storage class and size were given instead of an existing claim
+		if pvcConfig := kube.GetGeneratedChiaRootClaimConfig(wallet.Spec.Storage); pvcConfig != nil {
+			pvc, err := r.assembleChiaRootClaim(ctx, wallet, *pvcConfig)
+			if err == nil {
+				err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, pvc)
+			}
+			if err != nil {
+				metrics.RecordReconcileError("ChiaWallet", "PersistentVolumeClaim")
+				r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create wallet CHIA_ROOT PersistentVolumeClaim -- Check operator logs.")
+				return ctrl.Result{}, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet CHIA_ROOT PersistentVolumeClaim: %v", req.NamespacedName, err)
+			}
+		}
+
+		deploy := r.assembleDeployment(ctx, wallet)
+		res, err = kube.ReconcileDeployment(ctx, resourceReconciler, deploy)
+		if err != nil {
+			if res == nil {
+				res = &reconcile.Result{}
+			}
+			metrics.RecordReconcileError("ChiaWallet", "Deployment")
+			r.Recorder.Event(&wallet, corev1.EventTypeWarning, "Failed", "Failed to create harvester Deployment -- Check operator logs.")
+			return *res, fmt.Errorf("ChiaWalletReconciler ChiaWallet=%s encountered error reconciling wallet Deployment: %v", req.NamespacedName, err)
+		}
 	}
 
 	// Update CR status
@@ -110,11 +241,16 @@ func (r *ChiaWalletReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	wallet.Status.Ready = true
 	err = r.Status().Update(ctx, &wallet)
 	if err != nil {
-		metrics.OperatorErrors.Add(1.0)
+		metrics.RecordReconcileError("ChiaWallet", "ChiaWallet")
 		log.Error(err, fmt.Sprintf("ChiaWalletReconciler ChiaWallet=%s unable to update ChiaWallet status", req.NamespacedName))
 		return ctrl.Result{}, err
 	}
 
+	// Requeue to recreate the StatefulSet once its orphaning delete finishes
+	if recreating {
+		return ctrl.Result{RequeueAfter: volumePollInterval}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -10,10 +10,12 @@ import (
 	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
 // getChiaVolumes retrieves the requisite volumes from the Chia config struct
@@ -44,12 +46,15 @@ func (r *ChiaWalletReconciler) getChiaVolumes(ctx context.Context, wallet k8schi
 	// If both are empty, fall back to emptyDir so chia-exporter can mount CHIA_ROOT
 	var chiaRootAdded bool = false
 	if wallet.Spec.Storage != nil && wallet.Spec.Storage.ChiaRoot != nil {
-		if wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim != nil {
+		if getChiaRootClaimTemplate(wallet) != nil {
+			// The StatefulSet creates the CHIA_ROOT volume from its volume claim template
+			chiaRootAdded = true
+		} else if wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim != nil {
 			v = append(v, corev1.Volume{
 				Name: "chiaroot",
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim.ClaimName,
+						ClaimName: kube.GetChiaRootClaimName(fmt.Sprintf(chiawalletNamePattern, wallet.Name), wallet.Spec.Storage),
 					},
 				},
 			})
@@ -83,6 +88,69 @@ func (r *ChiaWalletReconciler) getChiaVolumes(ctx context.Context, wallet k8schi
 	return v
 }
 
+// getChiaRootClaimTemplate gives the CHIA_ROOT volume claim template for a wallet running in StatefulSet mode, which is used when its
+// chiaRoot persistentVolumeClaim gives a resourceRequest instead of an existing claimName. Returns nil if the wallet doesn't use one.
+func getChiaRootClaimTemplate(wallet k8schianetv1.ChiaWallet) *corev1.PersistentVolumeClaim {
+	if !runsStatefulSet(wallet) || wallet.Spec.Storage == nil || wallet.Spec.Storage.ChiaRoot == nil {
+		return nil
+	}
+	pvc := wallet.Spec.Storage.ChiaRoot.PersistentVolumeClaim
+	if pvc == nil || pvc.ClaimName != "" || pvc.ResourceRequest == "" {
+		return nil
+	}
+
+	template := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "chiaroot",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(pvc.ResourceRequest),
+				},
+			},
+		},
+	}
+
+	// An empty storage class would disable dynamic provisioning, leave it unset to use the cluster's default
+	if pvc.StorageClass != "" {
+		template.Spec.StorageClassName = &pvc.StorageClass
+	}
+
+	return &template
+}
+
+// runsStatefulSet returns true if the wallet runs in a StatefulSet rather than a Deployment
+func runsStatefulSet(wallet k8schianetv1.ChiaWallet) bool {
+	return wallet.Spec.WorkloadKind == k8schianetv1.WorkloadKindStatefulSet
+}
+
+// getChiaVolumeMounts retrieves the requisite volume mounts from the Chia config struct
+func (r *ChiaWalletReconciler) getChiaVolumeMounts(ctx context.Context, wallet k8schianetv1.ChiaWallet) []corev1.VolumeMount {
+	var v []corev1.VolumeMount
+
+	// secret ca volume
+	v = append(v, corev1.VolumeMount{
+		Name:      "secret-ca",
+		MountPath: "/chia-ca",
+	})
+
+	// mnemonic key volume
+	v = append(v, corev1.VolumeMount{
+		Name:      "key",
+		MountPath: "/key",
+	})
+
+	// CHIA_ROOT volume
+	v = append(v, corev1.VolumeMount{
+		Name:      "chiaroot",
+		MountPath: "/chia-data",
+	})
+
+	return v
+}
+
 // getChiaEnv retrieves the environment variables from the Chia config struct
 func (r *ChiaWalletReconciler) getChiaEnv(ctx context.Context, wallet k8schianetv1.ChiaWallet) []corev1.EnvVar {
 	var env []corev1.EnvVar
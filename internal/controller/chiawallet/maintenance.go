@@ -0,0 +1,44 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiawallet
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// GetMaintenancePodConfig gives the pod configuration of a ChiaWallet for ChiaMaintenance tasks.
+// A CHIA_ROOT volume claim template is resolved to the claim the StatefulSet created for its only replica.
+func GetMaintenancePodConfig(ctx context.Context, wallet k8schianetv1.ChiaWallet) kube.ChiaPodConfig {
+	r := &ChiaWalletReconciler{}
+	vols := r.getChiaVolumes(ctx, wallet)
+	if vct := getChiaRootClaimTemplate(wallet); vct != nil {
+		vols = append(vols, corev1.Volume{
+			Name: vct.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: fmt.Sprintf("%s-%s-0", vct.Name, fmt.Sprintf(chiawalletNamePattern, wallet.Name)),
+				},
+			},
+		})
+	}
+
+	return kube.ChiaPodConfig{
+		WorkloadName:    fmt.Sprintf(chiawalletNamePattern, wallet.Name),
+		StatefulSet:     runsStatefulSet(wallet),
+		Image:           wallet.Spec.ChiaConfig.Image,
+		ImagePullPolicy: wallet.Spec.ImagePullPolicy,
+		Env:             r.getChiaEnv(ctx, wallet),
+		Volumes:         vols,
+		VolumeMounts:    r.getChiaVolumeMounts(ctx, wallet),
+		NodeSelector:    wallet.Spec.NodeSelector,
+		SecurityContext: wallet.Spec.PodSecurityContext,
+	}
+}
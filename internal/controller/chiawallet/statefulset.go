@@ -0,0 +1,45 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiawallet
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// reconcileVolumeClaimTemplates deletes the wallet StatefulSet with an orphaned pod when its volume claim templates changed, because they're
+// immutable and it has to be recreated with the new templates. The existing claim is kept and isn't resized. Returns true if the StatefulSet is being recreated.
+func (r *ChiaWalletReconciler) reconcileVolumeClaimTemplates(ctx context.Context, desired appsv1.StatefulSet) (bool, error) {
+	var current appsv1.StatefulSet
+	err := r.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &current)
+	if err != nil && errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// An orphaning delete is still in progress
+	if !current.DeletionTimestamp.IsZero() {
+		return true, nil
+	}
+
+	if kube.VolumeClaimTemplatesMatch(current, desired) {
+		return false, nil
+	}
+
+	orphan := client.PropagationPolicy("Orphan")
+	err = r.Delete(ctx, &current, orphan)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return true, nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiawallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// newStatefulWallet gives a ChiaWallet of the given workload kind with the given CHIA_ROOT claim config
+func newStatefulWallet(kind k8schianetv1.WorkloadKind, pvc *k8schianetv1.PersistentVolumeClaimConfig) k8schianetv1.ChiaWallet {
+	wallet := k8schianetv1.ChiaWallet{
+		TypeMeta:   metav1.TypeMeta{Kind: "ChiaWallet", APIVersion: "k8s.chia.net/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "wallet", Namespace: "chia"},
+		Spec: k8schianetv1.ChiaWalletSpec{
+			WorkloadKind: kind,
+		},
+	}
+	if pvc != nil {
+		wallet.Spec.Storage = &k8schianetv1.StorageConfig{
+			ChiaRoot: &k8schianetv1.ChiaRootConfig{PersistentVolumeClaim: pvc},
+		}
+	}
+	return wallet
+}
+
+func TestGetChiaRootClaimTemplate(t *testing.T) {
+	storageClass := "local-nvme"
+	tests := []struct {
+		name                 string
+		wallet               k8schianetv1.ChiaWallet
+		expectedRequest      string
+		expectedStorageClass *string
+	}{
+		{
+			name:   "deployment",
+			wallet: newStatefulWallet(k8schianetv1.WorkloadKindDeployment, &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"}),
+		},
+		{
+			name:   "statefulset without storage",
+			wallet: newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, nil),
+		},
+		{
+			name:   "statefulset with an existing claim",
+			wallet: newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "wallet-root"}),
+		},
+		{
+			name:            "statefulset with a resource request",
+			wallet:          newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"}),
+			expectedRequest: "10Gi",
+		},
+		{
+			name: "statefulset with a storage class",
+			wallet: newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{
+				ResourceRequest: "10Gi",
+				StorageClass:    "local-nvme",
+			}),
+			expectedRequest:      "10Gi",
+			expectedStorageClass: &storageClass,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := getChiaRootClaimTemplate(tt.wallet)
+			if tt.expectedRequest == "" {
+				if template != nil {
+					t.Errorf("Expected no claim template, got %v", template)
+				}
+				return
+			}
+			if template == nil {
+				t.Fatal("Expected a claim template, got none")
+			}
+			request := template.Spec.Resources.Requests[corev1.ResourceStorage]
+			if request.String() != tt.expectedRequest {
+				t.Errorf("Expected request %s, got %s", tt.expectedRequest, request.String())
+			}
+			if diff := cmp.Diff(tt.expectedStorageClass, template.Spec.StorageClassName); diff != "" {
+				t.Errorf("Storage class does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAssembleStatefulSet(t *testing.T) {
+	tests := []struct {
+		name              string
+		wallet            k8schianetv1.ChiaWallet
+		maintenance       bool
+		expectedReplicas  int32
+		expectedTemplates []string
+		expectedRetention appsv1.PersistentVolumeClaimRetentionPolicyType
+	}{
+		{
+			name:              "existing claim",
+			wallet:            newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "wallet-root"}),
+			expectedReplicas:  1,
+			expectedRetention: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+		},
+		{
+			name:              "claim template",
+			wallet:            newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"}),
+			expectedReplicas:  1,
+			expectedTemplates: []string{"chiaroot"},
+			expectedRetention: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+		},
+		{
+			name: "claim template deleted with the wallet",
+			wallet: newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{
+				ResourceRequest: "10Gi",
+				RetentionPolicy: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+			}),
+			expectedReplicas:  1,
+			expectedTemplates: []string{"chiaroot"},
+			expectedRetention: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+		},
+		{
+			name:              "paused for maintenance",
+			wallet:            newStatefulWallet(k8schianetv1.WorkloadKindStatefulSet, &k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"}),
+			maintenance:       true,
+			expectedReplicas:  0,
+			expectedTemplates: []string{"chiaroot"},
+			expectedRetention: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maintenance {
+				tt.wallet.Annotations = map[string]string{consts.MaintenanceAnnotation: "db-upgrade"}
+			}
+			r := &ChiaWalletReconciler{}
+
+			stateful := r.assembleStatefulSet(context.Background(), tt.wallet)
+			if stateful.Name != "wallet-wallet" {
+				t.Errorf("Expected StatefulSet name wallet-wallet, got %s", stateful.Name)
+			}
+			if *stateful.Spec.Replicas != tt.expectedReplicas {
+				t.Errorf("Expected %d replicas, got %d", tt.expectedReplicas, *stateful.Spec.Replicas)
+			}
+			var templates []string
+			for _, template := range stateful.Spec.VolumeClaimTemplates {
+				templates = append(templates, template.Name)
+			}
+			if diff := cmp.Diff(tt.expectedTemplates, templates); diff != "" {
+				t.Errorf("Volume claim templates do not match. Diff: %s", diff)
+			}
+			if stateful.Spec.PersistentVolumeClaimRetentionPolicy.WhenDeleted != tt.expectedRetention {
+				t.Errorf("Expected %s retention when deleted, got %s", tt.expectedRetention, stateful.Spec.PersistentVolumeClaimRetentionPolicy.WhenDeleted)
+			}
+		})
+	}
+}
+
+func TestReconcileVolumeClaimTemplates(t *testing.T) {
+	newStatefulSet := func(request string) *appsv1.StatefulSet {
+		stateful := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "wallet-wallet", Namespace: "chia"},
+		}
+		if request != "" {
+			stateful.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "chiaroot"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(request)},
+						},
+					},
+				},
+			}
+		}
+		return stateful
+	}
+	tests := []struct {
+		name             string
+		current          []client.Object
+		expectedRecreate bool
+		expectedExists   bool
+	}{
+		{
+			name: "no statefulset",
+		},
+		{
+			name:           "templates match",
+			current:        []client.Object{newStatefulSet("10Gi")},
+			expectedExists: true,
+		},
+		{
+			name:             "request changed",
+			current:          []client.Object{newStatefulSet("5Gi")},
+			expectedRecreate: true,
+		},
+		{
+			name:             "template added",
+			current:          []client.Object{newStatefulSet("")},
+			expectedRecreate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ChiaWalletReconciler{
+				Client: fake.NewClientBuilder().WithObjects(tt.current...).Build(),
+			}
+
+			recreate, err := r.reconcileVolumeClaimTemplates(context.Background(), *newStatefulSet("10Gi"))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if recreate != tt.expectedRecreate {
+				t.Errorf("Expected recreate to be %t, got %t", tt.expectedRecreate, recreate)
+			}
+
+			var current appsv1.StatefulSet
+			err = r.Get(context.Background(), types.NamespacedName{Namespace: "chia", Name: "wallet-wallet"}, &current)
+			if exists := !errors.IsNotFound(err); exists != tt.expectedExists {
+				t.Errorf("Expected StatefulSet to exist to be %t, got %t", tt.expectedExists, exists)
+			}
+		})
+	}
+}
@@ -7,6 +7,10 @@ package consts
 // ControllerOwner bool to help set the controller owner for a create kubernetes Kind
 var ControllerOwner = true
 
+// MaintenanceAnnotation is set on a chia component CR by a ChiaMaintenance that needs exclusive access to its volumes.
+// Its value is the name of the ChiaMaintenance, and the component's workload is scaled to zero while it is set.
+const MaintenanceAnnotation = "k8s.chia.net/maintenance"
+
 const (
 	// DaemonPort defines the port for the Chia daemon
 	DaemonPort = 55400
@@ -50,3 +54,58 @@ const (
 	// ChiaExporterPort defines the port for Chia Exporter instances
 	ChiaExporterPort = 9914
 )
+
+const (
+	// TimelordIdleCondition is the status condition type raised when a timelord has not infused a proof within its idle threshold
+	TimelordIdleCondition = "Idle"
+
+	// TimelordRPCAvailableCondition is the status condition type that reports whether the timelord RPC could be queried
+	TimelordRPCAvailableCondition = "RPCAvailable"
+
+	// BackupsDisabledCondition is the status condition type raised when a ChiaNode configures backups but the VolumeSnapshot CRDs aren't installed
+	BackupsDisabledCondition = "BackupsDisabled"
+
+	// ResizeUnsupportedCondition is the status condition type raised when a ChiaNode's volume claims need expanding but their StorageClass doesn't allow it
+	ResizeUnsupportedCondition = "ResizeUnsupported"
+
+	// PeerNotFoundCondition is the status condition type raised when ChiaNodes listed as in-cluster peers don't exist
+	PeerNotFoundCondition = "PeerNotFound"
+
+	// PeerSyncedServiceDisabledCondition is the status condition type raised when ChiaNodes listed as in-cluster peers have their synced Service disabled
+	PeerSyncedServiceDisabledCondition = "PeerSyncedServiceDisabled"
+
+	// HeadlessServiceDisabledCondition is the status condition type raised when a ChiaNode's headless Service is disabled
+	HeadlessServiceDisabledCondition = "HeadlessServiceDisabled"
+
+	// MonitorsDisabledCondition is the status condition type raised when a CR configures a chia-exporter monitor but its Prometheus Operator CRD isn't installed
+	MonitorsDisabledCondition = "MonitorsDisabled"
+
+	// AlertingDisabledCondition is the status condition type raised when a CR configures alerting but the PrometheusRule CRD isn't installed
+	AlertingDisabledCondition = "AlertingDisabled"
+
+	// AlertingWithoutMonitorCondition is the status condition type raised when a CR configures alerting without a chia-exporter monitor to scrape its metrics
+	AlertingWithoutMonitorCondition = "AlertingWithoutMonitor"
+
+	// PlotCheckUnsupportedCondition is the status condition type raised when a ChiaHarvester schedules plot checks but runs as a DaemonSet or in shards
+	PlotCheckUnsupportedCondition = "PlotCheckUnsupported"
+)
+
+const (
+	// NodeTerminationGracePeriodSeconds is the default termination grace period for ChiaNode pods, long enough for the blockchain database to be flushed
+	NodeTerminationGracePeriodSeconds int64 = 300
+
+	// SeederTerminationGracePeriodSeconds is the default termination grace period for ChiaSeeder pods
+	SeederTerminationGracePeriodSeconds int64 = 120
+
+	// WalletTerminationGracePeriodSeconds is the default termination grace period for ChiaWallet pods
+	WalletTerminationGracePeriodSeconds int64 = 120
+
+	// FarmerTerminationGracePeriodSeconds is the default termination grace period for ChiaFarmer pods
+	FarmerTerminationGracePeriodSeconds int64 = 60
+
+	// HarvesterTerminationGracePeriodSeconds is the default termination grace period for ChiaHarvester pods
+	HarvesterTerminationGracePeriodSeconds int64 = 60
+
+	// TimelordTerminationGracePeriodSeconds is the default termination grace period for ChiaTimelord pods
+	TimelordTerminationGracePeriodSeconds int64 = 60
+)
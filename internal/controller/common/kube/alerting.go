@@ -0,0 +1,131 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+const (
+	// defaultAlertFor is how long an alert's condition must hold before it fires when the alerting config doesn't say
+	defaultAlertFor = "15m"
+
+	// defaultAlertSeverity is the severity label of alerts when the alerting config doesn't say
+	defaultAlertSeverity = "warning"
+)
+
+// AlertingRule is a Prometheus alerting rule for one of a component's conditions
+type AlertingRule struct {
+	// Alert is the name of the alert
+	Alert string
+
+	// Expr is the PromQL expression that fires the alert when it gives any results
+	Expr string
+
+	// Summary is a short human readable description of the alert
+	Summary string
+}
+
+// GetMetricSelector gives the PromQL label matcher that selects a component's chia-exporter metrics by their provenance label
+func GetMetricSelector(ctx context.Context, kind string, meta metav1.ObjectMeta) string {
+	return fmt.Sprintf(`k8s_chia_net_provenance="%s.%s.%s"`, kind, meta.Namespace, meta.Name)
+}
+
+// AssemblePrometheusRule assembles the Prometheus Operator PrometheusRule with a component's alerts, or gives nil if the component doesn't configure alerting.
+// Every component gets a ChiaExporterDown alert besides its own rules. Rules listed in the config's DisabledAlerts are left out.
+// Like chia-exporter monitors, PrometheusRules in the CR's namespace are owned by the CR and PrometheusRules in other namespaces are named after the CR's namespace too.
+func AssemblePrometheusRule(ctx context.Context, kind string, meta metav1.ObjectMeta, workloadName string, additionalLabels map[string]string, ownerRefs []metav1.OwnerReference, config *k8schianetv1.AlertingConfig, rules ...AlertingRule) *unstructured.Unstructured {
+	if config == nil {
+		return nil
+	}
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(PrometheusRuleGVK)
+
+	rule.SetName(workloadName + "-alerts")
+	rule.SetNamespace(meta.Namespace)
+	if config.Namespace != "" && config.Namespace != meta.Namespace {
+		rule.SetName(fmt.Sprintf("%s-%s-alerts", meta.Namespace, workloadName))
+		rule.SetNamespace(config.Namespace)
+	} else {
+		rule.SetOwnerReferences(ownerRefs)
+	}
+	rule.SetLabels(GetCommonLabels(ctx, kind, meta, additionalLabels, config.Labels))
+
+	forDuration := config.For
+	if forDuration == "" {
+		forDuration = defaultAlertFor
+	}
+	severity := config.Severity
+	if severity == "" {
+		severity = defaultAlertSeverity
+	}
+
+	// absent catches chia-exporter's scrape target disappearing entirely, like when its pods are gone or nothing scrapes it
+	selector := GetMetricSelector(ctx, kind, meta)
+	rules = append([]AlertingRule{
+		{
+			Alert:   "ChiaExporterDown",
+			Expr:    fmt.Sprintf("up{%s} == 0 or absent(up{%s})", selector, selector),
+			Summary: fmt.Sprintf("chia-exporter for %s %s/%s is down", kind, meta.Namespace, meta.Name),
+		},
+	}, rules...)
+
+	ruleList := []interface{}{}
+	for _, r := range rules {
+		if isAlertDisabled(config, r.Alert) {
+			continue
+		}
+		ruleList = append(ruleList, map[string]interface{}{
+			"alert": r.Alert,
+			"expr":  r.Expr,
+			"for":   forDuration,
+			"labels": map[string]interface{}{
+				"severity": severity,
+			},
+			"annotations": map[string]interface{}{
+				"summary": r.Summary,
+			},
+		})
+	}
+
+	rule.Object["spec"] = map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name":  fmt.Sprintf("%s.%s.%s", kind, meta.Namespace, meta.Name),
+				"rules": ruleList,
+			},
+		},
+	}
+
+	return rule
+}
+
+// isAlertDisabled says whether an alert is listed in the alerting config's DisabledAlerts
+func isAlertDisabled(config *k8schianetv1.AlertingConfig, alert string) bool {
+	for _, disabled := range config.DisabledAlerts {
+		if disabled == alert {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcilePrometheusRule creates or updates a component's PrometheusRule, and removes any other PrometheusRules the component had,
+// like ones left over from changing the rule's namespace. All of the component's PrometheusRules are removed if rule is nil.
+func ReconcilePrometheusRule(ctx context.Context, c client.Client, rule *unstructured.Unstructured, provenanceLabels map[string]string) error {
+	err := removeUnstructured(ctx, c, []schema.GroupVersionKind{PrometheusRuleGVK}, provenanceLabels, rule)
+	if err != nil || rule == nil {
+		return err
+	}
+	return createOrUpdateUnstructured(ctx, c, rule)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestAssemblePrometheusRule(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: "node", Namespace: "chia"}
+	ownerRefs := []metav1.OwnerReference{{APIVersion: "k8s.chia.net/v1", Kind: "ChiaNode", Name: "node", UID: "node-uid"}}
+	notSynced := AlertingRule{
+		Alert:   "ChiaNodeNotSynced",
+		Expr:    `chia_full_node_sync_synced{k8s_chia_net_provenance="ChiaNode.chia.node"} == 0`,
+		Summary: "ChiaNode chia/node is not synced",
+	}
+	exporterDown := map[string]interface{}{
+		"alert":       "ChiaExporterDown",
+		"expr":        `up{k8s_chia_net_provenance="ChiaNode.chia.node"} == 0 or absent(up{k8s_chia_net_provenance="ChiaNode.chia.node"})`,
+		"for":         "15m",
+		"labels":      map[string]interface{}{"severity": "warning"},
+		"annotations": map[string]interface{}{"summary": "chia-exporter for ChiaNode chia/node is down"},
+	}
+
+	tests := []struct {
+		name              string
+		config            *k8schianetv1.AlertingConfig
+		expectedNamespace string
+		expectedName      string
+		expectedOwned     bool
+		expectedRules     []interface{}
+	}{
+		{
+			name: "alerting not configured",
+		},
+		{
+			name:              "defaults",
+			config:            &k8schianetv1.AlertingConfig{},
+			expectedNamespace: "chia",
+			expectedName:      "node-node-alerts",
+			expectedOwned:     true,
+			expectedRules: []interface{}{
+				exporterDown,
+				map[string]interface{}{
+					"alert":       "ChiaNodeNotSynced",
+					"expr":        notSynced.Expr,
+					"for":         "15m",
+					"labels":      map[string]interface{}{"severity": "warning"},
+					"annotations": map[string]interface{}{"summary": "ChiaNode chia/node is not synced"},
+				},
+			},
+		},
+		{
+			name: "another namespace with a disabled alert",
+			config: &k8schianetv1.AlertingConfig{
+				Namespace:      "monitoring",
+				For:            "5m",
+				Severity:       "critical",
+				DisabledAlerts: []string{"ChiaExporterDown"},
+			},
+			expectedNamespace: "monitoring",
+			expectedName:      "chia-node-node-alerts",
+			expectedRules: []interface{}{
+				map[string]interface{}{
+					"alert":       "ChiaNodeNotSynced",
+					"expr":        notSynced.Expr,
+					"for":         "5m",
+					"labels":      map[string]interface{}{"severity": "critical"},
+					"annotations": map[string]interface{}{"summary": "ChiaNode chia/node is not synced"},
+				},
+			},
+		},
+		{
+			name:              "every alert disabled",
+			config:            &k8schianetv1.AlertingConfig{DisabledAlerts: []string{"ChiaExporterDown", "ChiaNodeNotSynced"}},
+			expectedNamespace: "chia",
+			expectedName:      "node-node-alerts",
+			expectedOwned:     true,
+			expectedRules:     []interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := AssemblePrometheusRule(context.Background(), "ChiaNode", meta, "node-node", nil, ownerRefs, tt.config, notSynced)
+			if tt.config == nil {
+				if rule != nil {
+					t.Errorf("Expected no PrometheusRule, got %v", rule)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatal("Expected a PrometheusRule, got none")
+			}
+
+			if rule.GetNamespace() != tt.expectedNamespace || rule.GetName() != tt.expectedName {
+				t.Errorf("Expected PrometheusRule %s/%s, got %s/%s", tt.expectedNamespace, tt.expectedName, rule.GetNamespace(), rule.GetName())
+			}
+			if owned := len(rule.GetOwnerReferences()) > 0; owned != tt.expectedOwned {
+				t.Errorf("Expected PrometheusRule to be owned to be %t, got %t", tt.expectedOwned, owned)
+			}
+			expectedSpec := map[string]interface{}{
+				"groups": []interface{}{
+					map[string]interface{}{
+						"name":  "ChiaNode.chia.node",
+						"rules": tt.expectedRules,
+					},
+				},
+			}
+			if diff := cmp.Diff(expectedSpec, rule.Object["spec"]); diff != "" {
+				t.Errorf("PrometheusRule spec does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcilePrometheusRule(t *testing.T) {
+	provenance := "ChiaNode.chia.node"
+	provenanceLabels := map[string]string{provenanceLabel: provenance}
+	newRule := func(namespace, name, group string) *unstructured.Unstructured {
+		rule := newUnstructured(PrometheusRuleGVK, namespace, name, provenance)
+		rule.Object["spec"] = map[string]interface{}{
+			"groups": []interface{}{map[string]interface{}{"name": group}},
+		}
+		return rule
+	}
+
+	tests := []struct {
+		name          string
+		existing      []client.Object
+		rule          *unstructured.Unstructured
+		expectedRules []string
+		expectedGroup string
+	}{
+		{
+			name:          "creates the rule",
+			rule:          newRule("chia", "node-node-alerts", "new"),
+			expectedRules: []string{"chia/node-node-alerts"},
+			expectedGroup: "new",
+		},
+		{
+			name:          "updates the rule",
+			existing:      []client.Object{newRule("chia", "node-node-alerts", "old")},
+			rule:          newRule("chia", "node-node-alerts", "new"),
+			expectedRules: []string{"chia/node-node-alerts"},
+			expectedGroup: "new",
+		},
+		{
+			name:          "removes the rule left over from a namespace change",
+			existing:      []client.Object{newRule("chia", "node-node-alerts", "old")},
+			rule:          newRule("monitoring", "chia-node-node-alerts", "new"),
+			expectedRules: []string{"monitoring/chia-node-node-alerts"},
+			expectedGroup: "new",
+		},
+		{
+			name: "removes all rules without alerting configured",
+			existing: []client.Object{
+				newRule("chia", "node-node-alerts", "old"),
+				newRule("monitoring", "chia-node-node-alerts", "old"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(newPrometheusScheme(t)).WithObjects(tt.existing...).Build()
+
+			err := ReconcilePrometheusRule(context.Background(), c, tt.rule, provenanceLabels)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedRules, listUnstructuredNames(t, c, PrometheusRuleGVK)); diff != "" {
+				t.Errorf("PrometheusRules do not match. Diff: %s", diff)
+			}
+			if tt.rule == nil {
+				return
+			}
+
+			var actual unstructured.Unstructured
+			actual.SetGroupVersionKind(PrometheusRuleGVK)
+			err = c.Get(context.Background(), client.ObjectKeyFromObject(tt.rule), &actual)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			group, _, _ := unstructured.NestedSlice(actual.Object, "spec", "groups")
+			if len(group) != 1 || group[0].(map[string]interface{})["name"] != tt.expectedGroup {
+				t.Errorf("Expected rule group %s, got %v", tt.expectedGroup, group)
+			}
+		})
+	}
+}
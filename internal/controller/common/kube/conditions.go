@@ -0,0 +1,37 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetWarningCondition raises or clears a status condition that reports a problem with a CR's configuration or its cluster.
+// The condition's type is also its reason and the reason of its Warning event. The event is only recorded when the condition is raised
+// or its message changes, so a problem that persists across reconciles doesn't record an event on each one. Returns true if the event was recorded.
+func SetWarningCondition(recorder record.EventRecorder, obj client.Object, conditions *[]metav1.Condition, conditionType string, raised bool, message string) bool {
+	if !raised {
+		meta.RemoveStatusCondition(conditions, conditionType)
+		return false
+	}
+
+	previous := meta.FindStatusCondition(*conditions, conditionType)
+	changed := previous == nil || previous.Status != metav1.ConditionTrue || previous.Message != message
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: obj.GetGeneration(),
+		Reason:             conditionType,
+		Message:            message,
+	})
+	if changed {
+		recorder.Event(obj, corev1.EventTypeWarning, conditionType, message)
+	}
+	return changed
+}
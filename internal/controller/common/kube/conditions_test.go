@@ -0,0 +1,59 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSetWarningCondition(t *testing.T) {
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "chia", Generation: 1}}
+	var conditions []metav1.Condition
+
+	steps := []struct {
+		name          string
+		raised        bool
+		message       string
+		expectEvent   bool
+		expectPresent bool
+	}{
+		{name: "raised", raised: true, message: "first", expectEvent: true, expectPresent: true},
+		{name: "still raised", raised: true, message: "first", expectEvent: false, expectPresent: true},
+		{name: "message changed", raised: true, message: "second", expectEvent: true, expectPresent: true},
+		{name: "cleared", raised: false, expectEvent: false, expectPresent: false},
+		{name: "still cleared", raised: false, expectEvent: false, expectPresent: false},
+		{name: "raised again", raised: true, message: "second", expectEvent: true, expectPresent: true},
+	}
+
+	// Steps run in order against the same conditions, like consecutive reconciles
+	for _, step := range steps {
+		recorder := record.NewFakeRecorder(10)
+		recorded := SetWarningCondition(recorder, obj, &conditions, "Broken", step.raised, step.message)
+		if recorded != step.expectEvent {
+			t.Errorf("%s: expected event recorded to be %t, got %t", step.name, step.expectEvent, recorded)
+		}
+		if !step.expectEvent && len(recorder.Events) != 0 {
+			t.Errorf("%s: expected no events, got %d", step.name, len(recorder.Events))
+		}
+		if step.expectEvent && len(recorder.Events) == 1 {
+			if event := <-recorder.Events; event != "Warning Broken "+step.message {
+				t.Errorf("%s: unexpected event: %s", step.name, event)
+			}
+		}
+
+		condition := meta.FindStatusCondition(conditions, "Broken")
+		if (condition != nil) != step.expectPresent {
+			t.Fatalf("%s: expected condition present to be %t", step.name, step.expectPresent)
+		}
+		if condition != nil && (condition.Status != metav1.ConditionTrue || condition.Reason != "Broken" || condition.Message != step.message) {
+			t.Errorf("%s: unexpected condition: %+v", step.name, *condition)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// VolumeSnapshotGVK is the GroupVersionKind of CSI VolumeSnapshots
+var VolumeSnapshotGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshot",
+}
+
+// ServiceMonitorGVK is the GroupVersionKind of Prometheus Operator ServiceMonitors
+var ServiceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// PodMonitorGVK is the GroupVersionKind of Prometheus Operator PodMonitors
+var PodMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PodMonitor",
+}
+
+// PrometheusRuleGVK is the GroupVersionKind of Prometheus Operator PrometheusRules
+var PrometheusRuleGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PrometheusRule",
+}
+
+// IsKindAvailable returns true if the cluster serves the given kind, for optional integrations with CRDs that may not be installed
+func IsKindAvailable(mapper meta.RESTMapper, gvk schema.GroupVersionKind) (bool, error) {
+	_, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PrometheusOperatorAPIs says which of the Prometheus Operator's kinds the cluster serves. Each CRD can be installed without the others,
+// so each kind of chia-exporter monitor and alerting rules are enabled independently.
+type PrometheusOperatorAPIs struct {
+	ServiceMonitors bool
+	PodMonitors     bool
+	PrometheusRules bool
+}
+
+// DiscoverPrometheusOperatorAPIs checks which of the Prometheus Operator's kinds the cluster serves
+func DiscoverPrometheusOperatorAPIs(mapper meta.RESTMapper) (PrometheusOperatorAPIs, error) {
+	var apis PrometheusOperatorAPIs
+	var err error
+	apis.ServiceMonitors, err = IsKindAvailable(mapper, ServiceMonitorGVK)
+	if err != nil {
+		return apis, err
+	}
+	apis.PodMonitors, err = IsKindAvailable(mapper, PodMonitorGVK)
+	if err != nil {
+		return apis, err
+	}
+	apis.PrometheusRules, err = IsKindAvailable(mapper, PrometheusRuleGVK)
+	return apis, err
+}
+
+// MonitorKinds gives the chia-exporter monitor kinds the cluster serves
+func (a PrometheusOperatorAPIs) MonitorKinds() []schema.GroupVersionKind {
+	var gvks []schema.GroupVersionKind
+	if a.ServiceMonitors {
+		gvks = append(gvks, ServiceMonitorGVK)
+	}
+	if a.PodMonitors {
+		gvks = append(gvks, PodMonitorGVK)
+	}
+	return gvks
+}
+
+// Serves says whether the cluster serves one of the Prometheus Operator's kinds
+func (a PrometheusOperatorAPIs) Serves(gvk schema.GroupVersionKind) bool {
+	switch gvk {
+	case ServiceMonitorGVK:
+		return a.ServiceMonitors
+	case PodMonitorGVK:
+		return a.PodMonitors
+	case PrometheusRuleGVK:
+		return a.PrometheusRules
+	}
+	return false
+}
+
+// ServesMonitor says whether the cluster serves the kind of a chia-exporter monitor config, which is true if no monitor is configured
+func (a PrometheusOperatorAPIs) ServesMonitor(config *k8schianetv1.ChiaExporterMonitor) bool {
+	if config == nil {
+		return true
+	}
+	if config.Kind == PodMonitorGVK.Kind {
+		return a.PodMonitors
+	}
+	return a.ServiceMonitors
+}
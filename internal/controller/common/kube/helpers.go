@@ -7,11 +7,14 @@ package kube
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 )
 
@@ -26,57 +29,71 @@ func GetCommonLabels(ctx context.Context, kind string, meta metav1.ObjectMeta, a
 	labels["app.kubernetes.io/instance"] = meta.Name
 	labels["app.kubernetes.io/name"] = meta.Name
 	labels["app.kubernetes.io/managed-by"] = "chia-operator"
-	labels["k8s.chia.net/provenance"] = fmt.Sprintf("%s.%s.%s", kind, meta.Namespace, meta.Name)
+	labels[provenanceLabel] = fmt.Sprintf("%s.%s.%s", kind, meta.Namespace, meta.Name)
 	return labels
 }
 
-// GetChiaExporterContainer assembles a chia-exporter container spec
-func GetChiaExporterContainer(ctx context.Context, image string, secContext *corev1.SecurityContext, pullPolicy corev1.PullPolicy, resReq corev1.ResourceRequirements) corev1.Container {
-	return corev1.Container{
-		Name:            "chia-exporter",
-		SecurityContext: secContext,
-		Image:           image,
-		ImagePullPolicy: pullPolicy,
-		Env: []corev1.EnvVar{
-			{
-				Name:  "CHIA_ROOT",
-				Value: "/chia-data",
+// GetChiaLifecycle assembles the lifecycle hooks for chia containers. The preStop hook stops chia services cleanly so databases are not killed mid-write.
+func GetChiaLifecycle(ctx context.Context) *corev1.Lifecycle {
+	return &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", "chia stop all -d"},
 			},
 		},
+	}
+}
+
+// GetTerminationGracePeriodSeconds gives the termination grace period from a CR's spec, or the component's default if it was not specified
+func GetTerminationGracePeriodSeconds(ctx context.Context, specified *int64, defaultSeconds int64) *int64 {
+	if specified != nil {
+		return specified
+	}
+	return &defaultSeconds
+}
+
+// GetChiaExporterContainer assembles a chia-exporter container spec from a CR's chia-exporter config. chia-exporter uses the chia container's
+// security context and the CR's image pull policy unless its config sets its own, but never the chia container's resources.
+func GetChiaExporterContainer(ctx context.Context, config k8schianetv1.SpecChiaExporter, chiaSecContext *corev1.SecurityContext, pullPolicy corev1.PullPolicy) corev1.Container {
+	port := int32(consts.ChiaExporterPort)
+	if config.MetricsPort != nil {
+		port = *config.MetricsPort
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "CHIA_ROOT",
+			Value: "/chia-data",
+		},
+	}
+	if config.MetricsPort != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "CHIA_EXPORTER_METRICS_PORT",
+			Value: strconv.Itoa(int(port)),
+		})
+	}
+	if config.LogLevel != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "CHIA_EXPORTER_LOG_LEVEL",
+			Value: config.LogLevel,
+		})
+	}
+	env = append(env, config.Env...)
+
+	container := corev1.Container{
+		Name:            "chia-exporter",
+		SecurityContext: chiaSecContext,
+		Image:           config.Image,
+		ImagePullPolicy: pullPolicy,
+		Args:            config.Args,
+		Env:             env,
 		Ports: []corev1.ContainerPort{
 			{
-				Name:          "metrics",
-				ContainerPort: consts.ChiaExporterPort,
+				Name:          chiaExporterPortName,
+				ContainerPort: port,
 				Protocol:      "TCP",
 			},
 		},
-		LivenessProbe: &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/healthz",
-					Port: intstr.FromInt(consts.ChiaExporterPort),
-				},
-			},
-		},
-		ReadinessProbe: &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/healthz",
-					Port: intstr.FromInt(consts.ChiaExporterPort),
-				},
-			},
-		},
-		StartupProbe: &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/healthz",
-					Port: intstr.FromInt(consts.ChiaExporterPort),
-				},
-			},
-			FailureThreshold: 30,
-			PeriodSeconds:    10,
-		},
-		Resources: resReq,
 		VolumeMounts: []corev1.VolumeMount{
 			{
 				Name:      "chiaroot",
@@ -84,4 +101,54 @@ func GetChiaExporterContainer(ctx context.Context, image string, secContext *cor
 			},
 		},
 	}
+
+	if config.ImagePullPolicy != "" {
+		container.ImagePullPolicy = config.ImagePullPolicy
+	}
+
+	if config.SecurityContext != nil {
+		container.SecurityContext = config.SecurityContext
+	}
+
+	if config.Resources != nil {
+		container.Resources = *config.Resources
+	}
+
+	container.LivenessProbe = config.LivenessProbe
+	container.ReadinessProbe = config.ReadinessProbe
+	container.StartupProbe = config.StartupProbe
+
+	return container
+}
+
+// FindVolumeClaimTemplate gives a StatefulSet's volume claim template by name
+func FindVolumeClaimTemplate(stateful appsv1.StatefulSet, name string) *corev1.PersistentVolumeClaim {
+	for i, vct := range stateful.Spec.VolumeClaimTemplates {
+		if vct.Name == name {
+			return &stateful.Spec.VolumeClaimTemplates[i]
+		}
+	}
+	return nil
+}
+
+// VolumeClaimTemplatesMatch compares the volume claim template fields the operator sets, ignoring fields the API server defaults.
+// Data sources only matter when a claim is created, so they're ignored rather than recreating a StatefulSet to change them.
+func VolumeClaimTemplatesMatch(current appsv1.StatefulSet, desired appsv1.StatefulSet) bool {
+	if len(current.Spec.VolumeClaimTemplates) != len(desired.Spec.VolumeClaimTemplates) {
+		return false
+	}
+	for _, desiredTemplate := range desired.Spec.VolumeClaimTemplates {
+		currentTemplate := FindVolumeClaimTemplate(current, desiredTemplate.Name)
+		if currentTemplate == nil {
+			return false
+		}
+		currentRequest := currentTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+		if currentRequest.Cmp(desiredTemplate.Spec.Resources.Requests[corev1.ResourceStorage]) != 0 {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(currentTemplate.Spec.StorageClassName, desiredTemplate.Spec.StorageClassName) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestGetChiaExporterContainer(t *testing.T) {
+	chiaUser := int64(1000)
+	exporterUser := int64(2000)
+	metricsPort := int32(9200)
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/metrics", Port: intstr.FromString("metrics")},
+		},
+		PeriodSeconds: 30,
+	}
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+	}
+	chiaSecContext := &corev1.SecurityContext{RunAsUser: &chiaUser}
+	volumeMounts := []corev1.VolumeMount{{Name: "chiaroot", MountPath: "/chia-data"}}
+
+	tests := []struct {
+		name     string
+		config   k8schianetv1.SpecChiaExporter
+		expected corev1.Container
+	}{
+		{
+			name: "defaults",
+			config: k8schianetv1.SpecChiaExporter{
+				Enabled: true,
+				Image:   "ghcr.io/chia-network/chia-exporter:latest",
+			},
+			expected: corev1.Container{
+				Name:            "chia-exporter",
+				Image:           "ghcr.io/chia-network/chia-exporter:latest",
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				SecurityContext: chiaSecContext,
+				Env:             []corev1.EnvVar{{Name: "CHIA_ROOT", Value: "/chia-data"}},
+				Ports:           []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9914, Protocol: "TCP"}},
+				VolumeMounts:    volumeMounts,
+			},
+		},
+		{
+			name: "configured",
+			config: k8schianetv1.SpecChiaExporter{
+				Enabled:         true,
+				Image:           "ghcr.io/chia-network/chia-exporter:latest",
+				ImagePullPolicy: corev1.PullAlways,
+				LogLevel:        "debug",
+				MetricsPort:     &metricsPort,
+				Args:            []string{"serve"},
+				Env:             []corev1.EnvVar{{Name: "CHIA_EXPORTER_MAXMIND_DB_PATH", Value: "/geoip/db.mmdb"}},
+				Resources:       &resources,
+				SecurityContext: &corev1.SecurityContext{RunAsUser: &exporterUser},
+				LivenessProbe:   probe,
+				ReadinessProbe:  probe,
+				StartupProbe:    probe,
+			},
+			expected: corev1.Container{
+				Name:            "chia-exporter",
+				Image:           "ghcr.io/chia-network/chia-exporter:latest",
+				ImagePullPolicy: corev1.PullAlways,
+				SecurityContext: &corev1.SecurityContext{RunAsUser: &exporterUser},
+				Args:            []string{"serve"},
+				Env: []corev1.EnvVar{
+					{Name: "CHIA_ROOT", Value: "/chia-data"},
+					{Name: "CHIA_EXPORTER_METRICS_PORT", Value: "9200"},
+					{Name: "CHIA_EXPORTER_LOG_LEVEL", Value: "debug"},
+					{Name: "CHIA_EXPORTER_MAXMIND_DB_PATH", Value: "/geoip/db.mmdb"},
+				},
+				Ports:          []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9200, Protocol: "TCP"}},
+				VolumeMounts:   volumeMounts,
+				Resources:      resources,
+				LivenessProbe:  probe,
+				ReadinessProbe: probe,
+				StartupProbe:   probe,
+			},
+		},
+		{
+			name: "only a readiness probe",
+			config: k8schianetv1.SpecChiaExporter{
+				Enabled:        true,
+				Image:          "ghcr.io/chia-network/chia-exporter:latest",
+				ReadinessProbe: probe,
+			},
+			expected: corev1.Container{
+				Name:            "chia-exporter",
+				Image:           "ghcr.io/chia-network/chia-exporter:latest",
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				SecurityContext: chiaSecContext,
+				Env:             []corev1.EnvVar{{Name: "CHIA_ROOT", Value: "/chia-data"}},
+				Ports:           []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9914, Protocol: "TCP"}},
+				VolumeMounts:    volumeMounts,
+				ReadinessProbe:  probe,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := GetChiaExporterContainer(context.Background(), tt.config, chiaSecContext, corev1.PullIfNotPresent)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("chia-exporter container does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// ChiaPodConfig holds the parts of a chia component's pod spec that ChiaMaintenance tasks reuse to run against the same storage
+type ChiaPodConfig struct {
+	// WorkloadName is the name of the Deployment or StatefulSet that runs the component
+	WorkloadName string
+
+	// StatefulSet is true if the workload is a StatefulSet rather than a Deployment
+	StatefulSet bool
+
+	// Unsupported is the reason ChiaMaintenance tasks can't run against the component in its current configuration, if any
+	Unsupported string
+
+	Image           string
+	ImagePullPolicy corev1.PullPolicy
+	Env             []corev1.EnvVar
+	Volumes         []corev1.Volume
+	VolumeMounts    []corev1.VolumeMount
+	NodeSelector    map[string]string
+	SecurityContext *corev1.PodSecurityContext
+}
+
+// IsPausedForMaintenance returns true if a ChiaMaintenance has requested exclusive access to a chia component's volumes
+func IsPausedForMaintenance(meta metav1.ObjectMeta) bool {
+	_, paused := meta.Annotations[consts.MaintenanceAnnotation]
+	return paused
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// chiaExporterPortName is the name of the chia-exporter port on the chia-exporter container and Service
+const chiaExporterPortName = "metrics"
+
+// provenanceLabel is the common label naming the CR that owns a resource. Monitors copy it onto the scraped metrics,
+// where Prometheus sanitizes it to k8s_chia_net_provenance, so alerting rules can select a component's metrics.
+const provenanceLabel = "k8s.chia.net/provenance"
+
+// relabelingsToUnstructured converts relabeling rules to their unstructured form, which has the same field names as the Prometheus Operator's
+func relabelingsToUnstructured(relabelings []k8schianetv1.RelabelConfig) ([]interface{}, error) {
+	data, err := json.Marshal(relabelings)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// AssembleChiaExporterMonitor assembles the Prometheus Operator ServiceMonitor or PodMonitor that scrapes a component's chia-exporter,
+// or gives nil if the component doesn't run chia-exporter or doesn't configure a monitor.
+// The monitor selects the component's chia-exporter Service or pods by the CR's common labels. Monitors in the CR's namespace are owned by the CR,
+// monitors in other namespaces are named after the CR's namespace too so they don't collide, and are removed by RemoveChiaExporterMonitors.
+// The CR's provenance and instance labels are copied onto the scraped metrics.
+func AssembleChiaExporterMonitor(ctx context.Context, kind string, meta metav1.ObjectMeta, workloadName string, additionalLabels map[string]string, ownerRefs []metav1.OwnerReference, exporter k8schianetv1.SpecChiaExporter) (*unstructured.Unstructured, error) {
+	if !exporter.Enabled || exporter.Monitor == nil {
+		return nil, nil
+	}
+	config := *exporter.Monitor
+	monitor := &unstructured.Unstructured{}
+
+	gvk := ServiceMonitorGVK
+	endpointsField := "endpoints"
+	targetLabelsField := "targetLabels"
+	if config.Kind == PodMonitorGVK.Kind {
+		gvk = PodMonitorGVK
+		endpointsField = "podMetricsEndpoints"
+		targetLabelsField = "podTargetLabels"
+	}
+	monitor.SetGroupVersionKind(gvk)
+
+	monitor.SetName(workloadName + "-metrics")
+	monitor.SetNamespace(meta.Namespace)
+	if config.Namespace != "" && config.Namespace != meta.Namespace {
+		monitor.SetName(fmt.Sprintf("%s-%s-metrics", meta.Namespace, workloadName))
+		monitor.SetNamespace(config.Namespace)
+	} else {
+		monitor.SetOwnerReferences(ownerRefs)
+	}
+	monitor.SetLabels(GetCommonLabels(ctx, kind, meta, additionalLabels, config.Labels))
+
+	endpoint := map[string]interface{}{
+		"port": chiaExporterPortName,
+	}
+	if config.Interval != "" {
+		endpoint["interval"] = config.Interval
+	}
+	if config.ScrapeTimeout != "" {
+		endpoint["scrapeTimeout"] = config.ScrapeTimeout
+	}
+	if len(config.Relabelings) > 0 {
+		relabelings, err := relabelingsToUnstructured(config.Relabelings)
+		if err != nil {
+			return nil, err
+		}
+		endpoint["relabelings"] = relabelings
+	}
+	if len(config.MetricRelabelings) > 0 {
+		metricRelabelings, err := relabelingsToUnstructured(config.MetricRelabelings)
+		if err != nil {
+			return nil, err
+		}
+		endpoint["metricRelabelings"] = metricRelabelings
+	}
+
+	selector := make(map[string]interface{})
+	for k, v := range GetCommonLabels(ctx, kind, meta) {
+		selector[k] = v
+	}
+
+	monitor.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": selector,
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{meta.Namespace},
+		},
+		endpointsField:    []interface{}{endpoint},
+		targetLabelsField: []interface{}{provenanceLabel, "app.kubernetes.io/instance"},
+	}
+
+	return monitor, nil
+}
+
+// ReconcileChiaExporterMonitor creates or updates a component's chia-exporter monitor, and removes any other monitors the component had,
+// like ones left over from changing the monitor's kind or namespace. All of the component's monitors are removed if monitor is nil.
+// A monitor whose kind the cluster doesn't serve isn't created.
+func ReconcileChiaExporterMonitor(ctx context.Context, c client.Client, apis PrometheusOperatorAPIs, monitor *unstructured.Unstructured, provenanceLabels map[string]string) error {
+	if monitor != nil && !apis.Serves(monitor.GroupVersionKind()) {
+		monitor = nil
+	}
+	err := removeUnstructured(ctx, c, apis.MonitorKinds(), provenanceLabels, monitor)
+	if err != nil || monitor == nil {
+		return err
+	}
+	return createOrUpdateUnstructured(ctx, c, monitor)
+}
+
+// RemoveChiaExporterMonitors removes all of a component's chia-exporter monitors, in any namespace
+func RemoveChiaExporterMonitors(ctx context.Context, c client.Client, apis PrometheusOperatorAPIs, provenanceLabels map[string]string) error {
+	return removeUnstructured(ctx, c, apis.MonitorKinds(), provenanceLabels, nil)
+}
+
+// RemoveOrphanedPrometheusObjects removes the chia-exporter monitors and PrometheusRules whose CR no longer exists. Ones in other namespaces than
+// their CR aren't garbage collected with it, and are otherwise only removed when the CR's deletion is reconciled, so this catches the ones left
+// behind by CRs that were deleted while the operator wasn't running. CRs are read with reader, which should read from the API server directly.
+func RemoveOrphanedPrometheusObjects(ctx context.Context, c client.Client, reader client.Reader, apis PrometheusOperatorAPIs) error {
+	gvks := apis.MonitorKinds()
+	if apis.PrometheusRules {
+		gvks = append(gvks, PrometheusRuleGVK)
+	}
+
+	for _, gvk := range gvks {
+		var list unstructured.UnstructuredList
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := reader.List(ctx, &list, client.HasLabels{provenanceLabel})
+		if err != nil {
+			return err
+		}
+
+		for i, obj := range list.Items {
+			// The provenance label is <kind>.<namespace>.<name>, kinds and namespaces can't contain dots but names can
+			parts := strings.SplitN(obj.GetLabels()[provenanceLabel], ".", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			var owner unstructured.Unstructured
+			owner.SetGroupVersionKind(k8schianetv1.GroupVersion.WithKind(parts[0]))
+			err = reader.Get(ctx, types.NamespacedName{Namespace: parts[1], Name: parts[2]}, &owner)
+			if err == nil || !errors.IsNotFound(err) {
+				continue
+			}
+			err = c.Delete(ctx, &list.Items[i])
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// createOrUpdateUnstructured creates an object of an optional CRD, or updates its labels, owners and spec if it exists
+func createOrUpdateUnstructured(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	var current unstructured.Unstructured
+	current.SetGroupVersionKind(obj.GroupVersionKind())
+	err := c.Get(ctx, client.ObjectKeyFromObject(obj), &current)
+	if err != nil && errors.IsNotFound(err) {
+		return c.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	current.SetLabels(obj.GetLabels())
+	current.SetOwnerReferences(obj.GetOwnerReferences())
+	current.Object["spec"] = obj.Object["spec"]
+	return c.Update(ctx, &current)
+}
+
+// removeUnstructured removes the objects of the given kinds with the given labels in any namespace, except for keep if it's given
+func removeUnstructured(ctx context.Context, c client.Client, gvks []schema.GroupVersionKind, provenanceLabels map[string]string, keep *unstructured.Unstructured) error {
+	for _, gvk := range gvks {
+		var list unstructured.UnstructuredList
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := c.List(ctx, &list, client.MatchingLabels(provenanceLabels))
+		if err != nil {
+			return err
+		}
+
+		for i, obj := range list.Items {
+			if keep != nil && gvk == keep.GroupVersionKind() && obj.GetNamespace() == keep.GetNamespace() && obj.GetName() == keep.GetName() {
+				continue
+			}
+			err = c.Delete(ctx, &list.Items[i])
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// newPrometheusScheme gives a scheme with the built-in types, the chia CRDs and the Prometheus Operator's kinds, which the fake client needs to store them
+func newPrometheusScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8schianetv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, gvk := range []schema.GroupVersionKind{ServiceMonitorGVK, PodMonitorGVK, PrometheusRuleGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+	return scheme
+}
+
+// newUnstructured gives an object of an optional CRD with the given provenance label
+func newUnstructured(gvk schema.GroupVersionKind, namespace, name, provenance string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{provenanceLabel: provenance})
+	return obj
+}
+
+// listUnstructuredNames gives the namespace/name of each object of the given kind in the fake client
+func listUnstructuredNames(t *testing.T, c client.Client, gvk schema.GroupVersionKind) []string {
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := c.List(context.Background(), &list); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, obj := range list.Items {
+		names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+	}
+	return names
+}
+
+func TestAssembleChiaExporterMonitor(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: "node", Namespace: "chia"}
+	ownerRefs := []metav1.OwnerReference{{APIVersion: "k8s.chia.net/v1", Kind: "ChiaNode", Name: "node", UID: "node-uid"}}
+	selector := map[string]interface{}{
+		"app.kubernetes.io/instance":   "node",
+		"app.kubernetes.io/name":       "node",
+		"app.kubernetes.io/managed-by": "chia-operator",
+		provenanceLabel:                "ChiaNode.chia.node",
+	}
+	action := "drop"
+
+	tests := []struct {
+		name              string
+		exporter          k8schianetv1.SpecChiaExporter
+		expectedGVK       schema.GroupVersionKind
+		expectedNamespace string
+		expectedName      string
+		expectedOwned     bool
+		expectedSpec      map[string]interface{}
+	}{
+		{
+			name:     "exporter disabled",
+			exporter: k8schianetv1.SpecChiaExporter{Monitor: &k8schianetv1.ChiaExporterMonitor{}},
+		},
+		{
+			name:     "no monitor",
+			exporter: k8schianetv1.SpecChiaExporter{Enabled: true},
+		},
+		{
+			name:              "service monitor",
+			exporter:          k8schianetv1.SpecChiaExporter{Enabled: true, Monitor: &k8schianetv1.ChiaExporterMonitor{}},
+			expectedGVK:       ServiceMonitorGVK,
+			expectedNamespace: "chia",
+			expectedName:      "node-node-metrics",
+			expectedOwned:     true,
+			expectedSpec: map[string]interface{}{
+				"selector":          map[string]interface{}{"matchLabels": selector},
+				"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{"chia"}},
+				"endpoints":         []interface{}{map[string]interface{}{"port": "metrics"}},
+				"targetLabels":      []interface{}{provenanceLabel, "app.kubernetes.io/instance"},
+			},
+		},
+		{
+			name: "pod monitor in another namespace",
+			exporter: k8schianetv1.SpecChiaExporter{Enabled: true, Monitor: &k8schianetv1.ChiaExporterMonitor{
+				Kind:          "PodMonitor",
+				Namespace:     "monitoring",
+				Interval:      "30s",
+				ScrapeTimeout: "10s",
+				MetricRelabelings: []k8schianetv1.RelabelConfig{
+					{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: action},
+				},
+			}},
+			expectedGVK:       PodMonitorGVK,
+			expectedNamespace: "monitoring",
+			expectedName:      "chia-node-node-metrics",
+			expectedSpec: map[string]interface{}{
+				"selector":          map[string]interface{}{"matchLabels": selector},
+				"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{"chia"}},
+				"podMetricsEndpoints": []interface{}{map[string]interface{}{
+					"port":          "metrics",
+					"interval":      "30s",
+					"scrapeTimeout": "10s",
+					"metricRelabelings": []interface{}{map[string]interface{}{
+						"sourceLabels": []interface{}{"__name__"},
+						"regex":        "go_.*",
+						"action":       "drop",
+					}},
+				}},
+				"podTargetLabels": []interface{}{provenanceLabel, "app.kubernetes.io/instance"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor, err := AssembleChiaExporterMonitor(context.Background(), "ChiaNode", meta, "node-node", nil, ownerRefs, tt.exporter)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.expectedSpec == nil {
+				if monitor != nil {
+					t.Errorf("Expected no monitor, got %v", monitor)
+				}
+				return
+			}
+			if monitor == nil {
+				t.Fatal("Expected a monitor, got none")
+			}
+
+			if monitor.GroupVersionKind() != tt.expectedGVK {
+				t.Errorf("Expected kind %s, got %s", tt.expectedGVK, monitor.GroupVersionKind())
+			}
+			if monitor.GetNamespace() != tt.expectedNamespace || monitor.GetName() != tt.expectedName {
+				t.Errorf("Expected monitor %s/%s, got %s/%s", tt.expectedNamespace, tt.expectedName, monitor.GetNamespace(), monitor.GetName())
+			}
+			if owned := len(monitor.GetOwnerReferences()) > 0; owned != tt.expectedOwned {
+				t.Errorf("Expected monitor to be owned to be %t, got %t", tt.expectedOwned, owned)
+			}
+			if diff := cmp.Diff(tt.expectedSpec, monitor.Object["spec"]); diff != "" {
+				t.Errorf("Monitor spec does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileChiaExporterMonitor(t *testing.T) {
+	provenance := "ChiaNode.chia.node"
+	provenanceLabels := map[string]string{provenanceLabel: provenance}
+	allAPIs := PrometheusOperatorAPIs{ServiceMonitors: true, PodMonitors: true}
+
+	tests := []struct {
+		name                   string
+		apis                   PrometheusOperatorAPIs
+		existing               []client.Object
+		monitor                *unstructured.Unstructured
+		expectedServiceMonitor []string
+		expectedPodMonitor     []string
+	}{
+		{
+			name:                   "creates the monitor",
+			apis:                   allAPIs,
+			monitor:                newUnstructured(ServiceMonitorGVK, "chia", "node-node-metrics", provenance),
+			expectedServiceMonitor: []string{"chia/node-node-metrics"},
+		},
+		{
+			name: "removes monitors left over from a kind or namespace change",
+			apis: allAPIs,
+			existing: []client.Object{
+				newUnstructured(ServiceMonitorGVK, "chia", "node-node-metrics", provenance),
+				newUnstructured(ServiceMonitorGVK, "monitoring", "chia-node-node-metrics", provenance),
+				newUnstructured(ServiceMonitorGVK, "chia", "other-node-metrics", "ChiaNode.chia.other"),
+			},
+			monitor:                newUnstructured(PodMonitorGVK, "chia", "node-node-metrics", provenance),
+			expectedServiceMonitor: []string{"chia/other-node-metrics"},
+			expectedPodMonitor:     []string{"chia/node-node-metrics"},
+		},
+		{
+			name: "removes all monitors without one configured",
+			apis: allAPIs,
+			existing: []client.Object{
+				newUnstructured(ServiceMonitorGVK, "chia", "node-node-metrics", provenance),
+				newUnstructured(PodMonitorGVK, "monitoring", "chia-node-node-metrics", provenance),
+			},
+		},
+		{
+			name:    "doesn't create a monitor whose kind isn't served",
+			apis:    PrometheusOperatorAPIs{PodMonitors: true},
+			monitor: newUnstructured(ServiceMonitorGVK, "chia", "node-node-metrics", provenance),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(newPrometheusScheme(t)).WithObjects(tt.existing...).Build()
+
+			err := ReconcileChiaExporterMonitor(context.Background(), c, tt.apis, tt.monitor, provenanceLabels)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedServiceMonitor, listUnstructuredNames(t, c, ServiceMonitorGVK)); diff != "" {
+				t.Errorf("ServiceMonitors do not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedPodMonitor, listUnstructuredNames(t, c, PodMonitorGVK)); diff != "" {
+				t.Errorf("PodMonitors do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRemoveOrphanedPrometheusObjects(t *testing.T) {
+	node := &k8schianetv1.ChiaNode{ObjectMeta: metav1.ObjectMeta{Name: "node", Namespace: "chia"}}
+	existing := []client.Object{
+		node,
+		newUnstructured(ServiceMonitorGVK, "monitoring", "chia-node-node-metrics", "ChiaNode.chia.node"),
+		newUnstructured(ServiceMonitorGVK, "monitoring", "chia-gone-node-metrics", "ChiaNode.chia.gone"),
+		newUnstructured(ServiceMonitorGVK, "monitoring", "unparseable-metrics", "ChiaNode"),
+		newUnstructured(PrometheusRuleGVK, "monitoring", "chia-gone-node-rules", "ChiaNode.chia.gone"),
+		newUnstructured(PrometheusRuleGVK, "monitoring", "chia-node-node-rules", "ChiaNode.chia.node"),
+	}
+
+	tests := []struct {
+		name                   string
+		apis                   PrometheusOperatorAPIs
+		expectedServiceMonitor []string
+		expectedRules          []string
+	}{
+		{
+			name:                   "monitors and rules",
+			apis:                   PrometheusOperatorAPIs{ServiceMonitors: true, PrometheusRules: true},
+			expectedServiceMonitor: []string{"monitoring/chia-node-node-metrics", "monitoring/unparseable-metrics"},
+			expectedRules:          []string{"monitoring/chia-node-node-rules"},
+		},
+		{
+			name:                   "rules not served",
+			apis:                   PrometheusOperatorAPIs{ServiceMonitors: true},
+			expectedServiceMonitor: []string{"monitoring/chia-node-node-metrics", "monitoring/unparseable-metrics"},
+			expectedRules:          []string{"monitoring/chia-gone-node-rules", "monitoring/chia-node-node-rules"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(newPrometheusScheme(t)).WithObjects(existing...).Build()
+
+			err := RemoveOrphanedPrometheusObjects(context.Background(), c, c, tt.apis)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedServiceMonitor, listUnstructuredNames(t, c, ServiceMonitorGVK)); diff != "" {
+				t.Errorf("ServiceMonitors do not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedRules, listUnstructuredNames(t, c, PrometheusRuleGVK)); diff != "" {
+				t.Errorf("PrometheusRules do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
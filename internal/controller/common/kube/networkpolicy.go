@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// defaultMonitoringNamespace is the namespace allowed to scrape chia-exporter when a NetworkPolicy doesn't specify who can
+const defaultMonitoringNamespace = "monitoring"
+
+const (
+	// operatorNamespaceEnv is the environment variable the operator's namespace is read from, set from the downward API in its Deployment
+	operatorNamespaceEnv = "POD_NAMESPACE"
+
+	// operatorPodLabel is set on the operator's pods, and is unique to this operator unlike the scaffolded control-plane label
+	operatorPodLabel = "k8s.chia.net/operator"
+)
+
+// getOperatorPeers selects the operator's pods, which query chia RPC servers for health and sync status. The operator's pods are only
+// selected in the operator's own namespace, so nothing is allowed if the operator isn't running in a pod that knows its namespace.
+func getOperatorPeers() []networkingv1.NetworkPolicyPeer {
+	namespace := os.Getenv(operatorNamespaceEnv)
+	if namespace == "" {
+		return nil
+	}
+
+	return []networkingv1.NetworkPolicyPeer{
+		{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"kubernetes.io/metadata.name": namespace,
+				},
+			},
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					operatorPodLabel: "controller-manager",
+				},
+			},
+		},
+	}
+}
+
+// NamedPort gives a NetworkPolicy port for a named container port
+func NamedPort(name string, protocol corev1.Protocol) networkingv1.NetworkPolicyPort {
+	port := intstr.FromString(name)
+	return networkingv1.NetworkPolicyPort{
+		Protocol: &protocol,
+		Port:     &port,
+	}
+}
+
+// AssembleNetworkPolicy assembles the NetworkPolicy for a component's pods. publicPorts are open to all clients, which is usually just
+// the peer port. The daemon and RPC ports are open to the config's RPC clients and the operator, and the chia-exporter port to its metrics clients.
+func AssembleNetworkPolicy(ctx context.Context, meta metav1.ObjectMeta, podSelector map[string]string, config k8schianetv1.NetworkPolicyConfig, publicPorts ...networkingv1.NetworkPolicyPort) networkingv1.NetworkPolicy {
+	rpcFrom := append([]networkingv1.NetworkPolicyPeer{}, config.RPCFrom...)
+	if len(rpcFrom) == 0 {
+		rpcFrom = []networkingv1.NetworkPolicyPeer{
+			{
+				PodSelector: &metav1.LabelSelector{},
+			},
+		}
+	}
+	rpcFrom = append(rpcFrom, getOperatorPeers()...)
+
+	metricsFrom := config.MetricsFrom
+	if len(metricsFrom) == 0 {
+		monitoringNamespace := config.MonitoringNamespace
+		if monitoringNamespace == "" {
+			monitoringNamespace = defaultMonitoringNamespace
+		}
+		metricsFrom = []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"kubernetes.io/metadata.name": monitoringNamespace,
+					},
+				},
+			},
+		}
+	}
+
+	var ingress []networkingv1.NetworkPolicyIngressRule
+	// An ingress rule without ports allows every port, so the public rule is only added with ports
+	if len(publicPorts) > 0 {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: publicPorts,
+		})
+	}
+	ingress = append(ingress, []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				NamedPort("daemon", corev1.ProtocolTCP),
+				NamedPort("rpc", corev1.ProtocolTCP),
+			},
+			From: rpcFrom,
+		},
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				NamedPort("metrics", corev1.ProtocolTCP),
+			},
+			From: metricsFrom,
+		},
+	}...)
+	ingress = append(ingress, config.AdditionalIngress...)
+
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: meta,
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: podSelector,
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}
+}
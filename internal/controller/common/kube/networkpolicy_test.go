@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// namespacePeer gives a NetworkPolicy peer selecting every pod in a namespace
+func namespacePeer(namespace string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace},
+		},
+	}
+}
+
+// rpcRule gives the ingress rule for the daemon and RPC ports from the given peers
+func rpcRule(from ...networkingv1.NetworkPolicyPeer) networkingv1.NetworkPolicyIngressRule {
+	return networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{NamedPort("daemon", corev1.ProtocolTCP), NamedPort("rpc", corev1.ProtocolTCP)},
+		From:  from,
+	}
+}
+
+// metricsRule gives the ingress rule for the chia-exporter port from the given peers
+func metricsRule(from ...networkingv1.NetworkPolicyPeer) networkingv1.NetworkPolicyIngressRule {
+	return networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{NamedPort("metrics", corev1.ProtocolTCP)},
+		From:  from,
+	}
+}
+
+func TestAssembleNetworkPolicy(t *testing.T) {
+	sameNamespace := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}}
+	operator := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": "chia-operator-system"},
+		},
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{operatorPodLabel: "controller-manager"},
+		},
+	}
+	peers := NamedPort("peers", corev1.ProtocolTCP)
+	additional := networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{NamedPort("farmer", corev1.ProtocolTCP)},
+		From:  []networkingv1.NetworkPolicyPeer{namespacePeer("harvesters")},
+	}
+
+	tests := []struct {
+		name              string
+		operatorNamespace string
+		config            k8schianetv1.NetworkPolicyConfig
+		publicPorts       []networkingv1.NetworkPolicyPort
+		expected          []networkingv1.NetworkPolicyIngressRule
+	}{
+		{
+			name: "defaults without an operator namespace",
+			expected: []networkingv1.NetworkPolicyIngressRule{
+				rpcRule(sameNamespace),
+				metricsRule(namespacePeer("monitoring")),
+			},
+		},
+		{
+			name:              "defaults with public ports and the operator",
+			operatorNamespace: "chia-operator-system",
+			publicPorts:       []networkingv1.NetworkPolicyPort{peers},
+			expected: []networkingv1.NetworkPolicyIngressRule{
+				{Ports: []networkingv1.NetworkPolicyPort{peers}},
+				rpcRule(sameNamespace, operator),
+				metricsRule(namespacePeer("monitoring")),
+			},
+		},
+		{
+			name: "monitoring namespace",
+			config: k8schianetv1.NetworkPolicyConfig{
+				MonitoringNamespace: "prometheus",
+			},
+			expected: []networkingv1.NetworkPolicyIngressRule{
+				rpcRule(sameNamespace),
+				metricsRule(namespacePeer("prometheus")),
+			},
+		},
+		{
+			name:              "configured clients and additional ingress",
+			operatorNamespace: "chia-operator-system",
+			config: k8schianetv1.NetworkPolicyConfig{
+				RPCFrom:             []networkingv1.NetworkPolicyPeer{namespacePeer("dashboards")},
+				MetricsFrom:         []networkingv1.NetworkPolicyPeer{namespacePeer("victoria-metrics")},
+				MonitoringNamespace: "prometheus",
+				AdditionalIngress:   []networkingv1.NetworkPolicyIngressRule{additional},
+			},
+			expected: []networkingv1.NetworkPolicyIngressRule{
+				rpcRule(namespacePeer("dashboards"), operator),
+				metricsRule(namespacePeer("victoria-metrics")),
+				additional,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(operatorNamespaceEnv, tt.operatorNamespace)
+			meta := metav1.ObjectMeta{Name: "node", Namespace: "chia"}
+			selector := map[string]string{"app.kubernetes.io/instance": "node"}
+
+			policy := AssembleNetworkPolicy(context.Background(), meta, selector, tt.config, tt.publicPorts...)
+			if diff := cmp.Diff(tt.expected, policy.Spec.Ingress); diff != "" {
+				t.Errorf("Ingress rules do not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff(selector, policy.Spec.PodSelector.MatchLabels); diff != "" {
+				t.Errorf("Pod selector does not match. Diff: %s", diff)
+			}
+			if diff := cmp.Diff([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes); diff != "" {
+				t.Errorf("Policy types do not match. Diff: %s", diff)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -20,16 +21,61 @@ func ReconcileService(ctx context.Context, rec reconciler.ResourceReconciler, se
 	return rec.ReconcileResource(&service, reconciler.StatePresent)
 }
 
+// RemoveService uses the ResourceReconciler to delete the service resource if it exists
+func RemoveService(ctx context.Context, rec reconciler.ResourceReconciler, service corev1.Service) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&service, reconciler.StateAbsent)
+}
+
+// ReconcileSecret uses the ResourceReconciler to determine if the secret resource needs to be created or updated
+func ReconcileSecret(ctx context.Context, rec reconciler.ResourceReconciler, secret corev1.Secret) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&secret, reconciler.StatePresent)
+}
+
+// RemoveSecret uses the ResourceReconciler to delete the secret resource if it exists
+func RemoveSecret(ctx context.Context, rec reconciler.ResourceReconciler, secret corev1.Secret) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&secret, reconciler.StateAbsent)
+}
+
 // ReconcileDeployment uses the ResourceReconciler to determine if the deployment resource needs to be created or updated
 func ReconcileDeployment(ctx context.Context, rec reconciler.ResourceReconciler, deploy appsv1.Deployment) (*reconcile.Result, error) {
 	return rec.ReconcileResource(&deploy, reconciler.StatePresent)
 }
 
+// RemoveDeployment uses the ResourceReconciler to delete the deployment resource if it exists
+func RemoveDeployment(ctx context.Context, rec reconciler.ResourceReconciler, deploy appsv1.Deployment) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&deploy, reconciler.StateAbsent)
+}
+
+// ReconcileDaemonSet uses the ResourceReconciler to determine if the daemonset resource needs to be created or updated
+func ReconcileDaemonSet(ctx context.Context, rec reconciler.ResourceReconciler, daemonSet appsv1.DaemonSet) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&daemonSet, reconciler.StatePresent)
+}
+
+// RemoveDaemonSet uses the ResourceReconciler to delete the daemonset resource if it exists
+func RemoveDaemonSet(ctx context.Context, rec reconciler.ResourceReconciler, daemonSet appsv1.DaemonSet) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&daemonSet, reconciler.StateAbsent)
+}
+
 // ReconcileStatefulset uses the ResourceReconciler to determine if the statefulset resource needs to be created or updated
 func ReconcileStatefulset(ctx context.Context, rec reconciler.ResourceReconciler, stateful appsv1.StatefulSet) (*reconcile.Result, error) {
 	return rec.ReconcileResource(&stateful, reconciler.StatePresent)
 }
 
+// RemoveStatefulset uses the ResourceReconciler to delete the statefulset resource if it exists
+func RemoveStatefulset(ctx context.Context, rec reconciler.ResourceReconciler, stateful appsv1.StatefulSet) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&stateful, reconciler.StateAbsent)
+}
+
+// ReconcileConfigMap uses the ResourceReconciler to determine if the configmap resource needs to be created or updated
+func ReconcileConfigMap(ctx context.Context, rec reconciler.ResourceReconciler, configMap corev1.ConfigMap) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&configMap, reconciler.StatePresent)
+}
+
+// RemoveConfigMap uses the ResourceReconciler to delete the configmap resource if it exists
+func RemoveConfigMap(ctx context.Context, rec reconciler.ResourceReconciler, configMap corev1.ConfigMap) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&configMap, reconciler.StateAbsent)
+}
+
 // ReconcileServiceAccount uses the ResourceReconciler to determine if the serviceaccount resource needs to be created or updated
 func ReconcileServiceAccount(ctx context.Context, rec reconciler.ResourceReconciler, sa corev1.ServiceAccount) (*reconcile.Result, error) {
 	return rec.ReconcileResource(&sa, reconciler.StatePresent)
@@ -49,3 +95,23 @@ func ReconcileRoleBinding(ctx context.Context, rec reconciler.ResourceReconciler
 func ReconcileJob(ctx context.Context, rec reconciler.ResourceReconciler, job batchv1.Job) (*reconcile.Result, error) {
 	return rec.ReconcileResource(&job, reconciler.StatePresent)
 }
+
+// ReconcileCronJob uses the ResourceReconciler to determine if the cronjob resource needs to be created or updated
+func ReconcileCronJob(ctx context.Context, rec reconciler.ResourceReconciler, cronJob batchv1.CronJob) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&cronJob, reconciler.StatePresent)
+}
+
+// RemoveCronJob uses the ResourceReconciler to delete the cronjob resource if it exists
+func RemoveCronJob(ctx context.Context, rec reconciler.ResourceReconciler, cronJob batchv1.CronJob) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&cronJob, reconciler.StateAbsent)
+}
+
+// ReconcileNetworkPolicy uses the ResourceReconciler to determine if the NetworkPolicy resource needs to be created or updated
+func ReconcileNetworkPolicy(ctx context.Context, rec reconciler.ResourceReconciler, policy networkingv1.NetworkPolicy) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&policy, reconciler.StatePresent)
+}
+
+// RemoveNetworkPolicy uses the ResourceReconciler to delete the NetworkPolicy resource if it exists
+func RemoveNetworkPolicy(ctx context.Context, rec reconciler.ResourceReconciler, policy networkingv1.NetworkPolicy) (*reconcile.Result, error) {
+	return rec.ReconcileResource(&policy, reconciler.StateAbsent)
+}
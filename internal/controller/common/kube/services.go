@@ -0,0 +1,97 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cisco-open/operator-tools/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// ShouldMakeService says whether a Service is enabled by its config. Services are enabled unless explicitly disabled.
+func ShouldMakeService(config k8schianetv1.ServiceConfig) bool {
+	return config.Enabled == nil || *config.Enabled
+}
+
+// ApplyServiceConfig applies a ServiceConfig to an assembled Service. The type and load balancer settings are left alone for headless Services,
+// and the external traffic policy is only set for Services that are exposed outside the cluster.
+func ApplyServiceConfig(srv *corev1.Service, config k8schianetv1.ServiceConfig) {
+	if len(config.Labels) > 0 {
+		labels := make(map[string]string)
+		for k, v := range srv.Labels {
+			labels[k] = v
+		}
+		for k, v := range config.Labels {
+			labels[k] = v
+		}
+		srv.Labels = labels
+	}
+
+	if len(config.Annotations) > 0 {
+		annotations := make(map[string]string)
+		for k, v := range srv.Annotations {
+			annotations[k] = v
+		}
+		for k, v := range config.Annotations {
+			annotations[k] = v
+		}
+		srv.Annotations = annotations
+	}
+
+	if config.SessionAffinity != "" {
+		srv.Spec.SessionAffinity = config.SessionAffinity
+	}
+
+	if config.IPFamilyPolicy != nil {
+		srv.Spec.IPFamilyPolicy = config.IPFamilyPolicy
+	}
+
+	if len(config.Ports) > 0 {
+		var ports []corev1.ServicePort
+		for _, port := range srv.Spec.Ports {
+			for _, name := range config.Ports {
+				if port.Name == string(name) {
+					ports = append(ports, port)
+					break
+				}
+			}
+		}
+		srv.Spec.Ports = ports
+	}
+
+	if srv.Spec.ClusterIP == corev1.ClusterIPNone {
+		return
+	}
+
+	if config.Type != "" {
+		srv.Spec.Type = config.Type
+	}
+
+	if srv.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		srv.Spec.LoadBalancerIP = config.LoadBalancerIP
+		srv.Spec.LoadBalancerClass = config.LoadBalancerClass
+	}
+
+	if config.ExternalTrafficPolicy != "" && (srv.Spec.Type == corev1.ServiceTypeNodePort || srv.Spec.Type == corev1.ServiceTypeLoadBalancer) {
+		srv.Spec.ExternalTrafficPolicy = config.ExternalTrafficPolicy
+	}
+}
+
+// ReconcileConfiguredService creates or updates a Service if its config enables it, or deletes it if its config disables it.
+// Returns an error without changing the Service if its ports list doesn't include any of the Service's ports.
+func ReconcileConfiguredService(ctx context.Context, rec reconciler.ResourceReconciler, service corev1.Service, config k8schianetv1.ServiceConfig) (*reconcile.Result, error) {
+	if !ShouldMakeService(config) {
+		return RemoveService(ctx, rec, service)
+	}
+	if len(service.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("Service %s ports %v doesn't include any of the Service's ports", service.Name, config.Ports)
+	}
+	return ReconcileService(ctx, rec, service)
+}
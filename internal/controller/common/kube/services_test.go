@@ -0,0 +1,210 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cisco-open/operator-tools/pkg/reconciler"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// newTestService gives a Service with the chia peers and RPC ports, which is headless if clusterIP is None
+func newTestService(clusterIP string) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node",
+			Namespace:   "chia",
+			Labels:      map[string]string{"app.kubernetes.io/name": "node"},
+			Annotations: map[string]string{"owner": "chia"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: clusterIP,
+			Ports: []corev1.ServicePort{
+				{Name: "peers", Port: 8444},
+				{Name: "rpc", Port: 8555},
+			},
+		},
+	}
+}
+
+func TestShouldMakeService(t *testing.T) {
+	enabled := true
+	disabled := false
+	tests := []struct {
+		name     string
+		config   k8schianetv1.ServiceConfig
+		expected bool
+	}{
+		{name: "unset", expected: true},
+		{name: "enabled", config: k8schianetv1.ServiceConfig{Enabled: &enabled}, expected: true},
+		{name: "disabled", config: k8schianetv1.ServiceConfig{Enabled: &disabled}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := ShouldMakeService(tt.config); actual != tt.expected {
+				t.Errorf("Expected %t, got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestApplyServiceConfig(t *testing.T) {
+	lbClass := "example.com/lb"
+	singleStack := corev1.IPFamilyPolicySingleStack
+	tests := []struct {
+		name      string
+		clusterIP string
+		config    k8schianetv1.ServiceConfig
+		expected  func(srv *corev1.Service)
+	}{
+		{
+			name:     "empty config",
+			expected: func(srv *corev1.Service) {},
+		},
+		{
+			name: "metadata is merged",
+			config: k8schianetv1.ServiceConfig{
+				AdditionalMetadata: k8schianetv1.AdditionalMetadata{
+					Labels:      map[string]string{"team": "chia"},
+					Annotations: map[string]string{"owner": "farm"},
+				},
+			},
+			expected: func(srv *corev1.Service) {
+				srv.Labels = map[string]string{"app.kubernetes.io/name": "node", "team": "chia"}
+				srv.Annotations = map[string]string{"owner": "farm"}
+			},
+		},
+		{
+			name: "load balancer",
+			config: k8schianetv1.ServiceConfig{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				LoadBalancerIP:        "203.0.113.10",
+				LoadBalancerClass:     &lbClass,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+			},
+			expected: func(srv *corev1.Service) {
+				srv.Spec.Type = corev1.ServiceTypeLoadBalancer
+				srv.Spec.LoadBalancerIP = "203.0.113.10"
+				srv.Spec.LoadBalancerClass = &lbClass
+				srv.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyLocal
+			},
+		},
+		{
+			name: "load balancer settings ignored for other types",
+			config: k8schianetv1.ServiceConfig{
+				LoadBalancerIP:        "203.0.113.10",
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+			},
+			expected: func(srv *corev1.Service) {},
+		},
+		{
+			name: "ports filter",
+			config: k8schianetv1.ServiceConfig{
+				Ports: []k8schianetv1.ServicePortName{"rpc"},
+			},
+			expected: func(srv *corev1.Service) {
+				srv.Spec.Ports = []corev1.ServicePort{{Name: "rpc", Port: 8555}}
+			},
+		},
+		{
+			name: "ports filter without any matching ports",
+			config: k8schianetv1.ServiceConfig{
+				Ports: []k8schianetv1.ServicePortName{"metrics"},
+			},
+			expected: func(srv *corev1.Service) {
+				srv.Spec.Ports = nil
+			},
+		},
+		{
+			name:      "headless service keeps its type",
+			clusterIP: corev1.ClusterIPNone,
+			config: k8schianetv1.ServiceConfig{
+				Type:            corev1.ServiceTypeLoadBalancer,
+				SessionAffinity: corev1.ServiceAffinityClientIP,
+				IPFamilyPolicy:  &singleStack,
+			},
+			expected: func(srv *corev1.Service) {
+				srv.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+				srv.Spec.IPFamilyPolicy = &singleStack
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := newTestService(tt.clusterIP)
+			tt.expected(&expected)
+
+			actual := newTestService(tt.clusterIP)
+			ApplyServiceConfig(&actual, tt.config)
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				t.Errorf("Service does not match. Diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileConfiguredService(t *testing.T) {
+	disabled := false
+	existing := newTestService("")
+	tests := []struct {
+		name           string
+		existing       []client.Object
+		ports          []corev1.ServicePort
+		config         k8schianetv1.ServiceConfig
+		expectErr      bool
+		expectedExists bool
+	}{
+		{
+			name:           "creates an enabled Service",
+			ports:          existing.Spec.Ports,
+			expectedExists: true,
+		},
+		{
+			name:     "deletes a disabled Service",
+			existing: []client.Object{existing.DeepCopy()},
+			ports:    existing.Spec.Ports,
+			config:   k8schianetv1.ServiceConfig{Enabled: &disabled},
+		},
+		{
+			name:           "leaves a Service without ports alone",
+			existing:       []client.Object{existing.DeepCopy()},
+			config:         k8schianetv1.ServiceConfig{Ports: []k8schianetv1.ServicePortName{"metrics"}},
+			expectErr:      true,
+			expectedExists: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.existing...).Build()
+			srv := newTestService("")
+			srv.Spec.Ports = tt.ports
+
+			_, err := ReconcileConfiguredService(context.Background(), reconciler.NewReconcilerWith(c), srv, tt.config)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error to be %t, got %v", tt.expectErr, err)
+			}
+
+			var actual corev1.Service
+			err = c.Get(context.Background(), types.NamespacedName{Namespace: "chia", Name: "node"}, &actual)
+			if exists := !errors.IsNotFound(err); exists != tt.expectedExists {
+				t.Errorf("Expected Service to exist to be %t, got %t", tt.expectedExists, exists)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// chiaRootClaimNamePattern is the name of a CHIA_ROOT PersistentVolumeClaim the operator creates, given the name of the component's workload
+const chiaRootClaimNamePattern = "%s-chiaroot"
+
+// GetGeneratedChiaRootClaimConfig gives the CHIA_ROOT claim config if the operator should create the claim from its storage class and size,
+// rather than use an existing claim. Returns nil otherwise.
+func GetGeneratedChiaRootClaimConfig(storage *k8schianetv1.StorageConfig) *k8schianetv1.PersistentVolumeClaimConfig {
+	if storage == nil || storage.ChiaRoot == nil || storage.ChiaRoot.PersistentVolumeClaim == nil {
+		return nil
+	}
+	pvc := storage.ChiaRoot.PersistentVolumeClaim
+	if pvc.ClaimName != "" || pvc.ResourceRequest == "" {
+		return nil
+	}
+	return pvc
+}
+
+// GetChiaRootClaimName gives the name of the CHIA_ROOT claim in a component's storage config, which is the generated claim's name if the operator creates it
+func GetChiaRootClaimName(workloadName string, storage *k8schianetv1.StorageConfig) string {
+	if GetGeneratedChiaRootClaimConfig(storage) != nil {
+		return fmt.Sprintf(chiaRootClaimNamePattern, workloadName)
+	}
+	return storage.ChiaRoot.PersistentVolumeClaim.ClaimName
+}
+
+// ValidateResourceRequests checks that each claim config's resourceRequest is a valid quantity, so a CR with an invalid request
+// is rejected before volume claims or claim templates are assembled from it
+func ValidateResourceRequests(configs ...*k8schianetv1.PersistentVolumeClaimConfig) error {
+	for _, config := range configs {
+		if config == nil || config.ResourceRequest == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(config.ResourceRequest); err != nil {
+			return fmt.Errorf("invalid resourceRequest %q: %v", config.ResourceRequest, err)
+		}
+	}
+	return nil
+}
+
+// AssembleChiaRootClaim assembles a generated CHIA_ROOT claim. Claims with the Delete retention policy are owned by the CR, so they're garbage collected with it.
+func AssembleChiaRootClaim(ctx context.Context, workloadName string, namespace string, labels map[string]string, ownerRefs []metav1.OwnerReference, config k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	request, err := resource.ParseQuantity(config.ResourceRequest)
+	if err != nil {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("invalid resourceRequest %q: %v", config.ResourceRequest, err)
+	}
+
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(chiaRootClaimNamePattern, workloadName),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: request,
+				},
+			},
+		},
+	}
+
+	// An empty storage class would disable dynamic provisioning, leave it unset to use the cluster's default
+	if config.StorageClass != "" {
+		pvc.Spec.StorageClassName = &config.StorageClass
+	}
+
+	if config.RetentionPolicy == appsv1.DeletePersistentVolumeClaimRetentionPolicyType {
+		pvc.OwnerReferences = ownerRefs
+	}
+
+	return pvc, nil
+}
+
+// ReconcilePersistentVolumeClaim creates a generated PersistentVolumeClaim if it doesn't exist. Most of a claim's spec is immutable,
+// so an existing claim only has its labels, owner references, and a larger storage request applied. A claim with the same name that
+// the operator didn't create for this CR is left alone and returns an error, rather than being adopted and possibly deleted with the CR.
+func ReconcilePersistentVolumeClaim(ctx context.Context, c client.Client, pvc corev1.PersistentVolumeClaim) error {
+	var current corev1.PersistentVolumeClaim
+	err := c.Get(ctx, types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name}, &current)
+	if err != nil && errors.IsNotFound(err) {
+		return c.Create(ctx, &pvc)
+	}
+	if err != nil {
+		return err
+	}
+	if current.Labels[provenanceLabel] != pvc.Labels[provenanceLabel] {
+		return fmt.Errorf("PersistentVolumeClaim %s/%s already exists and was not created by the operator for this resource", pvc.Namespace, pvc.Name)
+	}
+
+	patch := client.MergeFrom(current.DeepCopy())
+	if current.Labels == nil {
+		current.Labels = make(map[string]string)
+	}
+	for k, v := range pvc.Labels {
+		current.Labels[k] = v
+	}
+	current.OwnerReferences = pvc.OwnerReferences
+
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	currentRequest := current.Spec.Resources.Requests[corev1.ResourceStorage]
+	if currentRequest.Cmp(requested) < 0 {
+		if current.Spec.Resources.Requests == nil {
+			current.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		current.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+	}
+
+	return c.Patch(ctx, &current, patch)
+}
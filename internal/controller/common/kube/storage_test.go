@@ -0,0 +1,261 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// newChiaRootStorage gives a storage config with the given CHIA_ROOT claim config
+func newChiaRootStorage(pvc *k8schianetv1.PersistentVolumeClaimConfig) *k8schianetv1.StorageConfig {
+	return &k8schianetv1.StorageConfig{
+		ChiaRoot: &k8schianetv1.ChiaRootConfig{PersistentVolumeClaim: pvc},
+	}
+}
+
+func TestGetChiaRootClaimName(t *testing.T) {
+	tests := []struct {
+		name              string
+		storage           *k8schianetv1.StorageConfig
+		expectedGenerated bool
+		expectedName      string
+	}{
+		{
+			name:         "existing claim",
+			storage:      newChiaRootStorage(&k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "farmer-root"}),
+			expectedName: "farmer-root",
+		},
+		{
+			name:         "existing claim takes precedence over a resource request",
+			storage:      newChiaRootStorage(&k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "farmer-root", ResourceRequest: "10Gi"}),
+			expectedName: "farmer-root",
+		},
+		{
+			name:              "generated claim",
+			storage:           newChiaRootStorage(&k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"}),
+			expectedGenerated: true,
+			expectedName:      "farmer-farmer-chiaroot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if generated := GetGeneratedChiaRootClaimConfig(tt.storage) != nil; generated != tt.expectedGenerated {
+				t.Errorf("Expected generated to be %t, got %t", tt.expectedGenerated, generated)
+			}
+			if actual := GetChiaRootClaimName("farmer-farmer", tt.storage); actual != tt.expectedName {
+				t.Errorf("Expected claim name %s, got %s", tt.expectedName, actual)
+			}
+		})
+	}
+
+	for _, storage := range []*k8schianetv1.StorageConfig{nil, {}, {ChiaRoot: &k8schianetv1.ChiaRootConfig{}}} {
+		if GetGeneratedChiaRootClaimConfig(storage) != nil {
+			t.Errorf("Expected no generated claim config for %v", storage)
+		}
+	}
+}
+
+func TestValidateResourceRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   []*k8schianetv1.PersistentVolumeClaimConfig
+		expectErr bool
+	}{
+		{
+			name: "no configs",
+		},
+		{
+			name:    "nil and empty requests",
+			configs: []*k8schianetv1.PersistentVolumeClaimConfig{nil, {ClaimName: "farmer-root"}},
+		},
+		{
+			name:    "valid requests",
+			configs: []*k8schianetv1.PersistentVolumeClaimConfig{{ResourceRequest: "10Gi"}, {ResourceRequest: "500M"}},
+		},
+		{
+			name:      "invalid request",
+			configs:   []*k8schianetv1.PersistentVolumeClaimConfig{{ResourceRequest: "10Gi"}, {ResourceRequest: "lots"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResourceRequests(tt.configs...)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error to be %t, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestAssembleChiaRootClaim(t *testing.T) {
+	storageClass := "local-nvme"
+	labels := map[string]string{provenanceLabel: "ChiaFarmer.chia.farmer"}
+	ownerRefs := []metav1.OwnerReference{{APIVersion: "k8s.chia.net/v1", Kind: "ChiaFarmer", Name: "farmer", UID: "farmer-uid"}}
+
+	tests := []struct {
+		name      string
+		config    k8schianetv1.PersistentVolumeClaimConfig
+		expected  corev1.PersistentVolumeClaimSpec
+		owned     bool
+		expectErr bool
+	}{
+		{
+			name:   "default storage class retained",
+			config: k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "10Gi"},
+			expected: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		},
+		{
+			name: "storage class deleted with the CR",
+			config: k8schianetv1.PersistentVolumeClaimConfig{
+				ResourceRequest: "10Gi",
+				StorageClass:    storageClass,
+				RetentionPolicy: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+			},
+			expected: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+				StorageClassName: &storageClass,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+			owned: true,
+		},
+		{
+			name:      "invalid request",
+			config:    k8schianetv1.PersistentVolumeClaimConfig{ResourceRequest: "lots"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvc, err := AssembleChiaRootClaim(context.Background(), "farmer-farmer", "chia", labels, ownerRefs, tt.config)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error to be %t, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+
+			if pvc.Name != "farmer-farmer-chiaroot" || pvc.Namespace != "chia" {
+				t.Errorf("Expected claim chia/farmer-farmer-chiaroot, got %s/%s", pvc.Namespace, pvc.Name)
+			}
+			if diff := cmp.Diff(tt.expected, pvc.Spec); diff != "" {
+				t.Errorf("Claim spec does not match. Diff: %s", diff)
+			}
+			if owned := len(pvc.OwnerReferences) > 0; owned != tt.owned {
+				t.Errorf("Expected claim to be owned to be %t, got %t", tt.owned, owned)
+			}
+		})
+	}
+}
+
+func TestReconcilePersistentVolumeClaim(t *testing.T) {
+	newClaim := func(provenance string, request string, owned bool) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "farmer-farmer-chiaroot",
+				Namespace: "chia",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(request)},
+				},
+			},
+		}
+		if provenance != "" {
+			pvc.Labels = map[string]string{provenanceLabel: provenance}
+		}
+		if owned {
+			pvc.OwnerReferences = []metav1.OwnerReference{{APIVersion: "k8s.chia.net/v1", Kind: "ChiaFarmer", Name: "farmer", UID: "farmer-uid"}}
+		}
+		return pvc
+	}
+
+	tests := []struct {
+		name            string
+		existing        []client.Object
+		desired         *corev1.PersistentVolumeClaim
+		expectErr       bool
+		expectedRequest string
+		expectedOwned   bool
+	}{
+		{
+			name:            "creates the claim",
+			desired:         newClaim("ChiaFarmer.chia.farmer", "10Gi", true),
+			expectedRequest: "10Gi",
+			expectedOwned:   true,
+		},
+		{
+			name:            "expands a smaller claim",
+			existing:        []client.Object{newClaim("ChiaFarmer.chia.farmer", "10Gi", false)},
+			desired:         newClaim("ChiaFarmer.chia.farmer", "20Gi", false),
+			expectedRequest: "20Gi",
+		},
+		{
+			name:            "doesn't shrink a larger claim",
+			existing:        []client.Object{newClaim("ChiaFarmer.chia.farmer", "20Gi", false)},
+			desired:         newClaim("ChiaFarmer.chia.farmer", "10Gi", false),
+			expectedRequest: "20Gi",
+		},
+		{
+			name:            "retention policy change updates owner references",
+			existing:        []client.Object{newClaim("ChiaFarmer.chia.farmer", "10Gi", true)},
+			desired:         newClaim("ChiaFarmer.chia.farmer", "10Gi", false),
+			expectedRequest: "10Gi",
+		},
+		{
+			name:            "leaves a claim the operator didn't create alone",
+			existing:        []client.Object{newClaim("", "10Gi", false)},
+			desired:         newClaim("ChiaFarmer.chia.farmer", "20Gi", true),
+			expectErr:       true,
+			expectedRequest: "10Gi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.existing...).Build()
+
+			err := ReconcilePersistentVolumeClaim(context.Background(), c, *tt.desired)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error to be %t, got %v", tt.expectErr, err)
+			}
+
+			var actual corev1.PersistentVolumeClaim
+			err = c.Get(context.Background(), types.NamespacedName{Namespace: "chia", Name: "farmer-farmer-chiaroot"}, &actual)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			request := actual.Spec.Resources.Requests[corev1.ResourceStorage]
+			if request.String() != tt.expectedRequest {
+				t.Errorf("Expected request %s, got %s", tt.expectedRequest, request.String())
+			}
+			if owned := len(actual.OwnerReferences) > 0; owned != tt.expectedOwned {
+				t.Errorf("Expected claim to be owned to be %t, got %t", tt.expectedOwned, owned)
+			}
+		})
+	}
+}
@@ -22,9 +22,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	apiv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/chiarpc"
+	"github.com/chia-network/chia-operator/internal/chiarpc/chiarpctest"
 	"github.com/chia-network/chia-operator/internal/controller/chiaca"
 	"github.com/chia-network/chia-operator/internal/controller/chiafarmer"
 	"github.com/chia-network/chia-operator/internal/controller/chiaharvester"
+	"github.com/chia-network/chia-operator/internal/controller/chiamaintenance"
 	"github.com/chia-network/chia-operator/internal/controller/chianode"
 	"github.com/chia-network/chia-operator/internal/controller/chiaseeder"
 	"github.com/chia-network/chia-operator/internal/controller/chiatimelord"
@@ -41,6 +44,10 @@ var (
 	testEnv   *envtest.Environment
 	ctx       context.Context
 	cancel    context.CancelFunc
+
+	// rpcServer serves the RPC queries of the controllers that query chia RPC servers, signed by the CA in rpcCASecretData
+	rpcServer       *chiarpctest.Server
+	rpcCASecretData map[string][]byte
 )
 
 const (
@@ -85,6 +92,11 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	rpcCASecretData, err = chiarpctest.NewCASecretData()
+	Expect(err).ToNot(HaveOccurred())
+	rpcServer, err = chiarpctest.NewServer(rpcCASecretData)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&chiaca.ChiaCAReconciler{
 		Client:   k8sManager.GetClient(),
 		Scheme:   k8sManager.GetScheme(),
@@ -106,10 +118,21 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&chiamaintenance.ChiaMaintenanceReconciler{
+		Client:             k8sManager.GetClient(),
+		Scheme:             k8sManager.GetScheme(),
+		Recorder:           k8sManager.GetEventRecorderFor("chiamaintenance-controller"),
+		NodePodConfig:      chianode.GetMaintenancePodConfig,
+		HarvesterPodConfig: chiaharvester.GetMaintenancePodConfig,
+		WalletPodConfig:    chiawallet.GetMaintenancePodConfig,
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&chianode.ChiaNodeReconciler{
-		Client:   k8sManager.GetClient(),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: k8sManager.GetEventRecorderFor("chianode-controller"),
+		Client:     k8sManager.GetClient(),
+		Scheme:     k8sManager.GetScheme(),
+		Recorder:   k8sManager.GetEventRecorderFor("chianode-controller"),
+		RPCOptions: []chiarpc.Option{rpcServer.DialOption()},
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -121,9 +144,10 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 
 	err = (&chiatimelord.ChiaTimelordReconciler{
-		Client:   k8sManager.GetClient(),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: k8sManager.GetEventRecorderFor("chiatimelord-controller"),
+		Client:     k8sManager.GetClient(),
+		Scheme:     k8sManager.GetScheme(),
+		Recorder:   k8sManager.GetEventRecorderFor("chiatimelord-controller"),
+		RPCOptions: []chiarpc.Option{rpcServer.DialOption()},
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -143,6 +167,7 @@ var _ = BeforeSuite(func() {
 
 var _ = AfterSuite(func() {
 	cancel()
+	rpcServer.Close()
 	By("tearing down the test environment")
 	err := testEnv.Stop()
 	if err != nil {
@@ -1,84 +1,56 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var (
-	// ChiaCAs is a gauge metric that keeps a running total of deployed ChiaCAs
-	ChiaCAs = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chiaca_total",
-			Help: "Number of ChiaCA objects controlled by this operator",
-		},
-	)
-
-	// ChiaFarmers is a gauge metric that keeps a running total of deployed ChiaFarmers
-	ChiaFarmers = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chiafarmer_total",
-			Help: "Number of ChiaFarmer objects controlled by this operator",
-		},
-	)
-
-	// ChiaHarvesters is a gauge metric that keeps a running total of deployed ChiaHarvesters
-	ChiaHarvesters = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chiaharvester_total",
-			Help: "Number of ChiaHarvester objects controlled by this operator",
-		},
-	)
-
-	// ChiaNodes is a gauge metric that keeps a running total of deployed ChiaNodes
-	ChiaNodes = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chianode_total",
-			Help: "Number of ChiaNode objects controlled by this operator",
-		},
-	)
-
-	// ChiaSeeders is a gauge metric that keeps a running total of deployed ChiaSeeders
-	ChiaSeeders = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chiaseeder_total",
-			Help: "Number of ChiaSeeder objects controlled by this operator",
-		},
-	)
-
-	// ChiaTimelords is a gauge metric that keeps a running total of deployed ChiaTimelords
-	ChiaTimelords = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chiatimelord_total",
-			Help: "Number of ChiaTimelord objects controlled by this operator",
+	// OperatorErrors is a counter of the number of errors this exporter has encountered since it started
+	OperatorErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "chia_operator_errors_total",
+			Help: "Number of errors this exporter has encountered since it started",
 		},
 	)
 
-	// ChiaWallets is a gauge metric that keeps a running total of deployed ChiaWallets
-	ChiaWallets = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "chia_operator_chiawallet_total",
-			Help: "Number of ChiaWallet objects controlled by this operator",
+	// ReconcileErrors is a counter of the errors encountered reconciling each kind of CR, by the type of resource that caused the error
+	ReconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chia_operator_reconcile_errors_total",
+			Help: "Number of errors encountered reconciling each kind of CR, by the type of resource that caused the error",
 		},
+		[]string{"kind", "resource"},
 	)
 
-	// OperatorErrors is a counter of the number of errors this exporter has encountered since it started
-	OperatorErrors = prometheus.NewCounter(
-		prometheus.CounterOpts{
-			Name: "chia_operator_errors_total",
-			Help: "Number of errors this exporter has encountered since it started",
+	// ReconcileDuration is a histogram of how long reconciling each kind of CR takes
+	ReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "chia_operator_reconcile_duration_seconds",
+			Help:    "Time taken to reconcile each kind of CR",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
 		},
+		[]string{"kind"},
 	)
 )
 
 func init() {
 	metrics.Registry.MustRegister(
-		ChiaCAs,
-		ChiaFarmers,
-		ChiaHarvesters,
-		ChiaNodes,
-		ChiaTimelords,
-		ChiaWallets,
 		OperatorErrors,
+		ReconcileErrors,
+		ReconcileDuration,
 	)
 }
+
+// RecordReconcileError counts an error a controller encountered reconciling a CR of the given kind, by the type of resource that caused it
+func RecordReconcileError(kind, resource string) {
+	OperatorErrors.Add(1.0)
+	ReconcileErrors.WithLabelValues(kind, resource).Inc()
+}
+
+// ObserveReconcileDuration records how long a reconcile of a CR of the given kind took. Controllers defer it at the start of Reconcile.
+func ObserveReconcileDuration(kind string, start time.Time) {
+	ReconcileDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+// collectTimeout bounds how long a scrape waits on the cache, which blocks until it has synced after the operator starts
+const collectTimeout = 10 * time.Second
+
+// resourceReady is a gauge of whether each CR reports itself ready
+var resourceReady = prometheus.NewDesc(
+	"chia_operator_resource_ready",
+	"Whether a CR controlled by this operator reports that it is ready",
+	[]string{"kind", "namespace", "name"},
+	nil,
+)
+
+// resourceKind is a kind of CR the resource collector counts
+type resourceKind struct {
+	// kind is the kind of the CR
+	kind string
+
+	// total describes the gauge of the number of CRs of this kind
+	total *prometheus.Desc
+
+	// newList gives an empty list of this kind of CR
+	newList func() client.ObjectList
+}
+
+// newResourceKind describes a kind of CR and the gauge of how many there are
+func newResourceKind(kind, metricName string, newList func() client.ObjectList) resourceKind {
+	return resourceKind{
+		kind: kind,
+		total: prometheus.NewDesc(
+			metricName,
+			"Number of "+kind+" objects controlled by this operator",
+			nil,
+			nil,
+		),
+		newList: newList,
+	}
+}
+
+var resourceKinds = []resourceKind{
+	newResourceKind("ChiaCA", "chia_operator_chiaca_total", func() client.ObjectList { return &k8schianetv1.ChiaCAList{} }),
+	newResourceKind("ChiaFarmer", "chia_operator_chiafarmer_total", func() client.ObjectList { return &k8schianetv1.ChiaFarmerList{} }),
+	newResourceKind("ChiaHarvester", "chia_operator_chiaharvester_total", func() client.ObjectList { return &k8schianetv1.ChiaHarvesterList{} }),
+	newResourceKind("ChiaMaintenance", "chia_operator_chiamaintenance_total", func() client.ObjectList { return &k8schianetv1.ChiaMaintenanceList{} }),
+	newResourceKind("ChiaNode", "chia_operator_chianode_total", func() client.ObjectList { return &k8schianetv1.ChiaNodeList{} }),
+	newResourceKind("ChiaSeeder", "chia_operator_chiaseeder_total", func() client.ObjectList { return &k8schianetv1.ChiaSeederList{} }),
+	newResourceKind("ChiaTimelord", "chia_operator_chiatimelord_total", func() client.ObjectList { return &k8schianetv1.ChiaTimelordList{} }),
+	newResourceKind("ChiaWallet", "chia_operator_chiawallet_total", func() client.ObjectList { return &k8schianetv1.ChiaWalletList{} }),
+}
+
+// getReadiness gives whether a CR reports itself ready, and whether its kind reports readiness at all
+func getReadiness(obj runtime.Object) (ready bool, reportsReadiness bool) {
+	switch o := obj.(type) {
+	case *k8schianetv1.ChiaCA:
+		return o.Status.Ready, true
+	case *k8schianetv1.ChiaFarmer:
+		return o.Status.Ready, true
+	case *k8schianetv1.ChiaHarvester:
+		return o.Status.Ready, true
+	case *k8schianetv1.ChiaNode:
+		return o.Status.Ready, true
+	case *k8schianetv1.ChiaSeeder:
+		return o.Status.Ready, true
+	case *k8schianetv1.ChiaTimelord:
+		return o.Status.Ready, true
+	case *k8schianetv1.ChiaWallet:
+		return o.Status.Ready, true
+	}
+	return false, false
+}
+
+// ResourceCollector computes the number of each kind of CR and their readiness from the manager's cache on each scrape,
+// so the metrics don't depend on which CRs this operator process has reconciled since it started
+type ResourceCollector struct {
+	reader client.Reader
+}
+
+// NewResourceCollector creates a ResourceCollector that lists CRs with the given reader, which should be the manager's cache
+func NewResourceCollector(reader client.Reader) *ResourceCollector {
+	return &ResourceCollector{reader: reader}
+}
+
+// Describe sends the descriptors of the collector's metrics
+func (c *ResourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, k := range resourceKinds {
+		ch <- k.total
+	}
+	ch <- resourceReady
+}
+
+// Collect lists each kind of CR from the cache and sends their totals and readiness. Kinds that can't be listed are left out of the scrape.
+func (c *ResourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	for _, k := range resourceKinds {
+		list := k.newList()
+		err := c.reader.List(ctx, list)
+		if err != nil {
+			log.Log.WithName("metrics").Error(err, "unable to list "+k.kind+" resources for metrics")
+			continue
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			log.Log.WithName("metrics").Error(err, "unable to read "+k.kind+" resources for metrics")
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(k.total, prometheus.GaugeValue, float64(len(items)))
+		for _, item := range items {
+			ready, reportsReadiness := getReadiness(item)
+			if !reportsReadiness {
+				continue
+			}
+			value := 0.0
+			if ready {
+				value = 1.0
+			}
+			obj := item.(client.Object)
+			ch <- prometheus.MustNewConstMetric(resourceReady, prometheus.GaugeValue, value, k.kind, obj.GetNamespace(), obj.GetName())
+		}
+	}
+}
+
+// RegisterResourceCollector registers a ResourceCollector reading from the given reader with controller-runtime's metrics registry
+func RegisterResourceCollector(reader client.Reader) error {
+	return metrics.Registry.Register(NewResourceCollector(reader))
+}
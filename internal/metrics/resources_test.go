@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestResourceCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := k8schianetv1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Error building scheme: %v", err)
+	}
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&k8schianetv1.ChiaNode{
+			ObjectMeta: metav1.ObjectMeta{Name: "mainnet", Namespace: "chia"},
+			Status:     k8schianetv1.ChiaNodeStatus{Ready: true},
+		},
+		&k8schianetv1.ChiaNode{
+			ObjectMeta: metav1.ObjectMeta{Name: "testnet", Namespace: "chia"},
+		},
+		&k8schianetv1.ChiaMaintenance{
+			ObjectMeta: metav1.ObjectMeta{Name: "compact", Namespace: "chia"},
+		},
+	).Build()
+
+	expected := `
+# HELP chia_operator_chiamaintenance_total Number of ChiaMaintenance objects controlled by this operator
+# TYPE chia_operator_chiamaintenance_total gauge
+chia_operator_chiamaintenance_total 1
+# HELP chia_operator_chianode_total Number of ChiaNode objects controlled by this operator
+# TYPE chia_operator_chianode_total gauge
+chia_operator_chianode_total 2
+# HELP chia_operator_chiawallet_total Number of ChiaWallet objects controlled by this operator
+# TYPE chia_operator_chiawallet_total gauge
+chia_operator_chiawallet_total 0
+# HELP chia_operator_resource_ready Whether a CR controlled by this operator reports that it is ready
+# TYPE chia_operator_resource_ready gauge
+chia_operator_resource_ready{kind="ChiaNode",name="mainnet",namespace="chia"} 1
+chia_operator_resource_ready{kind="ChiaNode",name="testnet",namespace="chia"} 0
+`
+	err = testutil.CollectAndCompare(NewResourceCollector(reader), strings.NewReader(expected),
+		"chia_operator_chiamaintenance_total",
+		"chia_operator_chianode_total",
+		"chia_operator_chiawallet_total",
+		"chia_operator_resource_ready",
+	)
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}